@@ -39,8 +39,14 @@ func (a *Agent) Run(ctx context.Context) error {
 		return ctx.Err()
 	}
 
+	log.Printf("D! [agent] Initializing plugins")
+	err := a.initPlugins()
+	if err != nil {
+		return err
+	}
+
 	log.Printf("D! [agent] Connecting outputs")
-	err := a.connectOutputs(ctx)
+	err = a.connectOutputs(ctx)
 	if err != nil {
 		return err
 	}
@@ -138,35 +144,210 @@ func (a *Agent) Run(ctx context.Context) error {
 	return nil
 }
 
-// Test runs the inputs once and prints the output to stdout in line protocol.
-func (a *Agent) Test(ctx context.Context) error {
-	var wg sync.WaitGroup
-	metricC := make(chan telegraf.Metric)
-	nulC := make(chan telegraf.Metric)
-	defer func() {
-		close(metricC)
-		close(nulC)
-		wg.Wait()
-	}()
+// Test runs the inputs once, passes their metrics through the processors and
+// aggregators, and prints the result to stdout in line protocol.  Service
+// inputs are only run when wait is positive, and are given wait to produce
+// metrics.  An error is returned if any plugin reported an error.
+func (a *Agent) Test(ctx context.Context, wait time.Duration) error {
+	err := a.initPlugins()
+	if err != nil {
+		return err
+	}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	s := influx.NewSerializer()
+	s.SetFieldSortOrder(influx.SortFields)
 
-		s := influx.NewSerializer()
-		s.SetFieldSortOrder(influx.SortFields)
-		for metric := range metricC {
+	return a.runOnce(ctx, wait, func(src <-chan telegraf.Metric) error {
+		for metric := range src {
 			octets, err := s.Serialize(metric)
 			if err == nil {
 				fmt.Print("> ", string(octets))
+			}
+			// The metric was not sent anywhere, so it is not acknowledged to
+			// the input as delivered.
+			metric.Reject()
+		}
+		return nil
+	})
+}
+
+// Once runs the inputs once, passes their metrics through the processors and
+// aggregators, and writes the result to the outputs.  Service inputs are
+// only run when wait is positive, and are given wait to produce metrics.  An
+// error is returned if any plugin reported an error, or an output failed to
+// write or dropped metrics.
+func (a *Agent) Once(ctx context.Context, wait time.Duration) error {
+	err := a.initPlugins()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("D! [agent] Connecting outputs")
+	err = a.connectOutputs(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = a.runOnce(ctx, wait, func(src <-chan telegraf.Metric) error {
+		dropped := make([]int64, len(a.Config.Outputs))
+		for i, output := range a.Config.Outputs {
+			dropped[i] = output.MetricsDropped()
+		}
 
+		for metric := range src {
+			for i, output := range a.Config.Outputs {
+				if i == len(a.Config.Outputs)-1 {
+					output.AddMetric(metric)
+				} else {
+					output.AddMetric(metric.Copy())
+				}
+			}
+
+			// Write the batches as they fill so that the metrics fit in
+			// the buffer.
+			for _, output := range a.Config.Outputs {
+				select {
+				case <-output.BatchReady:
+					err := output.WriteBatch()
+					if err != nil {
+						log.Printf("E! [agent] Error writing to output [%s]: %v", output.Name, err)
+					}
+				default:
+				}
 			}
 		}
-	}()
+
+		var failed int
+		for i, output := range a.Config.Outputs {
+			err := output.Write()
+			if err != nil {
+				log.Printf("E! [agent] Error writing to output [%s]: %v", output.Name, err)
+				failed++
+				continue
+			}
+
+			if n := output.MetricsDropped() - dropped[i]; n > 0 {
+				log.Printf("E! [agent] Output [%s] dropped %d metrics, exceeding metric_buffer_limit",
+					output.Name, n)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d outputs failed to write", failed)
+		}
+		return nil
+	})
+
+	log.Printf("D! [agent] Closing outputs")
+	if cerr := a.closeOutputs(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// runOnce gathers every input once, waits for service inputs and passes the
+// metrics through the processors and aggregators to the output function.
+// The aggregators are pushed a single time, after all inputs are done.
+func (a *Agent) runOnce(
+	ctx context.Context,
+	wait time.Duration,
+	outputF func(src <-chan telegraf.Metric) error,
+) error {
+	gatherErrors := NErrors.Get()
+
+	inputC := make(chan telegraf.Metric, 100)
+	procC := make(chan telegraf.Metric, 100)
+	outputC := make(chan telegraf.Metric, 100)
+
+	startTime := time.Now()
+
+	// Service inputs are only run when they are given time to produce
+	// metrics, otherwise they are skipped.
+	if wait > 0 {
+		log.Printf("D! [agent] Starting service inputs")
+		err := a.startServiceInputs(ctx, inputC)
+		if err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	src := inputC
+	dst := inputC
 
 	wg.Add(1)
-	go func() {
+	go func(dst chan telegraf.Metric) {
 		defer wg.Done()
+
+		a.gatherInputsOnce(ctx, dst, wait > 0)
+
+		if wait > 0 {
+			log.Printf("D! [agent] Waiting %s for service inputs", wait)
+			internal.SleepContext(ctx, wait)
+
+			log.Printf("D! [agent] Stopping service inputs")
+			a.stopServiceInputs()
+		}
+
+		close(dst)
+	}(dst)
+
+	src = dst
+
+	if len(a.Config.Processors) > 0 {
+		dst = procC
+
+		wg.Add(1)
+		go func(src, dst chan telegraf.Metric) {
+			defer wg.Done()
+
+			err := a.runProcessors(src, dst)
+			if err != nil {
+				log.Printf("E! [agent] Error running processors: %v", err)
+			}
+			close(dst)
+		}(src, dst)
+
+		src = dst
+	}
+
+	if len(a.Config.Aggregators) > 0 {
+		dst = outputC
+
+		wg.Add(1)
+		go func(src, dst chan telegraf.Metric) {
+			defer wg.Done()
+
+			a.runAggregatorsOnce(startTime, src, dst)
+			close(dst)
+		}(src, dst)
+
+		src = dst
+	}
+
+	err := outputF(src)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+
+	if n := NErrors.Get() - gatherErrors; n > 0 {
+		return fmt.Errorf("%d errors occurred while gathering metrics", n)
+	}
+	return nil
+}
+
+// gatherInputsOnce runs the Gather function of every input once.  Service
+// inputs are skipped unless services is set.
+func (a *Agent) gatherInputsOnce(
+	ctx context.Context,
+	dst chan<- telegraf.Metric,
+	services bool,
+) {
+	nulC := make(chan telegraf.Metric)
+	defer close(nulC)
+	go func() {
 		for range nulC {
 		}
 	}()
@@ -174,43 +355,35 @@ func (a *Agent) Test(ctx context.Context) error {
 	for _, input := range a.Config.Inputs {
 		select {
 		case <-ctx.Done():
-			return nil
+			return
 		default:
-			if _, ok := input.Input.(telegraf.ServiceInput); ok {
-				log.Printf("W!: [agent] skipping plugin [[%s]]: service inputs not supported in --test mode",
-					input.Name())
-				continue
-			}
+		}
 
-			acc := NewAccumulator(input, metricC)
-			acc.SetPrecision(a.Config.Agent.Precision.Duration,
+		if _, ok := input.Input.(telegraf.ServiceInput); ok && !services {
+			log.Printf("W!: [agent] skipping plugin [[%s]]: service inputs not supported in --test mode",
+				input.Name())
+			continue
+		}
+
+		acc := NewAccumulator(input, dst)
+		acc.SetPrecision(a.Config.Agent.Precision.Duration,
+			a.Config.Agent.Interval.Duration)
+		input.SetDefaultTags(a.Config.Tags)
+
+		// Special instructions for some inputs. cpu, for example, needs to be
+		// run twice in order to return cpu usage percentages.
+		switch input.Name() {
+		case "inputs.cpu", "inputs.mongodb", "inputs.procstat":
+			nulAcc := NewAccumulator(input, nulC)
+			nulAcc.SetPrecision(a.Config.Agent.Precision.Duration,
 				a.Config.Agent.Interval.Duration)
-			input.SetDefaultTags(a.Config.Tags)
-
-			// Special instructions for some inputs. cpu, for example, needs to be
-			// run twice in order to return cpu usage percentages.
-			switch input.Name() {
-			case "inputs.cpu", "inputs.mongodb", "inputs.procstat":
-				nulAcc := NewAccumulator(input, nulC)
-				nulAcc.SetPrecision(a.Config.Agent.Precision.Duration,
-					a.Config.Agent.Interval.Duration)
-				if err := input.Input.Gather(nulAcc); err != nil {
-					return err
-				}
+			nulAcc.AddError(input.Gather(nulAcc))
 
-				time.Sleep(500 * time.Millisecond)
-				if err := input.Input.Gather(acc); err != nil {
-					return err
-				}
-			default:
-				if err := input.Input.Gather(acc); err != nil {
-					return err
-				}
-			}
+			time.Sleep(500 * time.Millisecond)
 		}
-	}
 
-	return nil
+		acc.AddError(input.Gather(acc))
+	}
 }
 
 // runInputs starts and triggers the periodic gather for Inputs.
@@ -322,7 +495,7 @@ func (a *Agent) runProcessors(
 	agg chan<- telegraf.Metric,
 ) error {
 	for metric := range src {
-		metrics := a.applyProcessors(metric)
+		metrics := a.applyProcessors(a.Config.Processors, metric)
 
 		for _, metric := range metrics {
 			agg <- metric
@@ -332,10 +505,13 @@ func (a *Agent) runProcessors(
 	return nil
 }
 
-// applyProcessors applies all processors to a metric.
-func (a *Agent) applyProcessors(m telegraf.Metric) []telegraf.Metric {
+// applyProcessors applies the processors to a metric.
+func (a *Agent) applyProcessors(
+	processors models.RunningProcessors,
+	m telegraf.Metric,
+) []telegraf.Metric {
 	metrics := []telegraf.Metric{m}
-	for _, processor := range a.Config.Processors {
+	for _, processor := range processors {
 		metrics = processor.Apply(metrics...)
 	}
 
@@ -358,16 +534,7 @@ func (a *Agent) runAggregators(
 	go func() {
 		defer wg.Done()
 		for metric := range src {
-			var dropOriginal bool
-			for _, agg := range a.Config.Aggregators {
-				if ok := agg.Add(metric); ok {
-					dropOriginal = true
-				}
-			}
-
-			if !dropOriginal {
-				dst <- metric
-			}
+			a.addToAggregators(metric, dst)
 		}
 		cancel()
 	}()
@@ -399,7 +566,7 @@ func (a *Agent) runAggregators(
 	}
 
 	for metric := range aggregations {
-		metrics := a.applyProcessors(metric)
+		metrics := a.applyProcessors(a.Config.AggProcessors, metric)
 		for _, metric := range metrics {
 			dst <- metric
 		}
@@ -409,6 +576,56 @@ func (a *Agent) runAggregators(
 	return nil
 }
 
+// runAggregatorsOnce adds all metrics to the aggregators and pushes each
+// aggregator a single time once the source is closed.
+func (a *Agent) runAggregatorsOnce(
+	startTime time.Time,
+	src <-chan telegraf.Metric,
+	dst chan<- telegraf.Metric,
+) {
+	for _, agg := range a.Config.Aggregators {
+		agg.SetPeriodStart(startTime)
+	}
+
+	for metric := range src {
+		a.addToAggregators(metric, dst)
+	}
+
+	precision := a.Config.Agent.Precision.Duration
+	interval := a.Config.Agent.Interval.Duration
+	aggregations := make(chan telegraf.Metric, 100)
+	go func() {
+		for _, agg := range a.Config.Aggregators {
+			acc := NewAccumulator(agg, aggregations)
+			acc.SetPrecision(precision, interval)
+			agg.Push(acc)
+		}
+		close(aggregations)
+	}()
+
+	for metric := range aggregations {
+		metrics := a.applyProcessors(a.Config.AggProcessors, metric)
+		for _, metric := range metrics {
+			dst <- metric
+		}
+	}
+}
+
+// addToAggregators adds the metric to all aggregators, and passes it on
+// unless an aggregator drops the original.
+func (a *Agent) addToAggregators(metric telegraf.Metric, dst chan<- telegraf.Metric) {
+	var dropOriginal bool
+	for _, agg := range a.Config.Aggregators {
+		if ok := agg.Add(metric); ok {
+			dropOriginal = true
+		}
+	}
+
+	if !dropOriginal {
+		dst <- metric
+	}
+}
+
 // push runs the push for a single aggregator every period.  More simple than
 // the output/input version as timeout should be less likely.... not really
 // because the output channel can block for now.
@@ -562,6 +779,46 @@ func (a *Agent) flushOnce(
 
 }
 
+// initPlugins runs the Init function of all plugins that implement
+// telegraf.Initializer.
+func (a *Agent) initPlugins() error {
+	for _, input := range a.Config.Inputs {
+		if err := initPlugin(input.Input); err != nil {
+			return fmt.Errorf("could not initialize %s: %v", input.Name(), err)
+		}
+	}
+	seen := make(map[*models.RunningProcessor]bool)
+	for _, processors := range []models.RunningProcessors{a.Config.Processors, a.Config.AggProcessors} {
+		for _, processor := range processors {
+			if seen[processor] {
+				continue
+			}
+			seen[processor] = true
+			if err := initPlugin(processor.Processor); err != nil {
+				return fmt.Errorf("could not initialize processors.%s: %v", processor.Name, err)
+			}
+		}
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		if err := initPlugin(aggregator.Aggregator); err != nil {
+			return fmt.Errorf("could not initialize %s: %v", aggregator.Name(), err)
+		}
+	}
+	for _, output := range a.Config.Outputs {
+		if err := initPlugin(output.Output); err != nil {
+			return fmt.Errorf("could not initialize outputs.%s: %v", output.Name, err)
+		}
+	}
+	return nil
+}
+
+func initPlugin(plugin interface{}) error {
+	if initializer, ok := plugin.(telegraf.Initializer); ok {
+		return initializer.Init()
+	}
+	return nil
+}
+
 // connectOutputs connects to all outputs.
 func (a *Agent) connectOutputs(ctx context.Context) error {
 	for _, output := range a.Config.Outputs {
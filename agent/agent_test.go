@@ -1,9 +1,15 @@
 package agent
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
 
 	// needing to load the plugins
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
@@ -11,6 +17,7 @@ import (
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAgent_OmitHostname(t *testing.T) {
@@ -109,3 +116,190 @@ func TestAgent_LoadOutput(t *testing.T) {
 	a, _ = NewAgent(c)
 	assert.Equal(t, 3, len(a.Config.Outputs))
 }
+
+type onceInput struct {
+	err   error
+	count int
+}
+
+func (i *onceInput) SampleConfig() string { return "" }
+func (i *onceInput) Description() string  { return "" }
+func (i *onceInput) Gather(acc telegraf.Accumulator) error {
+	acc.AddFields("test", map[string]interface{}{"value": 42}, nil)
+	for n := 1; n < i.count; n++ {
+		acc.AddFields("test", map[string]interface{}{"value": n}, nil)
+	}
+	return i.err
+}
+
+type onceServiceInput struct {
+	onceInput
+	started bool
+}
+
+func (i *onceServiceInput) Start(acc telegraf.Accumulator) error {
+	i.started = true
+	return nil
+}
+func (i *onceServiceInput) Stop() {}
+
+type onceProcessor struct{}
+
+func (p *onceProcessor) SampleConfig() string { return "" }
+func (p *onceProcessor) Description() string  { return "" }
+func (p *onceProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		m.AddTag("processed", "true")
+	}
+	return in
+}
+
+type onceAggregator struct {
+	count int64
+}
+
+func (a *onceAggregator) SampleConfig() string   { return "" }
+func (a *onceAggregator) Description() string    { return "" }
+func (a *onceAggregator) Add(in telegraf.Metric) { a.count++ }
+func (a *onceAggregator) Reset()                 { a.count = 0 }
+func (a *onceAggregator) Push(acc telegraf.Accumulator) {
+	acc.AddFields("count", map[string]interface{}{"value": a.count}, nil)
+}
+
+type onceOutput struct {
+	sync.Mutex
+	err      error
+	failures int
+	metrics  []telegraf.Metric
+}
+
+func (o *onceOutput) SampleConfig() string { return "" }
+func (o *onceOutput) Description() string  { return "" }
+func (o *onceOutput) Connect() error       { return nil }
+func (o *onceOutput) Close() error         { return nil }
+func (o *onceOutput) Write(metrics []telegraf.Metric) error {
+	o.Lock()
+	defer o.Unlock()
+	if o.err != nil {
+		return o.err
+	}
+	if o.failures > 0 {
+		o.failures--
+		return errors.New("write failed")
+	}
+	o.metrics = append(o.metrics, metrics...)
+	return nil
+}
+
+func newOnceAgent(input telegraf.Input, output *onceOutput) *Agent {
+	c := config.NewConfig()
+	c.Inputs = append(c.Inputs, models.NewRunningInput(input,
+		&models.InputConfig{Name: "once"}))
+	rp := &models.RunningProcessor{
+		Name:      "once",
+		Processor: &onceProcessor{},
+		Config:    &models.ProcessorConfig{Name: "once"},
+	}
+	c.Processors = append(c.Processors, rp)
+	c.AggProcessors = append(c.AggProcessors, rp)
+	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(
+		&onceAggregator{},
+		&models.AggregatorConfig{Name: "once", Period: time.Minute}))
+	c.Outputs = append(c.Outputs, models.NewRunningOutput("once", output,
+		&models.OutputConfig{Name: "once"}, 1000, 10000))
+
+	a, _ := NewAgent(c)
+	return a
+}
+
+func TestAgent_Once(t *testing.T) {
+	output := &onceOutput{}
+	a := newOnceAgent(&onceInput{}, output)
+
+	err := a.Once(context.Background(), 0)
+	require.NoError(t, err)
+
+	require.Len(t, output.metrics, 2)
+	for _, m := range output.metrics {
+		require.True(t, m.HasTag("processed"))
+	}
+
+	var names []string
+	for _, m := range output.metrics {
+		names = append(names, m.Name())
+	}
+	assert.ElementsMatch(t, []string{"test", "count"}, names)
+}
+
+func TestAgent_OnceGatherError(t *testing.T) {
+	output := &onceOutput{}
+	a := newOnceAgent(&onceInput{err: errors.New("gather failed")}, output)
+
+	err := a.Once(context.Background(), 0)
+	require.Error(t, err)
+
+	// the metrics gathered are written nevertheless
+	require.Len(t, output.metrics, 2)
+}
+
+func TestAgent_OnceWriteError(t *testing.T) {
+	output := &onceOutput{err: errors.New("write failed")}
+	a := newOnceAgent(&onceInput{}, output)
+
+	err := a.Once(context.Background(), 0)
+	require.Error(t, err)
+}
+
+func TestAgent_OnceBufferLimit(t *testing.T) {
+	output := &onceOutput{}
+	a := newOnceAgent(&onceInput{count: 10}, output)
+	a.Config.Outputs[0] = models.NewRunningOutput("once", output,
+		&models.OutputConfig{Name: "once"}, 1, 2)
+
+	err := a.Once(context.Background(), 0)
+	require.NoError(t, err)
+
+	// the batches are written as they fill, so no metric is dropped
+	require.Len(t, output.metrics, 11)
+}
+
+func TestAgent_OnceBufferOverflow(t *testing.T) {
+	output := &onceOutput{failures: 5}
+	a := newOnceAgent(&onceInput{count: 10}, output)
+	a.Config.Outputs[0] = models.NewRunningOutput("once", output,
+		&models.OutputConfig{Name: "once"}, 1, 2)
+
+	err := a.Once(context.Background(), 0)
+	require.Error(t, err)
+	require.True(t, len(output.metrics) < 11)
+}
+
+func TestAgent_OnceServiceInput(t *testing.T) {
+	input := &onceServiceInput{}
+	a := newOnceAgent(input, &onceOutput{})
+
+	err := a.Once(context.Background(), 0)
+	require.NoError(t, err)
+	require.False(t, input.started)
+
+	err = a.Once(context.Background(), 10*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, input.started)
+}
+
+func TestAgent_Test(t *testing.T) {
+	output := &onceOutput{}
+	a := newOnceAgent(&onceInput{}, output)
+
+	err := a.Test(context.Background(), 0)
+	require.NoError(t, err)
+	require.Len(t, output.metrics, 0)
+}
+
+func TestAgent_TestGatherError(t *testing.T) {
+	output := &onceOutput{}
+	a := newOnceAgent(&onceInput{err: errors.New("gather failed")}, output)
+
+	err := a.Test(context.Background(), 0)
+	require.Error(t, err)
+}
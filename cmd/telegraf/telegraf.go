@@ -13,6 +13,7 @@ import (
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/internal"
@@ -34,6 +35,8 @@ var pprofAddr = flag.String("pprof-addr", "",
 var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
 var fTest = flag.Bool("test", false, "gather metrics, print them out, and exit")
+var fTestWait = flag.Int("test-wait", 0, "wait up to this many seconds for service inputs to complete in test or once mode, service inputs are skipped if not set")
+var fOnce = flag.Bool("once", false, "gather metrics once, write them to the outputs, and exit")
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
@@ -160,7 +163,13 @@ func runAgent(ctx context.Context,
 	)
 
 	if *fTest {
-		return ag.Test(ctx)
+		wait := time.Duration(*fTestWait) * time.Second
+		return ag.Test(ctx, wait)
+	}
+
+	if *fOnce {
+		wait := time.Duration(*fTestWait) * time.Second
+		return ag.Once(ctx, wait)
 	}
 
 	log.Printf("I! Loaded inputs: %s", strings.Join(c.InputNames(), " "))
@@ -190,6 +199,24 @@ func runAgent(ctx context.Context,
 	return ag.Run(ctx)
 }
 
+// checkConfig checks the configuration files for errors, prints them and
+// exits with a non-zero status if any were found.
+func checkConfig() {
+	errs := config.CheckConfig(*fConfig)
+	if *fConfigDirectory != "" {
+		errs = append(errs, config.CheckDirectory(*fConfigDirectory)...)
+	}
+
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "%d errors found\n", len(errs))
+		os.Exit(1)
+	}
+	fmt.Println("Configuration OK")
+}
+
 func usageExit(rc int) {
 	fmt.Println(internal.Usage)
 	os.Exit(rc)
@@ -288,6 +315,10 @@ func main() {
 			fmt.Println(formatFullVersion())
 			return
 		case "config":
+			if len(args) > 1 && args[1] == "check" {
+				checkConfig()
+				return
+			}
 			config.PrintSampleConfig(
 				inputFilters,
 				outputFilters,
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+// checkTOML is used to apply tables while checking the configuration.
+var checkTOML = &toml.Config{
+	NormFieldName: toml.DefaultConfig.NormFieldName,
+	FieldToKey:    toml.DefaultConfig.FieldToKey,
+	MissingField: func(typ reflect.Type, key string) error {
+		return fmt.Errorf("unknown key %q", key)
+	},
+}
+
+// multiError holds all errors found in a single table.
+type multiError []error
+
+func (e multiError) Error() string {
+	s := make([]string, 0, len(e))
+	for _, err := range e {
+		s = append(s, err.Error())
+	}
+	return strings.Join(s, "; ")
+}
+
+// CheckConfig checks the configuration file without starting any plugins.
+// Unknown keys, invalid values and plugins rejecting their settings in Init
+// are reported; all errors found are returned.
+func CheckConfig(path string) []error {
+	if path == "" {
+		var err error
+		if path, err = getDefaultConfigPath(); err != nil {
+			return []error{err}
+		}
+	}
+
+	c := NewConfig()
+	c.checking = true
+	return c.checkConfig(path)
+}
+
+// CheckDirectory checks all the *.conf files in the directory like
+// CheckConfig.
+func CheckDirectory(path string) []error {
+	var errs []error
+	walkfn := func(thispath string, info os.FileInfo, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), "..") {
+				// skip Kubernetes mounts, prevening loading the same config twice
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+		name := info.Name()
+		if len(name) < 6 || name[len(name)-5:] != ".conf" {
+			return nil
+		}
+		errs = append(errs, CheckConfig(thispath)...)
+		return nil
+	}
+	filepath.Walk(path, walkfn)
+	return errs
+}
+
+func (c *Config) checkConfig(path string) []error {
+	data, err := loadConfig(path)
+	if err != nil {
+		return []error{fmt.Errorf("%s: %s", path, err)}
+	}
+
+	tbl, err := parseConfig(data)
+	if err != nil {
+		return []error{fmt.Errorf("%s: %s", path, err)}
+	}
+
+	var errs []error
+	report := func(section string, table *ast.Table, err error) {
+		if err == nil {
+			return
+		}
+
+		tableErrs, ok := err.(multiError)
+		if !ok {
+			tableErrs = multiError{err}
+		}
+		for _, err := range tableErrs {
+			if _, ok := err.(*toml.LineError); !ok {
+				err = fmt.Errorf("line %d: %s", table.Line, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: [%s] %s", path, section, err))
+		}
+	}
+
+	for _, name := range sortedKeys(tbl) {
+		subTable, ok := tbl.Fields[name].(*ast.Table)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: [%s] invalid configuration", path, name))
+			continue
+		}
+
+		var add func(string, *ast.Table) error
+		switch name {
+		case "tags", "global_tags":
+			report(name, subTable, c.unmarshalTable(subTable, c.Tags))
+			continue
+		case "agent":
+			report(name, subTable, c.unmarshalTable(subTable, c.Agent))
+			continue
+		case "outputs":
+			add = c.addOutput
+		case "inputs", "plugins":
+			add = c.addInput
+		case "processors":
+			add = c.addProcessor
+		case "aggregators":
+			add = c.addAggregator
+		default:
+			// legacy config file support, assume it's an input
+			report("inputs."+name, subTable, c.checkPlugin(c.addInput, name, subTable))
+			continue
+		}
+
+		for _, pluginName := range sortedKeys(subTable) {
+			section := name + "." + pluginName
+			switch pluginSubTable := subTable.Fields[pluginName].(type) {
+			case *ast.Table:
+				if name == "processors" || name == "aggregators" {
+					report(section, pluginSubTable, fmt.Errorf("unsupported config format, use [[%s]]", section))
+					continue
+				}
+				report(section, pluginSubTable, c.checkPlugin(add, pluginName, pluginSubTable))
+			case []*ast.Table:
+				for _, t := range pluginSubTable {
+					report(section, t, c.checkPlugin(add, pluginName, t))
+				}
+			default:
+				errs = append(errs, fmt.Errorf("%s: [%s] unsupported config format", path, section))
+			}
+		}
+	}
+
+	return errs
+}
+
+// checkPlugin adds the plugin configured by the table and initializes it, if
+// the plugin implements telegraf.Initializer.
+func (c *Config) checkPlugin(
+	add func(string, *ast.Table) error,
+	name string,
+	table *ast.Table,
+) error {
+	existing := make(map[interface{}]bool)
+	for _, plugin := range c.plugins() {
+		existing[plugin] = true
+	}
+
+	if err := add(name, table); err != nil {
+		return err
+	}
+
+	for _, plugin := range c.plugins() {
+		if existing[plugin] {
+			continue
+		}
+		if initializer, ok := plugin.(telegraf.Initializer); ok {
+			if err := initializer.Init(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// plugins returns all the configured plugins.
+func (c *Config) plugins() []interface{} {
+	var plugins []interface{}
+	for _, input := range c.Inputs {
+		plugins = append(plugins, input.Input)
+	}
+	for _, output := range c.Outputs {
+		plugins = append(plugins, output.Output)
+	}
+	for _, aggregator := range c.Aggregators {
+		plugins = append(plugins, aggregator.Aggregator)
+	}
+	for _, processor := range c.Processors {
+		plugins = append(plugins, processor.Processor)
+	}
+	for _, processor := range c.AggProcessors {
+		plugins = append(plugins, processor.Processor)
+	}
+	return plugins
+}
+
+// sortedKeys returns the keys of the table in their order of appearance.
+func sortedKeys(table *ast.Table) []string {
+	keys := make([]string, 0, len(table.Fields))
+	for key := range table.Fields {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		li, lj := keyLine(table.Fields[keys[i]]), keyLine(table.Fields[keys[j]])
+		if li != lj {
+			return li < lj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func keyLine(node interface{}) int {
+	switch n := node.(type) {
+	case *ast.KeyValue:
+		return n.Line
+	case *ast.Table:
+		return n.Line
+	case []*ast.Table:
+		if len(n) > 0 {
+			return n[0].Line
+		}
+	}
+	return 0
+}
@@ -62,6 +62,12 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+	// AggProcessors are applied to the metrics emitted by the aggregators
+	AggProcessors models.RunningProcessors
+
+	// checking is set while checking the configuration, to report all invalid
+	// keys of a plugin instead of only the first one.
+	checking bool
 }
 
 func NewConfig() *Config {
@@ -77,6 +83,7 @@ func NewConfig() *Config {
 		Inputs:        make([]*models.RunningInput, 0),
 		Outputs:       make([]*models.RunningOutput, 0),
 		Processors:    make([]*models.RunningProcessor, 0),
+		AggProcessors: make([]*models.RunningProcessor, 0),
 		InputFilters:  make([]string, 0),
 		OutputFilters: make([]string, 0),
 	}
@@ -168,8 +175,14 @@ func (c *Config) AggregatorNames() []string {
 // Outputs returns a list of strings of the configured processors.
 func (c *Config) ProcessorNames() []string {
 	var name []string
-	for _, processor := range c.Processors {
-		name = append(name, processor.Name)
+	seen := make(map[*models.RunningProcessor]bool)
+	for _, processors := range []models.RunningProcessors{c.Processors, c.AggProcessors} {
+		for _, processor := range processors {
+			if !seen[processor] {
+				seen[processor] = true
+				name = append(name, processor.Name)
+			}
+		}
 	}
 	return name
 }
@@ -640,6 +653,10 @@ func (c *Config) LoadConfig(path string) error {
 		c.Tags["host"] = c.Agent.Hostname
 	}
 
+	// Processors are added in their order of appearance in the file, so that
+	// processors with the same order are applied in that order.
+	var processorTables []processorTable
+
 	// Parse all the rest of the plugins:
 	for name, val := range tbl.Fields {
 		subTable, ok := val.(*ast.Table)
@@ -692,9 +709,7 @@ func (c *Config) LoadConfig(path string) error {
 				switch pluginSubTable := pluginVal.(type) {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addProcessor(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
-						}
+						processorTables = append(processorTables, processorTable{pluginName, t})
 					}
 				default:
 					return fmt.Errorf("Unsupported config format: %s, file %s",
@@ -724,13 +739,55 @@ func (c *Config) LoadConfig(path string) error {
 		}
 	}
 
-	if len(c.Processors) > 1 {
-		sort.Sort(c.Processors)
+	sort.SliceStable(processorTables, func(i, j int) bool {
+		return processorTables[i].table.Line < processorTables[j].table.Line
+	})
+	for _, pt := range processorTables {
+		if err = c.addProcessor(pt.name, pt.table); err != nil {
+			return fmt.Errorf("Error parsing %s, %s", path, err)
+		}
 	}
 
+	// Processors from earlier files precede those of later files with the
+	// same order.
+	sort.Stable(c.Processors)
+	sort.Stable(c.AggProcessors)
+
+	return nil
+}
+
+// unmarshalTable applies the table to the plugin v.  While checking the
+// configuration every key is applied on its own, so that all invalid keys
+// are reported instead of only the first one.
+func (c *Config) unmarshalTable(table *ast.Table, v interface{}) error {
+	if !c.checking {
+		return toml.UnmarshalTable(table, v)
+	}
+
+	var errs multiError
+	for _, key := range sortedKeys(table) {
+		single := &ast.Table{
+			Position: table.Position,
+			Line:     table.Line,
+			Name:     table.Name,
+			Fields:   map[string]interface{}{key: table.Fields[key]},
+			Type:     table.Type,
+		}
+		if err := checkTOML.UnmarshalTable(single, v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
+type processorTable struct {
+	name  string
+	table *ast.Table
+}
+
 // trimBOM trims the Byte-Order-Marks from the beginning of the file.
 // this is for Windows compatibility only.
 // see https://github.com/influxdata/telegraf/issues/1378
@@ -806,7 +863,7 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, aggregator); err != nil {
+	if err := c.unmarshalTable(table, aggregator); err != nil {
 		return err
 	}
 
@@ -826,7 +883,7 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, processor); err != nil {
+	if err := c.unmarshalTable(table, processor); err != nil {
 		return err
 	}
 
@@ -836,7 +893,15 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		Config:    processorConfig,
 	}
 
-	c.Processors = append(c.Processors, rf)
+	switch processorConfig.Stage {
+	case models.ProcessorStageInputs:
+		c.Processors = append(c.Processors, rf)
+	case models.ProcessorStageAggregators:
+		c.AggProcessors = append(c.AggProcessors, rf)
+	default:
+		c.Processors = append(c.Processors, rf)
+		c.AggProcessors = append(c.AggProcessors, rf)
+	}
 	return nil
 }
 
@@ -866,7 +931,7 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, output); err != nil {
+	if err := c.unmarshalTable(table, output); err != nil {
 		return err
 	}
 
@@ -918,7 +983,7 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, input); err != nil {
+	if err := c.unmarshalTable(table, input); err != nil {
 		return err
 	}
 
@@ -1042,7 +1107,24 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 		}
 	}
 
+	conf.Stage = models.ProcessorStageAll
+	if node, ok := tbl.Fields["stage"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.Stage = str.Value
+			}
+		}
+	}
+	switch conf.Stage {
+	case models.ProcessorStageAll, models.ProcessorStageInputs, models.ProcessorStageAggregators:
+	default:
+		return nil, fmt.Errorf("invalid stage %q, must be one of %q, %q or %q",
+			conf.Stage, models.ProcessorStageAll, models.ProcessorStageInputs,
+			models.ProcessorStageAggregators)
+	}
+
 	delete(tbl.Fields, "order")
+	delete(tbl.Fields, "stage")
 	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
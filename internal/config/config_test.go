@@ -11,6 +11,11 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs/memcached"
 	"github.com/influxdata/telegraf/plugins/inputs/procstat"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	_ "github.com/influxdata/telegraf/plugins/processors/lookup"
+	_ "github.com/influxdata/telegraf/plugins/processors/printer"
+	_ "github.com/influxdata/telegraf/plugins/processors/regex"
+	_ "github.com/influxdata/telegraf/plugins/processors/rename"
+	_ "github.com/influxdata/telegraf/plugins/processors/strings"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -177,3 +182,64 @@ func TestConfig_LoadDirectory(t *testing.T) {
 	assert.Equal(t, pConfig, c.Inputs[3].Config,
 		"Merged Testdata did not produce correct procstat metadata.")
 }
+
+func TestConfig_ProcessorOrder(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/processor_order.toml")
+	assert.NoError(t, err)
+
+	var names []string
+	for _, processor := range c.Processors {
+		names = append(names, processor.Name)
+	}
+	assert.Equal(t, []string{"strings", "printer", "strings"}, names)
+	assert.Equal(t, models.ProcessorStageAll, c.Processors[0].Config.Stage)
+	assert.Equal(t, models.ProcessorStageInputs, c.Processors[1].Config.Stage)
+
+	names = nil
+	for _, processor := range c.AggProcessors {
+		names = append(names, processor.Name)
+	}
+	assert.Equal(t, []string{"strings", "strings", "rename"}, names)
+	assert.Equal(t, models.ProcessorStageAggregators, c.AggProcessors[2].Config.Stage)
+	assert.EqualValues(t, 1, c.AggProcessors[2].Config.Order)
+
+	// processors applied in both stages are shared
+	assert.True(t, c.Processors[0] == c.AggProcessors[0])
+	assert.Equal(t, []string{"strings", "printer", "strings", "rename"}, c.ProcessorNames())
+}
+
+func TestConfig_InvalidProcessorStage(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/invalid_processor_stage.toml")
+	assert.Error(t, err)
+}
+
+func TestCheckConfig(t *testing.T) {
+	errs := CheckConfig("./testdata/single_plugin.toml")
+	assert.Empty(t, errs)
+
+	errs = CheckDirectory("./testdata/subconfig")
+	assert.Empty(t, errs)
+}
+
+func TestCheckConfig_Invalid(t *testing.T) {
+	errs := CheckConfig("./testdata/check_invalid.toml")
+
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+
+	expected := []string{
+		`./testdata/check_invalid.toml: [agent] line 2: (config.AgentConfig.Interval) invalid duration "10x"`,
+		`./testdata/check_invalid.toml: [agent] line 3: unknown key "flush_intervl"`,
+		`./testdata/check_invalid.toml: [inputs.memcached] line 7: unknown key "unix_socket"`,
+		`./testdata/check_invalid.toml: [inputs.memcached] line 8: unknown key "timeout"`,
+		`./testdata/check_invalid.toml: [inputs.memcached] line 10: time: unknown unit " seconds" in duration "5 seconds"`,
+		`./testdata/check_invalid.toml: [inputs.memcached] line 13: Error compiling 'namepass', unexpected end of input`,
+		"./testdata/check_invalid.toml: [processors.regex] line 16: invalid pattern \"([\": error parsing regexp: missing closing ]: `[`",
+		`./testdata/check_invalid.toml: [processors.lookup] line 22: no files specified`,
+	}
+	assert.Equal(t, expected, messages)
+}
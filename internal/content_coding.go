@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ErrContentTooLarge is returned when decoded content exceeds the maximum size.
+var ErrContentTooLarge = errors.New("decoded content exceeds maximum size")
+
+// IsSupportedContentEncoding returns true if the content encoding can be
+// decoded.
+func IsSupportedContentEncoding(encoding string) bool {
+	switch encoding {
+	case "", "identity", "gzip", "zstd":
+		return true
+	default:
+		return false
+	}
+}
+
+// NewStreamContentDecoder returns a reader that decodes the stream according
+// to the content encoding, one of "identity", "gzip", or "zstd".  An empty
+// encoding is the identity.  Creating a gzip decoder reads the gzip header
+// from the stream.
+func NewStreamContentDecoder(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "", "identity":
+		return ioutil.NopCloser(r), nil
+	case "gzip":
+		return newGzipStreamReader(r)
+	case "zstd":
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return zstdStreamReader{d}, nil
+	default:
+		return nil, fmt.Errorf("unknown content encoding '%s'", encoding)
+	}
+}
+
+// zstdStreamReader releases the goroutines of the zstd decoder when closed.
+type zstdStreamReader struct {
+	*zstd.Decoder
+}
+
+func (z zstdStreamReader) Close() error {
+	z.Decoder.Close()
+	return nil
+}
+
+// gzipStreamReader decodes a stream of concatenated gzip members.  Unlike the
+// multistream mode of gzip.Reader, which waits for the header of the next
+// member before returning the end of the current one, the data of a member is
+// returned as soon as it is complete.
+type gzipStreamReader struct {
+	r *bufio.Reader
+	z *gzip.Reader
+}
+
+func newGzipStreamReader(r io.Reader) (*gzipStreamReader, error) {
+	br := bufio.NewReader(r)
+	z, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+	z.Multistream(false)
+	return &gzipStreamReader{r: br, z: z}, nil
+}
+
+func (g *gzipStreamReader) Read(p []byte) (int, error) {
+	for {
+		n, err := g.z.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+		if n > 0 {
+			return n, nil
+		}
+		if err := g.z.Reset(g.r); err != nil {
+			return 0, err
+		}
+		g.z.Multistream(false)
+	}
+}
+
+func (g *gzipStreamReader) Close() error {
+	return g.z.Close()
+}
+
+// ContentDecoder removes a content encoding from byte buffers.
+type ContentDecoder interface {
+	// Decode returns the decoded data, or ErrContentTooLarge if it exceeds
+	// the maximum size of the decoder.  The returned buffer may be reused by
+	// the next call.
+	Decode(data []byte) ([]byte, error)
+	// Close releases the resources of the decoder.
+	Close()
+}
+
+// NewContentDecoder returns a decoder for the content encoding, one of
+// "identity", "gzip", or "zstd", limiting the decoded size to maxSize bytes.
+// A maxSize of 0 is unlimited.
+func NewContentDecoder(encoding string, maxSize int64) (ContentDecoder, error) {
+	switch encoding {
+	case "", "identity":
+		return &IdentityDecoder{maxSize: maxSize}, nil
+	case "gzip":
+		return &GzipDecoder{maxSize: maxSize}, nil
+	case "zstd":
+		return NewZstdDecoder(maxSize)
+	default:
+		return nil, fmt.Errorf("unknown content encoding '%s'", encoding)
+	}
+}
+
+// IdentityDecoder returns the data unchanged.
+type IdentityDecoder struct {
+	maxSize int64
+}
+
+func (d *IdentityDecoder) Decode(data []byte) ([]byte, error) {
+	if d.maxSize > 0 && int64(len(data)) > d.maxSize {
+		return nil, ErrContentTooLarge
+	}
+	return data, nil
+}
+
+func (d *IdentityDecoder) Close() {
+}
+
+// GzipDecoder decodes gzip data.
+type GzipDecoder struct {
+	maxSize int64
+	reader  *gzip.Reader
+	buf     bytes.Buffer
+}
+
+func (d *GzipDecoder) Decode(data []byte) ([]byte, error) {
+	var err error
+	if d.reader == nil {
+		d.reader, err = gzip.NewReader(bytes.NewReader(data))
+	} else {
+		err = d.reader.Reset(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return readLimited(&d.buf, d.reader, d.maxSize)
+}
+
+func (d *GzipDecoder) Close() {
+}
+
+// ZstdDecoder decodes zstd data.
+type ZstdDecoder struct {
+	maxSize int64
+	decoder *zstd.Decoder
+	buf     bytes.Buffer
+}
+
+func NewZstdDecoder(maxSize int64) (*ZstdDecoder, error) {
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &ZstdDecoder{maxSize: maxSize, decoder: decoder}, nil
+}
+
+func (d *ZstdDecoder) Decode(data []byte) ([]byte, error) {
+	if err := d.decoder.Reset(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return readLimited(&d.buf, d.decoder, d.maxSize)
+}
+
+func (d *ZstdDecoder) Close() {
+	d.decoder.Close()
+}
+
+// readLimited reads r into buf, failing when it holds more than maxSize bytes.
+func readLimited(buf *bytes.Buffer, r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+
+	buf.Reset()
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	if maxSize > 0 && int64(buf.Len()) > maxSize {
+		return nil, ErrContentTooLarge
+	}
+	return buf.Bytes(), nil
+}
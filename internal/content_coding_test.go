@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zstdData(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestContentDecoder(t *testing.T) {
+	data := []byte("cpu value=42\n")
+	tests := []struct {
+		encoding string
+		encoded  []byte
+	}{
+		{"", data},
+		{"identity", data},
+		{"gzip", gzipData(t, data)},
+		{"zstd", zstdData(t, data)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			dec, err := NewContentDecoder(tt.encoding, 0)
+			require.NoError(t, err)
+			defer dec.Close()
+
+			// Decoders are reused for every buffer.
+			for i := 0; i < 2; i++ {
+				actual, err := dec.Decode(tt.encoded)
+				require.NoError(t, err)
+				require.Equal(t, data, actual)
+			}
+
+			r, err := NewStreamContentDecoder(tt.encoding, bytes.NewReader(tt.encoded))
+			require.NoError(t, err)
+			defer r.Close()
+			actual, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, data, actual)
+		})
+	}
+}
+
+func TestStreamContentDecoderGzipMembers(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(gzipData(t, []byte("cpu value=1\n")))
+	buf.Write(gzipData(t, []byte("cpu value=2\n")))
+
+	r, err := NewStreamContentDecoder("gzip", &buf)
+	require.NoError(t, err)
+	defer r.Close()
+	actual, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "cpu value=1\ncpu value=2\n", string(actual))
+}
+
+func TestContentDecoderMaxSize(t *testing.T) {
+	data := bytes.Repeat([]byte("cpu value=42\n"), 100)
+	tests := []struct {
+		encoding string
+		encoded  []byte
+	}{
+		{"identity", data},
+		{"gzip", gzipData(t, data)},
+		{"zstd", zstdData(t, data)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			dec, err := NewContentDecoder(tt.encoding, int64(len(data)))
+			require.NoError(t, err)
+			defer dec.Close()
+			actual, err := dec.Decode(tt.encoded)
+			require.NoError(t, err)
+			require.Equal(t, data, actual)
+
+			dec, err = NewContentDecoder(tt.encoding, int64(len(data)-1))
+			require.NoError(t, err)
+			defer dec.Close()
+			_, err = dec.Decode(tt.encoded)
+			require.Equal(t, ErrContentTooLarge, err)
+		})
+	}
+}
+
+func TestContentDecoderUnknown(t *testing.T) {
+	_, err := NewContentDecoder("deflate", 0)
+	require.Error(t, err)
+	_, err = NewStreamContentDecoder("deflate", bytes.NewReader(nil))
+	require.Error(t, err)
+}
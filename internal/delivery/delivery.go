@@ -0,0 +1,136 @@
+package delivery
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+)
+
+var (
+	// ErrTooManyUndelivered is returned when adding a group would exceed the
+	// maximum number of undelivered metrics.
+	ErrTooManyUndelivered = errors.New("too many undelivered metrics")
+
+	// ErrStopped is returned when adding a group to a stopped tracker.
+	ErrStopped = errors.New("delivery tracking stopped")
+)
+
+// Tracker adds groups of metrics with delivery tracking and reports when they
+// are delivered, so that service inputs can hold the response to the sender
+// until its metrics have been written by the outputs.
+type Tracker struct {
+	acc            telegraf.TrackingAccumulator
+	maxUndelivered int
+
+	mu          sync.Mutex
+	undelivered int
+	groups      map[telegraf.TrackingID]*group
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type group struct {
+	size      int
+	delivered chan bool
+}
+
+// NewTracker returns a tracker allowing up to maxUndelivered metrics to wait
+// for delivery.  Stop must be called to release it.
+func NewTracker(acc telegraf.Accumulator, maxUndelivered int) *Tracker {
+	t := &Tracker{
+		acc:            acc.WithTracking(maxUndelivered),
+		maxUndelivered: maxUndelivered,
+		groups:         make(map[telegraf.TrackingID]*group),
+		done:           make(chan struct{}),
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.deliveries()
+	}()
+	return t
+}
+
+// Add adds the metrics as a group.  The returned channel receives true once
+// all of them are delivered, or false if any is rejected.  A metric dropped by
+// an output, as by its filters, counts as delivered.
+//
+// A group larger than the maximum is only accepted while no other metrics
+// are waiting, so that it is not refused forever.
+func (t *Tracker) Add(metrics []telegraf.Metric) (<-chan bool, error) {
+	delivered := make(chan bool, 1)
+	if len(metrics) == 0 {
+		delivered <- true
+		return delivered, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.done:
+		return nil, ErrStopped
+	default:
+	}
+
+	if t.undelivered > 0 && t.undelivered+len(metrics) > t.maxUndelivered {
+		return nil, ErrTooManyUndelivered
+	}
+
+	// The delivery of the group cannot be handled before it is registered,
+	// as the lock is held.
+	id := t.acc.AddTrackingMetricGroup(metrics)
+	t.groups[id] = &group{size: len(metrics), delivered: delivered}
+	t.undelivered += len(metrics)
+	return delivered, nil
+}
+
+// Undelivered returns the number of metrics waiting for delivery.
+func (t *Tracker) Undelivered() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.undelivered
+}
+
+// Stop stops tracking, reporting the groups still waiting as not delivered.
+func (t *Tracker) Stop() {
+	t.mu.Lock()
+	close(t.done)
+	t.mu.Unlock()
+	t.wg.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, g := range t.groups {
+		g.delivered <- false
+		delete(t.groups, id)
+	}
+	t.undelivered = 0
+}
+
+func (t *Tracker) deliveries() {
+	for {
+		select {
+		case <-t.done:
+			return
+		case info := <-t.acc.Delivered():
+			t.onDelivery(info)
+		}
+	}
+}
+
+func (t *Tracker) onDelivery(info telegraf.DeliveryInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	g, ok := t.groups[info.ID()]
+	if !ok {
+		return
+	}
+	delete(t.groups, info.ID())
+	t.undelivered -= g.size
+	g.delivered <- info.Delivered()
+}
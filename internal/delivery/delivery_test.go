@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func testMetrics(n int) []telegraf.Metric {
+	metrics := make([]telegraf.Metric, 0, n)
+	for i := 0; i < n; i++ {
+		metrics = append(metrics, testutil.TestMetric(i))
+	}
+	return metrics
+}
+
+func TestTrackerDelivered(t *testing.T) {
+	acc := testutil.NewDeliveryAccumulator()
+	tracker := NewTracker(acc, 10)
+	defer tracker.Stop()
+
+	delivered, err := tracker.Add(testMetrics(2))
+	require.NoError(t, err)
+	require.Equal(t, 2, tracker.Undelivered())
+
+	// The group is delivered once all of its metrics are accepted.
+	(<-acc.Tracked).Accept()
+	select {
+	case <-delivered:
+		t.Fatal("group delivered before all metrics were accepted")
+	case <-time.After(10 * time.Millisecond):
+	}
+	(<-acc.Tracked).Accept()
+
+	require.True(t, <-delivered)
+	require.Equal(t, 0, tracker.Undelivered())
+}
+
+func TestTrackerRejected(t *testing.T) {
+	acc := testutil.NewDeliveryAccumulator()
+	tracker := NewTracker(acc, 10)
+	defer tracker.Stop()
+
+	delivered, err := tracker.Add(testMetrics(2))
+	require.NoError(t, err)
+	(<-acc.Tracked).Accept()
+	(<-acc.Tracked).Reject()
+
+	require.False(t, <-delivered)
+	require.Equal(t, 0, tracker.Undelivered())
+}
+
+func TestTrackerEmpty(t *testing.T) {
+	acc := testutil.NewDeliveryAccumulator()
+	tracker := NewTracker(acc, 10)
+	defer tracker.Stop()
+
+	delivered, err := tracker.Add(nil)
+	require.NoError(t, err)
+	require.True(t, <-delivered)
+}
+
+func TestTrackerMaxUndelivered(t *testing.T) {
+	acc := testutil.NewDeliveryAccumulator()
+	tracker := NewTracker(acc, 3)
+	defer tracker.Stop()
+
+	// A group larger than the maximum is accepted while nothing is waiting.
+	first, err := tracker.Add(testMetrics(4))
+	require.NoError(t, err)
+
+	_, err = tracker.Add(testMetrics(1))
+	require.Equal(t, ErrTooManyUndelivered, err)
+
+	for i := 0; i < 4; i++ {
+		(<-acc.Tracked).Accept()
+	}
+	require.True(t, <-first)
+
+	second, err := tracker.Add(testMetrics(2))
+	require.NoError(t, err)
+	third, err := tracker.Add(testMetrics(1))
+	require.NoError(t, err)
+	_, err = tracker.Add(testMetrics(1))
+	require.Equal(t, ErrTooManyUndelivered, err)
+
+	for i := 0; i < 3; i++ {
+		(<-acc.Tracked).Accept()
+	}
+	require.True(t, <-second)
+	require.True(t, <-third)
+}
+
+func TestTrackerStop(t *testing.T) {
+	acc := testutil.NewDeliveryAccumulator()
+	tracker := NewTracker(acc, 10)
+
+	delivered, err := tracker.Add(testMetrics(1))
+	require.NoError(t, err)
+
+	tracker.Stop()
+	require.False(t, <-delivered)
+
+	_, err = tracker.Add(testMetrics(1))
+	require.Equal(t, ErrStopped, err)
+}
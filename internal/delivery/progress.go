@@ -0,0 +1,80 @@
+package delivery
+
+import (
+	"sync"
+)
+
+// Progress tracks the position reached in reading an ordered stream, such as
+// a file or the journal, whose items are delivered asynchronously and so
+// possibly out of order.  The position of an item is only reached once the
+// item and all the items read before it are delivered, so that reading
+// resumed at the position reached does not skip an undelivered item.  Once an
+// item is rejected the position no longer advances.
+type Progress struct {
+	mu sync.Mutex
+
+	// next is the sequence number of the next item added, and first the one
+	// of the oldest item whose position is not yet reached.
+	next    uint64
+	first   uint64
+	pending map[uint64]*step
+	stalled bool
+}
+
+type step struct {
+	position interface{}
+	done     bool
+}
+
+// NewProgress returns a Progress at the start of the stream.
+func NewProgress() *Progress {
+	return &Progress{
+		pending: make(map[uint64]*step),
+	}
+}
+
+// Add adds the next item of the stream, which ends at the position, and
+// returns its sequence number.
+func (p *Progress) Add(position interface{}) uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	seq := p.next
+	p.next++
+	if !p.stalled {
+		p.pending[seq] = &step{position: position}
+	}
+	return seq
+}
+
+// Done marks the item as delivered, or as rejected if delivered is false.  It
+// returns the position reached, and true if it advanced.
+func (p *Progress) Done(seq uint64, delivered bool) (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.pending[seq]
+	if !ok {
+		return nil, false
+	}
+	if !delivered {
+		p.stalled = true
+		p.pending = make(map[uint64]*step)
+		return nil, false
+	}
+	s.done = true
+
+	var position interface{}
+	var advanced bool
+	for {
+		s, ok := p.pending[p.first]
+		if !ok || !s.done {
+			break
+		}
+		delete(p.pending, p.first)
+		p.first++
+		position = s.position
+		advanced = true
+	}
+	return position, advanced
+}
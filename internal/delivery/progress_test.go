@@ -0,0 +1,57 @@
+package delivery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressInOrder(t *testing.T) {
+	p := NewProgress()
+	first := p.Add(10)
+	second := p.Add(20)
+
+	position, ok := p.Done(first, true)
+	require.True(t, ok)
+	require.Equal(t, 10, position)
+
+	position, ok = p.Done(second, true)
+	require.True(t, ok)
+	require.Equal(t, 20, position)
+}
+
+func TestProgressOutOfOrder(t *testing.T) {
+	p := NewProgress()
+	first := p.Add(10)
+	second := p.Add(20)
+	third := p.Add(30)
+
+	// The position does not advance past the undelivered first item.
+	_, ok := p.Done(third, true)
+	require.False(t, ok)
+	_, ok = p.Done(second, true)
+	require.False(t, ok)
+
+	position, ok := p.Done(first, true)
+	require.True(t, ok)
+	require.Equal(t, 30, position)
+}
+
+func TestProgressRejected(t *testing.T) {
+	p := NewProgress()
+	first := p.Add(10)
+	second := p.Add(20)
+	third := p.Add(30)
+
+	position, ok := p.Done(first, true)
+	require.True(t, ok)
+	require.Equal(t, 10, position)
+
+	// Once an item is rejected, the position no longer advances.
+	_, ok = p.Done(second, false)
+	require.False(t, ok)
+	_, ok = p.Done(third, true)
+	require.False(t, ok)
+	_, ok = p.Done(p.Add(40), true)
+	require.False(t, ok)
+}
@@ -0,0 +1,190 @@
+// Package encoding decodes text in the character encodings supported by the
+// file based inputs to UTF-8.
+package encoding
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Encoding is a character encoding of text.
+type Encoding struct {
+	encoding encoding.Encoding
+	// newline is the encoded newline character
+	newline []byte
+	// unicode is set for the unicode encodings, whose byte order mark
+	// overrides the encoding.
+	unicode bool
+}
+
+var (
+	utf8    = &Encoding{unicode.UTF8, []byte{'\n'}, true}
+	utf16le = &Encoding{unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), []byte{'\n', 0}, true}
+	utf16be = &Encoding{unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), []byte{0, '\n'}, true}
+	latin1  = &Encoding{charmap.ISO8859_1, []byte{'\n'}, false}
+)
+
+// byte order marks of the unicode encodings
+var boms = []struct {
+	bom      []byte
+	encoding *Encoding
+}{
+	{[]byte{0xef, 0xbb, 0xbf}, utf8},
+	{[]byte{0xff, 0xfe}, utf16le},
+	{[]byte{0xfe, 0xff}, utf16be},
+}
+
+// Lookup returns the encoding by its name.  The empty name returns nil, which
+// stands for text that is not decoded.
+func Lookup(name string) (*Encoding, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "utf-8":
+		return utf8, nil
+	case "utf-16le":
+		return utf16le, nil
+	case "utf-16be":
+		return utf16be, nil
+	case "iso-8859-1":
+		return latin1, nil
+	}
+	return nil, fmt.Errorf("unknown character encoding %q", name)
+}
+
+// detect returns the encoding of the text starting with buf and the length
+// of its byte order mark.  A byte order mark overrides a unicode encoding.
+func (e *Encoding) detect(buf []byte) (*Encoding, int) {
+	if !e.unicode {
+		return e, 0
+	}
+	for _, b := range boms {
+		if bytes.HasPrefix(buf, b.bom) {
+			return b.encoding, len(b.bom)
+		}
+	}
+	return e, 0
+}
+
+// Detect returns the encoding of the text starting with buf.  This differs
+// from e if buf starts with the byte order mark of another unicode encoding.
+func (e *Encoding) Detect(buf []byte) *Encoding {
+	enc, _ := e.detect(buf)
+	return enc
+}
+
+// Decode decodes the text to UTF-8.
+func (e *Encoding) Decode(buf []byte) ([]byte, error) {
+	enc, n := e.detect(buf)
+	return enc.encoding.NewDecoder().Bytes(buf[n:])
+}
+
+// LineDecoder decodes the lines of a file read in parts ending at '\n'
+// bytes.  Such a part does not necessarily end a line in the encoding, as
+// in UTF-16, so the parts are joined and split at the encoded newlines.
+//
+// In UTF-16LE the '\n' byte is followed by the second byte of the newline,
+// which is only read with the next part.  To not hold back the last line of
+// the file until the next line is written, the decoder peeks at the bytes
+// following the part.
+type LineDecoder struct {
+	encoding *Encoding
+	decoder  *encoding.Decoder
+	// pending holds the bytes read that do not form a complete line yet.
+	pending []byte
+	// detect is set until the start of the file was checked for a byte
+	// order mark.
+	detect bool
+	// bom is the length of the byte order mark, which is counted to the
+	// first line.
+	bom int
+	// skip holds the bytes peeked at, which start the next part.
+	skip []byte
+}
+
+// Line is a decoded line.
+type Line struct {
+	// Text is the line without the newline.
+	Text string
+	// Size is the number of bytes the line took in the file, including its
+	// newline.
+	Size int
+}
+
+// NewLineDecoder returns a decoder for lines in the encoding.  If the lines
+// are read from the start of the file, fromStart must be set to detect the
+// byte order mark.
+func (e *Encoding) NewLineDecoder(fromStart bool) *LineDecoder {
+	return &LineDecoder{
+		encoding: e,
+		decoder:  e.encoding.NewDecoder(),
+		detect:   fromStart,
+	}
+}
+
+// Decode adds the part read and returns the lines completed by it.  If it
+// is not nil, peek returns up to n bytes following the part in the file.
+func (d *LineDecoder) Decode(part []byte, peek func(n int) []byte) ([]Line, error) {
+	if len(d.skip) > 0 && bytes.HasPrefix(part, d.skip) {
+		part = part[len(d.skip):]
+	}
+	d.skip = nil
+	d.pending = append(d.pending, part...)
+
+	if d.detect {
+		d.detect = false
+		var enc *Encoding
+		enc, d.bom = d.encoding.detect(d.pending)
+		if enc != d.encoding {
+			d.encoding = enc
+			d.decoder = enc.encoding.NewDecoder()
+		}
+		d.pending = d.pending[d.bom:]
+	}
+
+	var lines []Line
+	newline := d.encoding.newline
+	width := len(newline)
+	start := 0
+	for i := 0; i+width <= len(d.pending); i += width {
+		if !bytes.Equal(d.pending[i:i+width], newline) {
+			continue
+		}
+
+		text, err := d.decoder.Bytes(d.pending[start:i])
+		if err != nil {
+			return lines, err
+		}
+		lines = append(lines, Line{
+			Text: string(text),
+			Size: i + width - start + d.bom,
+		})
+		d.bom = 0
+		start = i + width
+	}
+	d.pending = d.pending[start:]
+
+	// Complete a newline the pending bytes end with the start of.
+	n := len(d.pending) % width
+	if n == 0 || peek == nil || !bytes.HasSuffix(d.pending, newline[:n]) {
+		return lines, nil
+	}
+	if rest := peek(width - n); bytes.Equal(rest, newline[n:]) {
+		text, err := d.decoder.Bytes(d.pending[:len(d.pending)-n])
+		if err != nil {
+			return lines, err
+		}
+		lines = append(lines, Line{
+			Text: string(text),
+			Size: len(d.pending) + len(rest) + d.bom,
+		})
+		d.bom = 0
+		d.pending = d.pending[:0]
+		d.skip = rest
+	}
+	return lines, nil
+}
@@ -0,0 +1,155 @@
+package encoding
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	enc, err := Lookup("")
+	require.NoError(t, err)
+	require.Nil(t, enc)
+
+	for _, name := range []string{"utf-8", "utf-16le", "utf-16be", "iso-8859-1"} {
+		enc, err := Lookup(name)
+		require.NoError(t, err)
+		require.NotNil(t, enc)
+	}
+
+	_, err = Lookup("utf-32")
+	require.Error(t, err)
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		input    []byte
+	}{
+		{"utf-8", "utf-8", []byte("h\xc3\xa9\n")},
+		{"utf-8 with byte order mark", "utf-8", []byte("\xef\xbb\xbfh\xc3\xa9\n")},
+		{"utf-16le", "utf-16le", []byte("h\x00\xe9\x00\n\x00")},
+		{"utf-16le with byte order mark", "utf-16le", []byte("\xff\xfeh\x00\xe9\x00\n\x00")},
+		{"utf-16be", "utf-16be", []byte("\x00h\x00\xe9\x00\n")},
+		{"byte order mark overrides utf-16le", "utf-16le", []byte("\xfe\xff\x00h\x00\xe9\x00\n")},
+		{"iso-8859-1", "iso-8859-1", []byte("h\xe9\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := Lookup(tt.encoding)
+			require.NoError(t, err)
+
+			actual, err := enc.Decode(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, "hé\n", string(actual))
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	enc, err := Lookup("utf-16be")
+	require.NoError(t, err)
+	require.Equal(t, utf16le, enc.Detect([]byte("\xff\xfea\x00")))
+	require.Equal(t, utf16be, enc.Detect([]byte("\x00a")))
+	require.Equal(t, latin1, latin1.Detect([]byte("\xff\xfea")))
+}
+
+// split splits the input after each '\n' byte, as read by the tailer.
+func split(input []byte) [][]byte {
+	var parts [][]byte
+	for len(input) > 0 {
+		n := bytes.IndexByte(input, '\n') + 1
+		if n == 0 {
+			n = len(input)
+		}
+		parts = append(parts, input[:n])
+		input = input[n:]
+	}
+	return parts
+}
+
+func TestLineDecoder(t *testing.T) {
+	tests := []struct {
+		name      string
+		encoding  string
+		fromStart bool
+		input     []byte
+		expected  []Line
+	}{
+		{
+			name:      "utf-8 with byte order mark",
+			encoding:  "utf-8",
+			fromStart: true,
+			input:     []byte("\xef\xbb\xbfa\n\xc3\xa9\n"),
+			expected:  []Line{{"a", 5}, {"é", 3}},
+		},
+		{
+			name:      "utf-16le with byte order mark",
+			encoding:  "utf-16le",
+			fromStart: true,
+			input:     []byte("\xff\xfea\x00\n\x00\n\n\n\x00"),
+			expected:  []Line{{"a", 6}, {"ਊ", 4}},
+		},
+		{
+			name:      "utf-16le not from the start",
+			encoding:  "utf-16le",
+			fromStart: false,
+			input:     []byte("\xff\xfea\x00\n\x00"),
+			expected:  []Line{{"\ufeffa", 6}},
+		},
+		{
+			name:      "utf-16be",
+			encoding:  "utf-16be",
+			fromStart: true,
+			input:     []byte("\x00a\x00\n\x0a\x0a\x00\n"),
+			expected:  []Line{{"a", 4}, {"ਊ", 4}},
+		},
+		{
+			name:      "iso-8859-1 ignores byte order mark",
+			encoding:  "iso-8859-1",
+			fromStart: true,
+			input:     []byte("\xff\xfea\n"),
+			expected:  []Line{{"ÿþa", 4}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := Lookup(tt.encoding)
+			require.NoError(t, err)
+
+			d := enc.NewLineDecoder(tt.fromStart)
+			var actual []Line
+			for _, part := range split(tt.input) {
+				lines, err := d.Decode(part, nil)
+				require.NoError(t, err)
+				actual = append(actual, lines...)
+			}
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestLineDecoderPeek(t *testing.T) {
+	enc, err := Lookup("utf-16le")
+	require.NoError(t, err)
+	input := []byte("a\x00\n\x00b\x00\n\x00")
+
+	d := enc.NewLineDecoder(true)
+	var actual []Line
+	offset := 0
+	for _, part := range split(input) {
+		offset += len(part)
+		end := offset
+		lines, err := d.Decode(part, func(n int) []byte {
+			if end+n > len(input) {
+				n = len(input) - end
+			}
+			return input[end : end+n]
+		})
+		require.NoError(t, err)
+		actual = append(actual, lines...)
+	}
+	require.Equal(t, []Line{{"a", 4}, {"b", 4}}, actual)
+}
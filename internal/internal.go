@@ -77,7 +77,11 @@ func (d *Duration) UnmarshalTOML(b []byte) error {
 	}
 
 	// Parse string duration, ie, "1s"
-	if uq, err := strconv.Unquote(string(b)); err == nil && len(uq) > 0 {
+	if uq, err := strconv.Unquote(string(b)); err == nil {
+		if len(uq) == 0 {
+			d.Duration = 0
+			return nil
+		}
 		d.Duration, err = time.ParseDuration(uq)
 		if err == nil {
 			return nil
@@ -97,7 +101,7 @@ func (d *Duration) UnmarshalTOML(b []byte) error {
 		return nil
 	}
 
-	return nil
+	return fmt.Errorf("invalid duration %s", b)
 }
 
 func (s *Size) UnmarshalTOML(b []byte) error {
@@ -0,0 +1,245 @@
+package listener
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	tlsint "github.com/influxdata/telegraf/internal/tls"
+)
+
+// Config holds the options of service inputs for authenticating and
+// filtering their clients, to be embedded in their configuration.
+type Config struct {
+	tlsint.ServerConfig
+
+	AllowedNetworks []string `toml:"allowed_networks"`
+	DeniedNetworks  []string `toml:"denied_networks"`
+}
+
+// Options are the limits of a stream listener.
+type Options struct {
+	// MaxConnections is the maximum number of open connections; further
+	// connections are closed as soon as they are accepted.  0 is unlimited.
+	MaxConnections int
+
+	// ReadTimeout is the maximum duration of each read from a connection.
+	// 0 is unlimited.
+	ReadTimeout time.Duration
+
+	// OnAccept is called with each connection allowed, before it is wrapped,
+	// to set socket options such as keep alive probes.
+	OnAccept func(c net.Conn)
+}
+
+// Filter allows or denies clients by the network of their address.
+type Filter struct {
+	allowed []*net.IPNet
+	denied  []*net.IPNet
+}
+
+// Filter returns the filter of the allowed and denied networks, or nil if
+// all clients are allowed.
+func (c *Config) Filter() (*Filter, error) {
+	if len(c.AllowedNetworks) == 0 && len(c.DeniedNetworks) == 0 {
+		return nil, nil
+	}
+
+	allowed, err := parseNetworks(c.AllowedNetworks)
+	if err != nil {
+		return nil, err
+	}
+	denied, err := parseNetworks(c.DeniedNetworks)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{allowed: allowed, denied: denied}, nil
+}
+
+// parseNetworks parses networks in CIDR notation, or single IP addresses.
+func parseNetworks(networks []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(networks))
+	for _, network := range networks {
+		if !strings.Contains(network, "/") {
+			ip := net.ParseIP(network)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid network '%s'", network)
+			}
+			if ip.To4() != nil {
+				network += "/32"
+			} else {
+				network += "/128"
+			}
+		}
+
+		_, ipnet, err := net.ParseCIDR(network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network '%s': %v", network, err)
+		}
+		nets = append(nets, ipnet)
+	}
+	return nets, nil
+}
+
+// Allowed returns true if the client at addr is allowed.  A client is
+// allowed if it is in one of the allowed networks, or if there are none, and
+// not in any of the denied networks.  Clients without an IP address, as on
+// unix sockets, are always allowed.
+func (f *Filter) Allowed(addr net.Addr) bool {
+	if f == nil {
+		return true
+	}
+
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.UDPAddr:
+		ip = a.IP
+	case *net.IPAddr:
+		ip = a.IP
+	default:
+		return true
+	}
+
+	if len(f.allowed) > 0 && !contains(f.allowed, ip) {
+		return false
+	}
+	return !contains(f.denied, ip)
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Listen listens on the stream network address, eg. "tcp" and ":8094",
+// serving TLS if configured.
+func (c *Config) Listen(network, address string, opts Options) (*Listener, error) {
+	tlsConfig, err := c.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	filter, err := c.Filter()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &Listener{
+		Listener:  l,
+		tlsConfig: tlsConfig,
+		filter:    filter,
+		opts:      opts,
+		conns:     make(map[*conn]struct{}),
+	}, nil
+}
+
+// Listener is a stream listener accepting only the connections of allowed
+// clients, up to the maximum number of connections.  Closing the listener
+// closes its connections.
+type Listener struct {
+	net.Listener
+
+	tlsConfig *tls.Config
+	filter    *Filter
+	opts      Options
+
+	mu     sync.Mutex
+	conns  map[*conn]struct{}
+	closed bool
+}
+
+// Accept waits for and returns the next connection allowed.
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if !l.filter.Allowed(c.RemoteAddr()) {
+			log.Printf("D! Refused connection from %s, not in an allowed network", c.RemoteAddr())
+			c.Close()
+			continue
+		}
+
+		l.mu.Lock()
+		if l.closed {
+			l.mu.Unlock()
+			c.Close()
+			continue
+		}
+		if l.opts.MaxConnections > 0 && len(l.conns) >= l.opts.MaxConnections {
+			l.mu.Unlock()
+			log.Printf("W! Refused connection from %s, the maximum of %d connections is reached",
+				c.RemoteAddr(), l.opts.MaxConnections)
+			c.Close()
+			continue
+		}
+		wrapped := &conn{Conn: c, listener: l}
+		l.conns[wrapped] = struct{}{}
+		l.mu.Unlock()
+
+		if l.opts.OnAccept != nil {
+			l.opts.OnAccept(c)
+		}
+
+		if l.tlsConfig != nil {
+			return tls.Server(wrapped, l.tlsConfig), nil
+		}
+		return wrapped, nil
+	}
+}
+
+// Close stops listening and closes the open connections.
+func (l *Listener) Close() error {
+	err := l.Listener.Close()
+
+	l.mu.Lock()
+	l.closed = true
+	conns := make([]*conn, 0, len(l.conns))
+	for c := range l.conns {
+		conns = append(conns, c)
+	}
+	l.mu.Unlock()
+
+	for _, c := range conns {
+		c.Close()
+	}
+	return err
+}
+
+// conn is a connection of a Listener, applying its read timeout.
+type conn struct {
+	net.Conn
+	listener *Listener
+	once     sync.Once
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	if c.listener.opts.ReadTimeout > 0 {
+		c.SetReadDeadline(time.Now().Add(c.listener.opts.ReadTimeout))
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *conn) Close() error {
+	c.once.Do(func() {
+		c.listener.mu.Lock()
+		delete(c.listener.conns, c)
+		c.listener.mu.Unlock()
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,220 @@
+package listener
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var pki = testutil.NewPKI("../../testutil/pki")
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		addr    net.Addr
+		allowed bool
+	}{
+		{
+			name:    "no networks",
+			addr:    &net.TCPAddr{IP: net.ParseIP("10.0.0.1")},
+			allowed: true,
+		},
+		{
+			name:    "allowed",
+			config:  Config{AllowedNetworks: []string{"192.168.0.0/16", "10.0.0.0/8"}},
+			addr:    &net.TCPAddr{IP: net.ParseIP("10.0.0.1")},
+			allowed: true,
+		},
+		{
+			name:   "not allowed",
+			config: Config{AllowedNetworks: []string{"192.168.0.0/16"}},
+			addr:   &net.UDPAddr{IP: net.ParseIP("10.0.0.1")},
+		},
+		{
+			name:   "denied",
+			config: Config{DeniedNetworks: []string{"10.0.0.1"}},
+			addr:   &net.TCPAddr{IP: net.ParseIP("10.0.0.1")},
+		},
+		{
+			name:    "not denied",
+			config:  Config{DeniedNetworks: []string{"10.0.0.1"}},
+			addr:    &net.TCPAddr{IP: net.ParseIP("10.0.0.2")},
+			allowed: true,
+		},
+		{
+			name: "denied within allowed",
+			config: Config{
+				AllowedNetworks: []string{"10.0.0.0/8"},
+				DeniedNetworks:  []string{"10.1.0.0/16"},
+			},
+			addr: &net.TCPAddr{IP: net.ParseIP("10.1.2.3")},
+		},
+		{
+			name:    "ipv6",
+			config:  Config{AllowedNetworks: []string{"::1", "2001:db8::/32"}},
+			addr:    &net.TCPAddr{IP: net.ParseIP("2001:db8::1")},
+			allowed: true,
+		},
+		{
+			name:    "unix socket",
+			config:  Config{AllowedNetworks: []string{"10.0.0.0/8"}},
+			addr:    &net.UnixAddr{Name: "/tmp/telegraf.sock", Net: "unix"},
+			allowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.config.Filter()
+			require.NoError(t, err)
+			require.Equal(t, tt.allowed, f.Allowed(tt.addr))
+		})
+	}
+}
+
+func TestFilterInvalid(t *testing.T) {
+	c := Config{AllowedNetworks: []string{"10.0.0.0/33"}}
+	_, err := c.Filter()
+	require.Error(t, err)
+
+	c = Config{DeniedNetworks: []string{"localhost"}}
+	_, err = c.Filter()
+	require.Error(t, err)
+}
+
+// accept accepts connections of the listener in the background.
+func accept(l net.Listener) <-chan net.Conn {
+	conns := make(chan net.Conn, 10)
+	go func() {
+		defer close(conns)
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns <- c
+		}
+	}()
+	return conns
+}
+
+// requireClosed requires the connection to be closed by the listener.
+func requireClosed(t *testing.T, c net.Conn) {
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err := c.Read(make([]byte, 1))
+	require.Equal(t, io.EOF, err)
+}
+
+func TestListenerDenied(t *testing.T) {
+	config := Config{DeniedNetworks: []string{"127.0.0.0/8"}}
+	l, err := config.Listen("tcp", "127.0.0.1:0", Options{})
+	require.NoError(t, err)
+	defer l.Close()
+	conns := accept(l)
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer c.Close()
+	requireClosed(t, c)
+	require.Len(t, conns, 0)
+}
+
+func TestListenerMaxConnections(t *testing.T) {
+	config := Config{}
+	l, err := config.Listen("tcp", "127.0.0.1:0", Options{MaxConnections: 1})
+	require.NoError(t, err)
+	defer l.Close()
+	conns := accept(l)
+
+	first, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer first.Close()
+	accepted := <-conns
+
+	second, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer second.Close()
+	requireClosed(t, second)
+
+	// Closing a connection makes room for another one.
+	accepted.Close()
+	third, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer third.Close()
+	select {
+	case <-conns:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not accepted")
+	}
+}
+
+func TestListenerReadTimeout(t *testing.T) {
+	config := Config{}
+	l, err := config.Listen("tcp", "127.0.0.1:0", Options{ReadTimeout: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer l.Close()
+	conns := accept(l)
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer c.Close()
+
+	accepted := <-conns
+	_, err = accepted.Read(make([]byte, 1))
+	require.Error(t, err)
+	netErr, ok := err.(net.Error)
+	require.True(t, ok)
+	require.True(t, netErr.Timeout())
+}
+
+func TestListenerClose(t *testing.T) {
+	var accepted int
+	config := Config{}
+	l, err := config.Listen("tcp", "127.0.0.1:0", Options{
+		OnAccept: func(c net.Conn) {
+			accepted++
+			require.IsType(t, &net.TCPConn{}, c)
+		},
+	})
+	require.NoError(t, err)
+	conns := accept(l)
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer c.Close()
+	<-conns
+	require.Equal(t, 1, accepted)
+
+	require.NoError(t, l.Close())
+	requireClosed(t, c)
+}
+
+func TestListenerTLSAllowedCNs(t *testing.T) {
+	config := Config{ServerConfig: *pki.TLSServerConfig()}
+	config.TLSAllowedCNs = []string{"other.localdomain"}
+	l, err := config.Listen("tcp", "127.0.0.1:0", Options{})
+	require.NoError(t, err)
+	defer l.Close()
+	conns := accept(l)
+
+	// The server handshake runs on the first read.
+	go func() {
+		accepted := <-conns
+		accepted.Read(make([]byte, 1))
+	}()
+
+	clientConfig, err := pki.TLSClientConfig().TLSConfig()
+	require.NoError(t, err)
+	c, err := tls.Dial("tcp", l.Addr().String(), clientConfig)
+	if err == nil {
+		// With TLS 1.3, the client learns of the rejection on its first read.
+		defer c.Close()
+		_, err = c.Read(make([]byte, 1))
+	}
+	require.Error(t, err)
+}
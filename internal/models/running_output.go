@@ -219,6 +219,12 @@ func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 	return err
 }
 
+// MetricsDropped returns the number of metrics dropped because the buffer was
+// full.
+func (ro *RunningOutput) MetricsDropped() int64 {
+	return ro.buffer.MetricsDropped.Get()
+}
+
 func (ro *RunningOutput) LogBufferStatus() {
 	nBuffer := ro.buffer.Len()
 	log.Printf("D! [outputs.%s] buffer fullness: %d / %d metrics. ",
@@ -20,10 +20,23 @@ func (rp RunningProcessors) Len() int           { return len(rp) }
 func (rp RunningProcessors) Swap(i, j int)      { rp[i], rp[j] = rp[j], rp[i] }
 func (rp RunningProcessors) Less(i, j int) bool { return rp[i].Config.Order < rp[j].Config.Order }
 
+// Processor stages, selecting the metrics a processor is applied to.
+const (
+	// ProcessorStageAll applies the processor to metrics from inputs, before
+	// the aggregators, and to metrics emitted by the aggregators.
+	ProcessorStageAll = "all"
+	// ProcessorStageInputs applies the processor to metrics from inputs only.
+	ProcessorStageInputs = "inputs"
+	// ProcessorStageAggregators applies the processor to metrics emitted by
+	// the aggregators only.
+	ProcessorStageAggregators = "aggregators"
+)
+
 // FilterConfig containing a name and filter
 type ProcessorConfig struct {
 	Name   string
 	Order  int64
+	Stage  string
 	Filter Filter
 }
 
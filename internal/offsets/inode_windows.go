@@ -0,0 +1,10 @@
+package offsets
+
+import (
+	"os"
+)
+
+// inode is not available on Windows, files are identified by their path only.
+func inode(info os.FileInfo) uint64 {
+	return 0
+}
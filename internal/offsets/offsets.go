@@ -0,0 +1,201 @@
+// Package offsets persists the read offsets of files in a state file, so that
+// reading them can be resumed where it stopped.
+package offsets
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/influxdata/tail"
+)
+
+// Position is the offset up to which a file has been read.  The inode
+// identifies the file, so that a file replaced under the same path is not
+// resumed at the offset of its predecessor.
+type Position struct {
+	Path   string `json:"path"`
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+// Store holds the positions of files and saves them to the state file.
+type Store struct {
+	filename  string
+	positions map[string]Position
+	dirty     bool
+
+	sync.Mutex
+}
+
+// Load reads the positions from the state file.  A missing state file is not
+// an error, the store is empty in that case.
+func Load(filename string) (*Store, error) {
+	s := &Store{
+		filename:  filename,
+		positions: make(map[string]Position),
+	}
+
+	buf, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []Position
+	if err := json.Unmarshal(buf, &positions); err != nil {
+		return nil, err
+	}
+	for _, pos := range positions {
+		s.positions[pos.Path] = pos
+	}
+	return s, nil
+}
+
+// Lookup returns the position to start reading the file at.  This is the
+// stored position of the file, unless the file was replaced or truncated
+// since.  Otherwise it is the start of the file, or its end if fromEnd is
+// set.
+func (s *Store) Lookup(path string, fromEnd bool) (Position, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Position{}, err
+	}
+	pos := Position{Path: path, Inode: inode(info)}
+
+	s.Lock()
+	stored, ok := s.positions[path]
+	s.Unlock()
+
+	if ok && stored.Inode == pos.Inode && stored.Offset <= info.Size() {
+		return stored, nil
+	}
+	if fromEnd {
+		pos.Offset = info.Size()
+	}
+	return pos, nil
+}
+
+// Update records the position, replacing the stored one of the file.
+func (s *Store) Update(pos Position) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.positions[pos.Path] = pos
+	s.dirty = true
+}
+
+// Save writes the positions to the state file, if they changed since the
+// last save.  Positions of files that were removed or replaced are dropped.
+func (s *Store) Save() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+
+	positions := make([]Position, 0, len(s.positions))
+	for path, pos := range s.positions {
+		info, err := os.Stat(path)
+		if err != nil || inode(info) != pos.Inode {
+			delete(s.positions, path)
+			continue
+		}
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].Path < positions[j].Path
+	})
+
+	buf, err := json.Marshal(positions)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that the state file is never left
+	// partially written.
+	tmpfile, err := ioutil.TempFile(filepath.Dir(s.filename), filepath.Base(s.filename))
+	if err != nil {
+		return err
+	}
+	_, err = tmpfile.Write(buf)
+	if cerr := tmpfile.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpfile.Name(), s.filename)
+	}
+	if err != nil {
+		os.Remove(tmpfile.Name())
+		return err
+	}
+
+	s.dirty = false
+	return nil
+}
+
+// File is the file read by a tailer, which reopens the file at its path when
+// it is truncated or replaced, as by log rotation.
+type File struct {
+	path  string
+	inode uint64
+	file  *os.File
+}
+
+// OpenFile opens the file at the path, as the tailer does.
+func OpenFile(path string) (*File, error) {
+	f := &File{path: path}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) open() error {
+	file, err := tail.OpenFile(f.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file = file
+	f.inode = inode(info)
+	return nil
+}
+
+// Inode returns the inode of the file read.
+func (f *File) Inode() uint64 {
+	return f.inode
+}
+
+// Reopened returns true if a line of n bytes read after offset is not in the
+// file read so far, because the file is shorter.  The tailer then reopened
+// the file after it was truncated or replaced, and the line is at the start
+// of the file now at the path, which is read from then on.
+func (f *File) Reopened(offset int64, n int64) bool {
+	info, err := f.file.Stat()
+	if err != nil || info.Size() >= offset+n {
+		return false
+	}
+	// If the file cannot be opened, the inode of the replaced file is kept,
+	// so that the offsets are not stored for the wrong file.
+	f.open()
+	return true
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,107 @@
+package offsets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "offsets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logfile := filepath.Join(dir, "test.log")
+	require.NoError(t, ioutil.WriteFile(logfile, []byte("line1\nline2\n"), 0644))
+
+	statefile := filepath.Join(dir, "state")
+	s, err := Load(statefile)
+	require.NoError(t, err)
+
+	pos, err := s.Lookup(logfile, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), pos.Offset)
+
+	pos, err = s.Lookup(logfile, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(12), pos.Offset)
+
+	s.Update(pos)
+	require.NoError(t, s.Save())
+
+	s, err = Load(statefile)
+	require.NoError(t, err)
+
+	pos, err = s.Lookup(logfile, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(12), pos.Offset)
+
+	// A truncated file is read from the start.
+	require.NoError(t, ioutil.WriteFile(logfile, []byte("line1\n"), 0644))
+	pos, err = s.Lookup(logfile, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), pos.Offset)
+}
+
+func TestStore_RemovedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "offsets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logfile := filepath.Join(dir, "test.log")
+	require.NoError(t, ioutil.WriteFile(logfile, []byte("line1\n"), 0644))
+
+	statefile := filepath.Join(dir, "state")
+	s, err := Load(statefile)
+	require.NoError(t, err)
+
+	pos, err := s.Lookup(logfile, true)
+	require.NoError(t, err)
+	s.Update(pos)
+
+	require.NoError(t, os.Remove(logfile))
+	require.NoError(t, s.Save())
+
+	buf, err := ioutil.ReadFile(statefile)
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(buf))
+}
+
+func TestFile_Reopened(t *testing.T) {
+	dir, err := ioutil.TempDir("", "offsets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logfile := filepath.Join(dir, "test.log")
+	require.NoError(t, ioutil.WriteFile(logfile, []byte("line1\nline2\n"), 0644))
+
+	f, err := OpenFile(logfile)
+	require.NoError(t, err)
+	defer f.Close()
+	inode := f.Inode()
+
+	require.False(t, f.Reopened(0, 6))
+	require.False(t, f.Reopened(6, 6))
+
+	// Truncated and written again, as with copytruncate.
+	require.NoError(t, ioutil.WriteFile(logfile, []byte("line3\n"), 0644))
+	require.True(t, f.Reopened(12, 6))
+	require.Equal(t, inode, f.Inode())
+	require.False(t, f.Reopened(0, 6))
+
+	// Replaced by a new file, the remaining lines of the renamed file are
+	// still read first.
+	require.NoError(t, ioutil.WriteFile(logfile, []byte("line3\nline4\n"), 0644))
+	require.NoError(t, os.Rename(logfile, logfile+".1"))
+	require.NoError(t, ioutil.WriteFile(logfile, []byte("line5\n"), 0644))
+	require.False(t, f.Reopened(6, 6))
+	require.True(t, f.Reopened(12, 6))
+	if runtime.GOOS != "windows" {
+		require.NotEqual(t, inode, f.Inode())
+	}
+	require.False(t, f.Reopened(0, 6))
+}
@@ -5,7 +5,7 @@ import (
 	"strings"
 )
 
-// Framing represents the framing technique we expect the messages to come.
+// Framing represents the framing technique of syslog messages in a stream.
 type Framing int
 
 const (
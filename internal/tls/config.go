@@ -25,6 +25,7 @@ type ServerConfig struct {
 	TLSCert           string   `toml:"tls_cert"`
 	TLSKey            string   `toml:"tls_key"`
 	TLSAllowedCACerts []string `toml:"tls_allowed_cacerts"`
+	TLSAllowedCNs     []string `toml:"tls_allowed_cns"`
 }
 
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
@@ -90,6 +91,13 @@ func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
 		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 	}
 
+	if len(c.TLSAllowedCNs) != 0 {
+		if len(c.TLSAllowedCACerts) == 0 {
+			return nil, fmt.Errorf("tls_allowed_cns requires tls_allowed_cacerts")
+		}
+		tlsConfig.VerifyPeerCertificate = verifyCommonName(c.TLSAllowedCNs)
+	}
+
 	if c.TLSCert != "" && c.TLSKey != "" {
 		err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey)
 		if err != nil {
@@ -100,6 +108,26 @@ func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
 	return tlsConfig, nil
 }
 
+// verifyCommonName returns a function verifying that the common name of the
+// client certificate is one of the allowed names.
+func verifyCommonName(allowed []string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		for _, chain := range verifiedChains {
+			if len(chain) == 0 {
+				continue
+			}
+			cn := chain[0].Subject.CommonName
+			for _, name := range allowed {
+				if cn == name {
+					return nil
+				}
+			}
+			return fmt.Errorf("common name %q of client certificate is not allowed", cn)
+		}
+		return fmt.Errorf("no verified client certificate")
+	}
+}
+
 func makeCertPool(certFiles []string) (*x509.CertPool, error) {
 	pool := x509.NewCertPool()
 	for _, certFile := range certFiles {
@@ -1,6 +1,8 @@
 package tls_test
 
 import (
+	"io/ioutil"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -144,6 +146,25 @@ func TestServerConfig(t *testing.T) {
 			expNil: true,
 			expErr: true,
 		},
+		{
+			name: "allowed cns",
+			server: tls.ServerConfig{
+				TLSCert:           pki.ServerCertPath(),
+				TLSKey:            pki.ServerKeyPath(),
+				TLSAllowedCACerts: []string{pki.CACertPath()},
+				TLSAllowedCNs:     []string{"client.localdomain"},
+			},
+		},
+		{
+			name: "allowed cns without allowed ca",
+			server: tls.ServerConfig{
+				TLSCert:       pki.ServerCertPath(),
+				TLSKey:        pki.ServerKeyPath(),
+				TLSAllowedCNs: []string{"client.localdomain"},
+			},
+			expNil: true,
+			expErr: true,
+		},
 		{
 			name: "invalid cert",
 			server: tls.ServerConfig{
@@ -224,3 +245,59 @@ func TestConnect(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 200, resp.StatusCode)
 }
+
+func TestConnectAllowedCNs(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowedCNs []string
+		expErr     bool
+	}{
+		{
+			name:       "allowed",
+			allowedCNs: []string{"other.localdomain", "client.localdomain"},
+		},
+		{
+			name:       "not allowed",
+			allowedCNs: []string{"other.localdomain"},
+			expErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverConfig := tls.ServerConfig{
+				TLSCert:           pki.ServerCertPath(),
+				TLSKey:            pki.ServerKeyPath(),
+				TLSAllowedCACerts: []string{pki.CACertPath()},
+				TLSAllowedCNs:     tt.allowedCNs,
+			}
+			serverTLSConfig, err := serverConfig.TLSConfig()
+			require.NoError(t, err)
+
+			ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			ts.TLS = serverTLSConfig
+			ts.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+			ts.StartTLS()
+			defer ts.Close()
+
+			clientTLSConfig, err := pki.TLSClientConfig().TLSConfig()
+			require.NoError(t, err)
+			client := http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: clientTLSConfig,
+				},
+				Timeout: 10 * time.Second,
+			}
+
+			resp, err := client.Get(ts.URL)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, 200, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"math"
+	"strconv"
+)
+
+// ToBool converts the value to a bool.
+func ToBool(v interface{}) (bool, bool) {
+	switch value := v.(type) {
+	case int64, uint64, float64:
+		if value != 0 {
+			return true, true
+		} else {
+			return false, false
+		}
+	case bool:
+		return value, true
+	case string:
+		result, err := strconv.ParseBool(value)
+		return result, err == nil
+	}
+	return false, false
+}
+
+// ToInt64 converts the value to an int64, out of range numbers are clamped.
+func ToInt64(v interface{}) (int64, bool) {
+	switch value := v.(type) {
+	case int64:
+		return value, true
+	case uint64:
+		if value <= uint64(math.MaxInt64) {
+			return int64(value), true
+		} else {
+			return math.MaxInt64, true
+		}
+	case float64:
+		if value < float64(math.MinInt64) {
+			return math.MinInt64, true
+		} else if value > float64(math.MaxInt64) {
+			return math.MaxInt64, true
+		} else {
+			return int64(value), true
+		}
+	case bool:
+		if value {
+			return 1, true
+		} else {
+			return 0, true
+		}
+	case string:
+		result, err := strconv.ParseInt(value, 10, 64)
+		return result, err == nil
+	}
+	return 0, false
+}
+
+// ToUint64 converts the value to an uint64, out of range numbers are clamped.
+func ToUint64(v interface{}) (uint64, bool) {
+	switch value := v.(type) {
+	case uint64:
+		return value, true
+	case int64:
+		if value < 0 {
+			return 0, true
+		} else {
+			return uint64(value), true
+		}
+	case float64:
+		if value < 0.0 {
+			return 0, true
+		} else if value > float64(math.MaxUint64) {
+			return math.MaxUint64, true
+		} else {
+			return uint64(value), true
+		}
+	case bool:
+		if value {
+			return 1, true
+		} else {
+			return 0, true
+		}
+	case string:
+		result, err := strconv.ParseUint(value, 10, 64)
+		return result, err == nil
+	}
+	return 0, false
+}
+
+// ToFloat64 converts the value to a float64.
+func ToFloat64(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case int64:
+		return float64(value), true
+	case uint64:
+		return float64(value), true
+	case float64:
+		return value, true
+	case bool:
+		if value {
+			return 1.0, true
+		} else {
+			return 0.0, true
+		}
+	case string:
+		result, err := strconv.ParseFloat(value, 64)
+		return result, err == nil
+	}
+	return 0.0, false
+}
+
+// ToString converts the value to its string representation.
+func ToString(v interface{}) (string, bool) {
+	switch value := v.(type) {
+	case int64:
+		return strconv.FormatInt(value, 10), true
+	case uint64:
+		return strconv.FormatUint(value, 10), true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(value), true
+	case string:
+		return value, true
+	}
+	return "", false
+}
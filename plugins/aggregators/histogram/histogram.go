@@ -11,12 +11,20 @@ import (
 // bucketTag is the tag, which contains right bucket border
 const bucketTag = "le"
 
+// bucketLeftTag is the tag, which contains left bucket border (exclusive)
+const bucketLeftTag = "gt"
+
 // bucketInf is the right bucket border for infinite values
 const bucketInf = "+Inf"
 
+// bucketNegInf is the left bucket border for infinite values
+const bucketNegInf = "-Inf"
+
 // HistogramAggregator is aggregator with histogram configs and particular histograms for defined metrics
 type HistogramAggregator struct {
-	Configs []config `toml:"config"`
+	Configs      []config `toml:"config"`
+	ResetBuckets bool     `toml:"reset"`
+	Cumulative   bool     `toml:"cumulative"`
 
 	buckets bucketsByMetrics
 	cache   map[uint64]metricHistogramCollection
@@ -41,6 +49,7 @@ type buckets []float64
 // metricHistogramCollection aggregates the histogram data
 type metricHistogramCollection struct {
 	histogramCollection map[string]counts
+	sumCollection       map[string]float64
 	name                string
 	tags                map[string]string
 }
@@ -52,12 +61,14 @@ type counts []int64
 type groupedByCountFields struct {
 	name            string
 	tags            map[string]string
-	fieldsWithCount map[string]int64
+	fieldsWithCount map[string]interface{}
 }
 
 // NewHistogramAggregator creates new histogram aggregator
 func NewHistogramAggregator() telegraf.Aggregator {
-	h := &HistogramAggregator{}
+	h := &HistogramAggregator{
+		Cumulative: true,
+	}
 	h.buckets = make(bucketsByMetrics)
 	h.resetCache()
 
@@ -72,6 +83,14 @@ var sampleConfig = `
   ## aggregator and will not get sent to the output plugins.
   drop_original = false
 
+  ## If true, the histogram will be reset on flush instead
+  ## of accumulating the results.
+  reset = false
+
+  ## Whether bucket values should be accumulated. If set to false, "gt" tag will be added.
+  ## Defaults to true.
+  cumulative = true
+
   ## Example config that aggregates all fields of the metric.
   # [[aggregators.histogram.config]]
   #   ## The set of buckets.
@@ -120,6 +139,7 @@ func (h *HistogramAggregator) Add(in telegraf.Metric) {
 			name:                in.Name(),
 			tags:                in.Tags(),
 			histogramCollection: make(map[string]counts),
+			sumCollection:       make(map[string]float64),
 		}
 	}
 
@@ -132,6 +152,7 @@ func (h *HistogramAggregator) Add(in telegraf.Metric) {
 			if value, ok := convert(value); ok {
 				index := sort.SearchFloat64s(buckets, value)
 				agr.histogramCollection[field][index]++
+				agr.sumCollection[field] += value
 			}
 		}
 	}
@@ -146,11 +167,12 @@ func (h *HistogramAggregator) Push(acc telegraf.Accumulator) {
 	for _, aggregate := range h.cache {
 		for field, counts := range aggregate.histogramCollection {
 			h.groupFieldsByBuckets(&metricsWithGroupedFields, aggregate.name, field, copyTags(aggregate.tags), counts)
+			h.groupFieldsBySummary(&metricsWithGroupedFields, aggregate, field, counts)
 		}
 	}
 
 	for _, metric := range metricsWithGroupedFields {
-		acc.AddFields(metric.name, makeFieldsWithCount(metric.fieldsWithCount), metric.tags)
+		acc.AddFields(metric.name, metric.fieldsWithCount, metric.tags)
 	}
 }
 
@@ -162,18 +184,45 @@ func (h *HistogramAggregator) groupFieldsByBuckets(
 	tags map[string]string,
 	counts []int64,
 ) {
-	count := int64(0)
-	for index, bucket := range h.getBuckets(name, field) {
-		count += counts[index]
+	sum := int64(0)
+	buckets := h.getBuckets(name, field) // note that len(buckets) + 1 == len(counts)
+
+	for index, count := range counts {
+		if !h.Cumulative {
+			sum = 0 // reset sum -> don't store cumulative counts
 
-		tags[bucketTag] = strconv.FormatFloat(bucket, 'f', -1, 64)
-		h.groupField(metricsWithGroupedFields, name, field, count, copyTags(tags))
+			tags[bucketLeftTag] = bucketNegInf
+			if index > 0 {
+				tags[bucketLeftTag] = strconv.FormatFloat(buckets[index-1], 'f', -1, 64)
+			}
+		}
+
+		tags[bucketTag] = bucketInf
+		if index < len(buckets) {
+			tags[bucketTag] = strconv.FormatFloat(buckets[index], 'f', -1, 64)
+		}
+
+		sum += count
+		h.groupField(metricsWithGroupedFields, name, field+"_bucket", sum, copyTags(tags))
 	}
+}
 
-	count += counts[len(counts)-1]
-	tags[bucketTag] = bucketInf
+// groupFieldsBySummary groups the sum and the count of the field values, the tags of this metric don't
+// contain the bucket borders
+func (h *HistogramAggregator) groupFieldsBySummary(
+	metricsWithGroupedFields *[]groupedByCountFields,
+	aggregate metricHistogramCollection,
+	field string,
+	counts []int64,
+) {
+	total := int64(0)
+	for _, count := range counts {
+		total += count
+	}
 
-	h.groupField(metricsWithGroupedFields, name, field, count, tags)
+	tags := copyTags(aggregate.tags)
+	h.groupField(metricsWithGroupedFields, aggregate.name, field+"_count", total, tags)
+	h.groupField(metricsWithGroupedFields, aggregate.name, field+"_sum", aggregate.sumCollection[field], tags)
 }
 
 // groupField groups field by count value
@@ -181,18 +230,18 @@ func (h *HistogramAggregator) groupField(
 	metricsWithGroupedFields *[]groupedByCountFields,
 	name string,
 	field string,
-	count int64,
+	value interface{},
 	tags map[string]string,
 ) {
 	for key, metric := range *metricsWithGroupedFields {
 		if name == metric.name && isTagsIdentical(tags, metric.tags) {
-			(*metricsWithGroupedFields)[key].fieldsWithCount[field] = count
+			(*metricsWithGroupedFields)[key].fieldsWithCount[field] = value
 			return
 		}
 	}
 
-	fieldsWithCount := map[string]int64{
-		field: count,
+	fieldsWithCount := map[string]interface{}{
+		field: value,
 	}
 
 	*metricsWithGroupedFields = append(
@@ -201,9 +250,14 @@ func (h *HistogramAggregator) groupField(
 	)
 }
 
-// Reset does nothing, because we need to collect counts for a long time, otherwise if config parameter 'reset' has
-// small value, we will get a histogram with a small amount of the distribution.
-func (h *HistogramAggregator) Reset() {}
+// Reset does nothing by default, because we typically need to collect counts for a long time.
+// Otherwise if config parameter 'reset' has 'true' value, we will get a histogram
+// with a small amount of the distribution. However in some use cases a reset is useful.
+func (h *HistogramAggregator) Reset() {
+	if h.ResetBuckets {
+		h.resetCache()
+	}
+}
 
 // resetCache resets cached counts(hits) in the buckets
 func (h *HistogramAggregator) resetCache() {
@@ -297,16 +351,6 @@ func isTagsIdentical(originalTags, checkedTags map[string]string) bool {
 	return true
 }
 
-// makeFieldsWithCount assigns count value to all metric fields
-func makeFieldsWithCount(fieldsWithCountIn map[string]int64) map[string]interface{} {
-	fieldsWithCountOut := map[string]interface{}{}
-	for field, count := range fieldsWithCountIn {
-		fieldsWithCountOut[field+"_bucket"] = count
-	}
-
-	return fieldsWithCountOut
-}
-
 // init initializes histogram aggregator plugin
 func init() {
 	aggregators.Add("histogram", func() telegraf.Aggregator {
@@ -12,8 +12,8 @@ import (
 )
 
 // NewTestHistogram creates new test histogram aggregation with specified config
-func NewTestHistogram(cfg []config) telegraf.Aggregator {
-	htm := &HistogramAggregator{Configs: cfg}
+func NewTestHistogram(cfg []config, reset bool, cumulative bool) telegraf.Aggregator {
+	htm := &HistogramAggregator{Configs: cfg, ResetBuckets: reset, Cumulative: cumulative}
 	htm.buckets = make(bucketsByMetrics)
 	htm.resetCache()
 
@@ -69,7 +69,7 @@ func BenchmarkApply(b *testing.B) {
 func TestHistogramWithPeriodAndOneField(t *testing.T) {
 	var cfg []config
 	cfg = append(cfg, config{Metric: "first_metric_name", Fields: []string{"a"}, Buckets: []float64{0.0, 10.0, 20.0, 30.0, 40.0}})
-	histogram := NewTestHistogram(cfg)
+	histogram := NewTestHistogram(cfg, false, true)
 
 	acc := &testutil.Accumulator{}
 
@@ -77,7 +77,7 @@ func TestHistogramWithPeriodAndOneField(t *testing.T) {
 	histogram.Add(firstMetric2)
 	histogram.Push(acc)
 
-	if len(acc.Metrics) != 6 {
+	if len(acc.Metrics) != 7 {
 		assert.Fail(t, "Incorrect number of metrics")
 	}
 	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(0)}, "0")
@@ -93,7 +93,7 @@ func TestHistogramWithPeriodAndAllFields(t *testing.T) {
 	var cfg []config
 	cfg = append(cfg, config{Metric: "first_metric_name", Buckets: []float64{0.0, 15.5, 20.0, 30.0, 40.0}})
 	cfg = append(cfg, config{Metric: "second_metric_name", Buckets: []float64{0.0, 4.0, 10.0, 23.0, 30.0}})
-	histogram := NewTestHistogram(cfg)
+	histogram := NewTestHistogram(cfg, false, true)
 
 	acc := &testutil.Accumulator{}
 
@@ -102,7 +102,7 @@ func TestHistogramWithPeriodAndAllFields(t *testing.T) {
 	histogram.Add(secondMetric)
 	histogram.Push(acc)
 
-	if len(acc.Metrics) != 12 {
+	if len(acc.Metrics) != 14 {
 		assert.Fail(t, "Incorrect number of metrics")
 	}
 
@@ -127,7 +127,7 @@ func TestHistogramDifferentPeriodsAndAllFields(t *testing.T) {
 
 	var cfg []config
 	cfg = append(cfg, config{Metric: "first_metric_name", Buckets: []float64{0.0, 10.0, 20.0, 30.0, 40.0}})
-	histogram := NewTestHistogram(cfg)
+	histogram := NewTestHistogram(cfg, false, true)
 
 	acc := &testutil.Accumulator{}
 	histogram.Add(firstMetric1)
@@ -152,6 +152,83 @@ func TestHistogramDifferentPeriodsAndAllFields(t *testing.T) {
 	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(2), "b_bucket": int64(1), "c_bucket": int64(1)}, bucketInf)
 }
 
+// TestHistogramNonCumulative tests metrics for one period and for one field with non-cumulative buckets
+func TestHistogramNonCumulative(t *testing.T) {
+	var cfg []config
+	cfg = append(cfg, config{Metric: "first_metric_name", Fields: []string{"a"}, Buckets: []float64{0.0, 10.0, 20.0, 30.0, 40.0}})
+	histogram := NewTestHistogram(cfg, false, false)
+
+	acc := &testutil.Accumulator{}
+
+	histogram.Add(firstMetric1)
+	histogram.Add(firstMetric2)
+	histogram.Push(acc)
+
+	if len(acc.Metrics) != 7 {
+		assert.Fail(t, "Incorrect number of metrics")
+	}
+
+	sum := firstMetric1.Fields()["a"].(float64)
+	sum += firstMetric2.Fields()["a"].(float64)
+
+	tags := func(gt, le string) map[string]string {
+		return map[string]string{"tag_name": "tag_value", bucketLeftTag: gt, bucketTag: le}
+	}
+	acc.AssertContainsTaggedFields(t, "first_metric_name", map[string]interface{}{"a_bucket": int64(0)}, tags(bucketNegInf, "0"))
+	acc.AssertContainsTaggedFields(t, "first_metric_name", map[string]interface{}{"a_bucket": int64(0)}, tags("0", "10"))
+	acc.AssertContainsTaggedFields(t, "first_metric_name", map[string]interface{}{"a_bucket": int64(2)}, tags("10", "20"))
+	acc.AssertContainsTaggedFields(t, "first_metric_name", map[string]interface{}{"a_bucket": int64(0)}, tags("20", "30"))
+	acc.AssertContainsTaggedFields(t, "first_metric_name", map[string]interface{}{"a_bucket": int64(0)}, tags("30", "40"))
+	acc.AssertContainsTaggedFields(t, "first_metric_name", map[string]interface{}{"a_bucket": int64(0)}, tags("40", bucketInf))
+	acc.AssertContainsTaggedFields(
+		t,
+		"first_metric_name",
+		map[string]interface{}{"a_count": int64(2), "a_sum": sum},
+		map[string]string{"tag_name": "tag_value"},
+	)
+}
+
+// TestHistogramWithReset tests that the counts are cleared between periods if reset is enabled
+func TestHistogramWithReset(t *testing.T) {
+	var cfg []config
+	cfg = append(cfg, config{Metric: "first_metric_name", Fields: []string{"a"}, Buckets: []float64{0.0, 10.0, 20.0, 30.0, 40.0}})
+	histogram := NewTestHistogram(cfg, true, true)
+
+	acc := &testutil.Accumulator{}
+
+	histogram.Add(firstMetric1)
+	histogram.Push(acc)
+	histogram.Reset()
+
+	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(1)}, "20")
+	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(1)}, bucketInf)
+
+	acc.ClearMetrics()
+	histogram.Add(firstMetric2)
+	histogram.Push(acc)
+	histogram.Reset()
+
+	if len(acc.Metrics) != 7 {
+		assert.Fail(t, "Incorrect number of metrics")
+	}
+	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(0)}, "10")
+	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(1)}, "20")
+	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(1)}, bucketInf)
+	acc.AssertContainsTaggedFields(
+		t,
+		"first_metric_name",
+		map[string]interface{}{"a_count": int64(1), "a_sum": float64(15.9)},
+		map[string]string{"tag_name": "tag_value"},
+	)
+
+	acc.ClearMetrics()
+	histogram.Push(acc)
+
+	if len(acc.Metrics) != 0 {
+		assert.Fail(t, "Incorrect number of metrics")
+	}
+}
+
 // TestWrongBucketsOrder tests the calling panic with incorrect order of buckets
 func TestWrongBucketsOrder(t *testing.T) {
 	defer func() {
@@ -166,7 +243,7 @@ func TestWrongBucketsOrder(t *testing.T) {
 
 	var cfg []config
 	cfg = append(cfg, config{Metric: "first_metric_name", Buckets: []float64{0.0, 90.0, 20.0, 30.0, 40.0}})
-	histogram := NewTestHistogram(cfg)
+	histogram := NewTestHistogram(cfg, false, true)
 	histogram.Add(firstMetric2)
 }
 
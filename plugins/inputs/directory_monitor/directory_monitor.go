@@ -0,0 +1,616 @@
+package directory_monitor
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const (
+	defaultMaxConcurrentFiles    = 1
+	defaultMaxUndeliveredMetrics = 1000
+	defaultMinFileAge            = 5 * time.Second
+	defaultParseMethod           = "at-once"
+
+	// maxLineSize is the maximum size of a line parsed line by line.
+	maxLineSize = 1024 * 1024
+)
+
+type empty struct{}
+type semaphore chan empty
+
+type DirectoryMonitor struct {
+	Directory             string            `toml:"directory"`
+	FinishedDirectory     string            `toml:"finished_directory"`
+	ErrorDirectory        string            `toml:"error_directory"`
+	FilesToMonitor        []string          `toml:"files_to_monitor"`
+	FilesToIgnore         []string          `toml:"files_to_ignore"`
+	MaxConcurrentFiles    int               `toml:"max_concurrent_files"`
+	MaxUndeliveredMetrics int               `toml:"max_undelivered_metrics"`
+	MinFileAge            internal.Duration `toml:"min_file_age"`
+	MaxFileAge            internal.Duration `toml:"max_file_age"`
+	FileTag               string            `toml:"file_tag"`
+	ParseMethod           string            `toml:"parse_method"`
+
+	parserFunc parsers.ParserFunc
+	monitor    []*regexp.Regexp
+	ignore     []*regexp.Regexp
+
+	acc   telegraf.TrackingAccumulator
+	sem   semaphore
+	scan  chan struct{}
+	files chan string
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	// pending holds the file of each metric not yet delivered.
+	pending     map[telegraf.TrackingID]*pendingFile
+	pendingLock sync.Mutex
+
+	// seen holds the files queued, being processed or whose metrics are not
+	// yet delivered, and the files that could not be processed and are left
+	// in place, so that they are not queued again.
+	seen     map[string]bool
+	seenLock sync.Mutex
+}
+
+// pendingFile is a file whose metrics are being added or delivered.  It is
+// moved out of the directory once all its metrics are added and delivered.
+type pendingFile struct {
+	path string
+
+	// undelivered is the number of metrics added and not yet delivered, and
+	// complete is set once all the metrics of the file are added.
+	undelivered int
+	complete    bool
+
+	// rejected is set if a metric of the file was not delivered, and err
+	// holds the error that stopped the parsing of the file.
+	rejected bool
+	err      error
+}
+
+func NewDirectoryMonitor() *DirectoryMonitor {
+	return &DirectoryMonitor{
+		MaxConcurrentFiles:    defaultMaxConcurrentFiles,
+		MaxUndeliveredMetrics: defaultMaxUndeliveredMetrics,
+		MinFileAge:            internal.Duration{Duration: defaultMinFileAge},
+		ParseMethod:           defaultParseMethod,
+	}
+}
+
+const sampleConfig = `
+  ## Directory to monitor for new files.  Each file is processed once, and
+  ## then moved out of the directory.  Subdirectories are not monitored.
+  directory = "/var/spool/telegraf"
+
+  ## Directory to move the processed files to once their metrics have been
+  ## written by the outputs.  If not set, the processed files are deleted.  A
+  ## file of the same name in it is replaced.
+  finished_directory = "/var/spool/telegraf/finished"
+
+  ## Directory to move the files that could not be processed to.  If not set,
+  ## these files are left in place and not processed again until telegraf is
+  ## restarted.  Files whose metrics are not written by the outputs are left
+  ## in place and processed again on the next scan.
+  # error_directory = "/var/spool/telegraf/error"
+
+  ## Regular expressions matching the names of the files to process, all
+  ## files if empty, and of the files to ignore.
+  # files_to_monitor = ['\.csv(\.gz)?$']
+  # files_to_ignore = ['^\.']
+
+  ## Maximum number of files processed at the same time.
+  # max_concurrent_files = 1
+
+  ## Maximum number of metrics of the files processed that have not yet been
+  ## written by the outputs.  For best throughput set based on the size of
+  ## the output's metric_batch_size, and keep it below its
+  ## metric_buffer_limit.
+  # max_undelivered_metrics = 1000
+
+  ## Minimum time since the last modification of a file before it is
+  ## processed, so that files still being written are not processed.
+  # min_file_age = "5s"
+
+  ## Maximum time since the last modification of a file, older files are
+  ## ignored.  0 (default) is unlimited.
+  # max_file_age = "0s"
+
+  ## Name of the tag to add with the name of the file to the metrics, not
+  ## added if empty.
+  # file_tag = ""
+
+  ## Method used to parse the files, "at-once" parses each file as a whole,
+  ## and adds its metrics only if the whole file is parsed.  "line-by-line"
+  ## parses each line on its own, as in the tail input, without reading the
+  ## whole file into memory.  The first line is parsed as the header of the
+  ## csv data format.
+  # parse_method = "at-once"
+
+  ## Data format of the files, files ending in .gz are decompressed with gzip
+  ## first.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+func (d *DirectoryMonitor) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *DirectoryMonitor) Description() string {
+	return "Process new files of a directory once, moving them out afterwards"
+}
+
+func (d *DirectoryMonitor) SetParserFunc(fn parsers.ParserFunc) {
+	d.parserFunc = fn
+}
+
+// Init checks the directories, compiles the file patterns and sets the
+// defaults of unset options.
+func (d *DirectoryMonitor) Init() error {
+	if d.Directory == "" {
+		return errors.New("directory is required")
+	}
+	for _, dir := range []string{d.FinishedDirectory, d.ErrorDirectory} {
+		if dir != "" && filepath.Clean(dir) == filepath.Clean(d.Directory) {
+			return fmt.Errorf("'%s' is the monitored directory", dir)
+		}
+	}
+
+	var err error
+	d.monitor, err = compileAll(d.FilesToMonitor)
+	if err != nil {
+		return err
+	}
+	d.ignore, err = compileAll(d.FilesToIgnore)
+	if err != nil {
+		return err
+	}
+
+	if d.MaxConcurrentFiles <= 0 {
+		d.MaxConcurrentFiles = defaultMaxConcurrentFiles
+	}
+	if d.MaxUndeliveredMetrics <= 0 {
+		d.MaxUndeliveredMetrics = defaultMaxUndeliveredMetrics
+	}
+
+	switch d.ParseMethod {
+	case "":
+		d.ParseMethod = defaultParseMethod
+	case "at-once", "line-by-line":
+	default:
+		return fmt.Errorf("unknown parse_method '%s'", d.ParseMethod)
+	}
+	return nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, nil
+}
+
+func (d *DirectoryMonitor) Start(acc telegraf.Accumulator) error {
+	if err := d.Init(); err != nil {
+		return err
+	}
+
+	for _, dir := range []string{d.FinishedDirectory, d.ErrorDirectory} {
+		if dir == "" {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	d.acc = acc.WithTracking(d.MaxUndeliveredMetrics)
+	d.sem = make(semaphore, d.MaxUndeliveredMetrics)
+	d.scan = make(chan struct{}, 1)
+	d.files = make(chan string)
+	d.done = make(chan struct{})
+	d.seen = make(map[string]bool)
+	d.pending = make(map[telegraf.TrackingID]*pendingFile)
+
+	d.wg.Add(2)
+	go func() {
+		defer d.wg.Done()
+		d.scanner()
+	}()
+	go func() {
+		defer d.wg.Done()
+		d.deliveries()
+	}()
+	for i := 0; i < d.MaxConcurrentFiles; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.worker()
+		}()
+	}
+	return nil
+}
+
+// Gather triggers a scan of the directory for files to process.
+func (d *DirectoryMonitor) Gather(_ telegraf.Accumulator) error {
+	select {
+	case d.scan <- struct{}{}:
+	default:
+		// A scan is pending already.
+	}
+	return nil
+}
+
+// scanner is launched as a goroutine to queue the files of the directory
+// ready to be processed on each scan.
+func (d *DirectoryMonitor) scanner() {
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-d.scan:
+			if err := d.scanDirectory(); err != nil {
+				d.acc.AddError(err)
+			}
+		}
+	}
+}
+
+func (d *DirectoryMonitor) scanDirectory() error {
+	infos, err := ioutil.ReadDir(d.Directory)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, info := range infos {
+		if !info.Mode().IsRegular() || !d.matches(info.Name()) {
+			continue
+		}
+
+		age := now.Sub(info.ModTime())
+		if age < d.MinFileAge.Duration {
+			continue
+		}
+		if d.MaxFileAge.Duration > 0 && age > d.MaxFileAge.Duration {
+			continue
+		}
+
+		path := filepath.Join(d.Directory, info.Name())
+		if !d.markSeen(path) {
+			continue
+		}
+		select {
+		case d.files <- path:
+		case <-d.done:
+			return nil
+		}
+	}
+	return nil
+}
+
+// matches returns true if the file name is to be processed.
+func (d *DirectoryMonitor) matches(name string) bool {
+	for _, re := range d.ignore {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(d.monitor) == 0 {
+		return true
+	}
+	for _, re := range d.monitor {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// markSeen marks the file as seen, returning false if it already was.
+func (d *DirectoryMonitor) markSeen(path string) bool {
+	d.seenLock.Lock()
+	defer d.seenLock.Unlock()
+	if d.seen[path] {
+		return false
+	}
+	d.seen[path] = true
+	return true
+}
+
+func (d *DirectoryMonitor) forget(path string) {
+	d.seenLock.Lock()
+	defer d.seenLock.Unlock()
+	delete(d.seen, path)
+}
+
+func (d *DirectoryMonitor) worker() {
+	for {
+		select {
+		case <-d.done:
+			return
+		case path := <-d.files:
+			d.process(path)
+		}
+	}
+}
+
+// process adds the metrics of the file, which is moved out of the directory
+// once they are all delivered.  Files that cannot be moved stay marked as
+// seen, so that their metrics are not added again.
+func (d *DirectoryMonitor) process(path string) {
+	file := &pendingFile{path: path}
+	if err := d.processFile(file); err != nil {
+		file.err = err
+	}
+
+	d.pendingLock.Lock()
+	file.complete = true
+	done := file.undelivered == 0
+	d.pendingLock.Unlock()
+
+	if done {
+		d.fileDone(file)
+	}
+}
+
+// fileDone moves the file out of the directory once all its metrics are
+// added and delivered.  The files whose metrics were rejected by the outputs
+// are left in place to be processed again on the next scan.
+func (d *DirectoryMonitor) fileDone(file *pendingFile) {
+	switch {
+	case file.err == errStopping:
+		// The file is processed again after a restart.
+	case file.err != nil:
+		d.acc.AddError(fmt.Errorf("error processing %s: %v", file.path, file.err))
+		d.failed(file.path)
+	case file.rejected:
+		d.acc.AddError(fmt.Errorf("metrics of %s were not delivered, processing it again", file.path))
+		d.forget(file.path)
+	default:
+		d.finished(file.path)
+	}
+}
+
+// finished moves the file to the finished directory, or removes it.
+func (d *DirectoryMonitor) finished(path string) {
+	if d.FinishedDirectory == "" {
+		if err := os.Remove(path); err != nil {
+			d.acc.AddError(fmt.Errorf("error removing %s: %v", path, err))
+			return
+		}
+	} else if err := moveFile(path, d.FinishedDirectory); err != nil {
+		d.acc.AddError(fmt.Errorf("error moving %s: %v", path, err))
+		return
+	}
+	d.forget(path)
+}
+
+// failed moves the file to the error directory, or leaves it in place.
+func (d *DirectoryMonitor) failed(path string) {
+	if d.ErrorDirectory == "" {
+		return
+	}
+	if err := moveFile(path, d.ErrorDirectory); err != nil {
+		d.acc.AddError(fmt.Errorf("error moving %s: %v", path, err))
+		return
+	}
+	d.forget(path)
+}
+
+var errStopping = errors.New("stopping")
+
+// processFile parses the file and adds its metrics.  Parsed at once, the
+// metrics of the file are only added if the whole file is parsed, parsed
+// line by line the metrics of the lines before an error are added.
+func (d *DirectoryMonitor) processFile(file *pendingFile) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(file.path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	parser, err := d.parserFunc()
+	if err != nil {
+		return err
+	}
+
+	if d.ParseMethod == "line-by-line" {
+		return d.parseLines(parser, r, file)
+	}
+
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	metrics, err := parser.Parse(buf)
+	if err != nil {
+		return err
+	}
+	return d.addMetrics(metrics, file)
+}
+
+// parseLines parses the file line by line, the first line with Parse so that
+// it can hold the header of the csv data format.
+func (d *DirectoryMonitor) parseLines(parser parsers.Parser, r io.Reader, file *pendingFile) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+
+	firstLine := true
+	for scanner.Scan() {
+		// Fix up files with Windows line endings.
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		var metrics []telegraf.Metric
+		if firstLine {
+			var err error
+			metrics, err = parser.Parse([]byte(line))
+			if err != nil {
+				return err
+			}
+			firstLine = false
+		} else {
+			m, err := parser.ParseLine(line)
+			if err != nil {
+				return err
+			}
+			if m != nil {
+				metrics = []telegraf.Metric{m}
+			}
+		}
+
+		if err := d.addMetrics(metrics, file); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// addMetrics adds the metrics for tracking, each on its own so that the
+// metrics of a large file do not have to fit in the output buffer at once.
+func (d *DirectoryMonitor) addMetrics(metrics []telegraf.Metric, file *pendingFile) error {
+	for _, m := range metrics {
+		if d.FileTag != "" {
+			m.AddTag(d.FileTag, filepath.Base(file.path))
+		}
+
+		select {
+		case <-d.done:
+			return errStopping
+		case d.sem <- empty{}:
+		}
+
+		d.pendingLock.Lock()
+		id := d.acc.AddTrackingMetricGroup([]telegraf.Metric{m})
+		d.pending[id] = file
+		file.undelivered++
+		d.pendingLock.Unlock()
+	}
+	return nil
+}
+
+// deliveries is launched as a goroutine to move the files out of the
+// directory when their metrics are delivered.
+func (d *DirectoryMonitor) deliveries() {
+	for {
+		select {
+		case <-d.done:
+			return
+		case info := <-d.acc.Delivered():
+			<-d.sem
+			d.onDelivery(info)
+		}
+	}
+}
+
+// drainDeliveries handles the deliveries already waiting.
+func (d *DirectoryMonitor) drainDeliveries() {
+	for {
+		select {
+		case info := <-d.acc.Delivered():
+			<-d.sem
+			d.onDelivery(info)
+		default:
+			return
+		}
+	}
+}
+
+func (d *DirectoryMonitor) onDelivery(info telegraf.DeliveryInfo) {
+	d.pendingLock.Lock()
+	file, ok := d.pending[info.ID()]
+	delete(d.pending, info.ID())
+	var done bool
+	if ok {
+		file.undelivered--
+		if !info.Delivered() {
+			file.rejected = true
+		}
+		done = file.complete && file.undelivered == 0
+	}
+	d.pendingLock.Unlock()
+
+	if done {
+		d.fileDone(file)
+	}
+}
+
+// moveFile moves the file into the directory, copying it if it cannot be
+// renamed, as across file systems.
+func moveFile(path string, dir string) error {
+	dest := filepath.Join(dir, filepath.Base(path))
+	if err := os.Rename(path, dest); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dest)
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (d *DirectoryMonitor) Stop() {
+	close(d.done)
+	d.wg.Wait()
+
+	// Move out the files delivered while stopping, the files still pending
+	// are processed again after a restart.
+	d.drainDeliveries()
+}
+
+func init() {
+	inputs.Add("directory_monitor", func() telegraf.Input {
+		return NewDirectoryMonitor()
+	})
+}
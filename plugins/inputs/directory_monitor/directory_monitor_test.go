@@ -0,0 +1,349 @@
+package directory_monitor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDirectoryMonitor(t *testing.T) (*DirectoryMonitor, func()) {
+	dir, err := ioutil.TempDir("", "directory_monitor")
+	require.NoError(t, err)
+
+	d := NewDirectoryMonitor()
+	d.Directory = filepath.Join(dir, "spool")
+	d.FinishedDirectory = filepath.Join(dir, "finished")
+	d.ErrorDirectory = filepath.Join(dir, "error")
+	d.MinFileAge.Duration = 0
+	d.SetParserFunc(parsers.NewInfluxParser)
+	require.NoError(t, os.Mkdir(d.Directory, 0755))
+
+	return d, func() { os.RemoveAll(dir) }
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+// waitForFile waits for the file to exist, or not to if exists is false.
+func waitForFile(t *testing.T, path string, exists bool) {
+	for i := 0; i < 100; i++ {
+		_, err := os.Stat(path)
+		if exists == (err == nil) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("file %s exists: %v, expected %v", path, !exists, exists)
+}
+
+func TestProcessFiles(t *testing.T) {
+	d, cleanup := newTestDirectoryMonitor(t)
+	defer cleanup()
+	d.FileTag = "file"
+
+	writeFile(t, filepath.Join(d.Directory, "a.influx"), "cpu value=1 1000000000\ndisk value=2 2000000000\n")
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte("mem value=3 3000000000\n"))
+	w.Close()
+	writeFile(t, filepath.Join(d.Directory, "b.influx.gz"), buf.String())
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(&acc))
+
+	acc.Wait(3)
+	waitForFile(t, filepath.Join(d.FinishedDirectory, "a.influx"), true)
+	waitForFile(t, filepath.Join(d.FinishedDirectory, "b.influx.gz"), true)
+	waitForFile(t, filepath.Join(d.Directory, "a.influx"), false)
+	waitForFile(t, filepath.Join(d.Directory, "b.influx.gz"), false)
+
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"value": float64(1)}, map[string]string{"file": "a.influx"})
+	acc.AssertContainsTaggedFields(t, "disk",
+		map[string]interface{}{"value": float64(2)}, map[string]string{"file": "a.influx"})
+	acc.AssertContainsTaggedFields(t, "mem",
+		map[string]interface{}{"value": float64(3)}, map[string]string{"file": "b.influx.gz"})
+
+	// Processed files are not processed again.
+	require.NoError(t, d.Gather(&acc))
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, uint64(3), acc.NMetrics())
+}
+
+func TestProcessCSVFiles(t *testing.T) {
+	d, cleanup := newTestDirectoryMonitor(t)
+	defer cleanup()
+	d.FilesToMonitor = []string{`\.csv$`}
+	d.SetParserFunc(func() (parsers.Parser, error) {
+		return parsers.NewParser(&parsers.Config{
+			DataFormat:        "csv",
+			MetricName:        "batch",
+			CSVHeaderRowCount: 1,
+			CSVTagColumns:     []string{"host"},
+		})
+	})
+
+	// Each file has its own header.
+	writeFile(t, filepath.Join(d.Directory, "1.csv"), "host,value\nserver01,1\n")
+	writeFile(t, filepath.Join(d.Directory, "2.csv"), "host,value\nserver02,2\n")
+	writeFile(t, filepath.Join(d.Directory, "3.txt"), "not monitored\n")
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(&acc))
+
+	acc.Wait(2)
+	waitForFile(t, filepath.Join(d.FinishedDirectory, "2.csv"), true)
+
+	acc.AssertContainsTaggedFields(t, "batch",
+		map[string]interface{}{"value": int64(1)}, map[string]string{"host": "server01"})
+	acc.AssertContainsTaggedFields(t, "batch",
+		map[string]interface{}{"value": int64(2)}, map[string]string{"host": "server02"})
+
+	_, err := os.Stat(filepath.Join(d.Directory, "3.txt"))
+	require.NoError(t, err)
+}
+
+func TestErrorDirectory(t *testing.T) {
+	d, cleanup := newTestDirectoryMonitor(t)
+	defer cleanup()
+
+	writeFile(t, filepath.Join(d.Directory, "bad.influx"), "cpu value=1\ncpu value=\n")
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(&acc))
+
+	acc.WaitError(1)
+	waitForFile(t, filepath.Join(d.ErrorDirectory, "bad.influx"), true)
+	waitForFile(t, filepath.Join(d.Directory, "bad.influx"), false)
+	// No metric of a file is added unless the whole file is parsed.
+	require.Equal(t, uint64(0), acc.NMetrics())
+}
+
+func TestFailedFileLeftInPlace(t *testing.T) {
+	d, cleanup := newTestDirectoryMonitor(t)
+	defer cleanup()
+	d.ErrorDirectory = ""
+
+	writeFile(t, filepath.Join(d.Directory, "bad.influx"), "cpu value=\n")
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(&acc))
+	acc.WaitError(1)
+
+	require.NoError(t, d.Gather(&acc))
+	time.Sleep(100 * time.Millisecond)
+
+	acc.Lock()
+	require.Len(t, acc.Errors, 1)
+	acc.Unlock()
+	_, err := os.Stat(filepath.Join(d.Directory, "bad.influx"))
+	require.NoError(t, err)
+}
+
+func TestRejectedFile(t *testing.T) {
+	d, cleanup := newTestDirectoryMonitor(t)
+	defer cleanup()
+
+	path := filepath.Join(d.Directory, "a.influx")
+	writeFile(t, path, "cpu value=1\ndisk value=2\n")
+
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, d.Start(acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(acc))
+
+	// The file stays in place until all its metrics are delivered.
+	(<-acc.Tracked).Accept()
+	m := <-acc.Tracked
+	time.Sleep(100 * time.Millisecond)
+	_, err := os.Stat(path)
+	require.NoError(t, err)
+
+	// A rejected file is left in place, and processed again.
+	m.Reject()
+	acc.WaitError(1)
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(d.ErrorDirectory, "a.influx"))
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, d.Gather(acc))
+	(<-acc.Tracked).Accept()
+	(<-acc.Tracked).Accept()
+	waitForFile(t, filepath.Join(d.FinishedDirectory, "a.influx"), true)
+	waitForFile(t, path, false)
+}
+
+func TestUndeliveredFileLeftInPlace(t *testing.T) {
+	d, cleanup := newTestDirectoryMonitor(t)
+	defer cleanup()
+
+	path := filepath.Join(d.Directory, "a.influx")
+	writeFile(t, path, "cpu value=1\n")
+
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, d.Start(acc))
+	require.NoError(t, d.Gather(acc))
+	<-acc.Tracked
+	d.Stop()
+
+	_, err := os.Stat(path)
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(d.FinishedDirectory, "a.influx"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestMoreMetricsThanUndelivered(t *testing.T) {
+	for _, method := range []string{"at-once", "line-by-line"} {
+		t.Run(method, func(t *testing.T) {
+			d, cleanup := newTestDirectoryMonitor(t)
+			defer cleanup()
+			d.ParseMethod = method
+			d.MaxUndeliveredMetrics = 2
+
+			writeFile(t, filepath.Join(d.Directory, "a.influx"),
+				"cpu value=1\ncpu value=2\n\ncpu value=3\r\ncpu value=4\ncpu value=5\n")
+
+			acc := testutil.AcceptingAccumulator{}
+			require.NoError(t, d.Start(&acc))
+			defer d.Stop()
+			require.NoError(t, d.Gather(&acc))
+
+			acc.Wait(5)
+			waitForFile(t, filepath.Join(d.FinishedDirectory, "a.influx"), true)
+			require.Equal(t, uint64(5), acc.NMetrics())
+		})
+	}
+}
+
+func TestLineByLineCSV(t *testing.T) {
+	d, cleanup := newTestDirectoryMonitor(t)
+	defer cleanup()
+	d.ParseMethod = "line-by-line"
+	d.SetParserFunc(func() (parsers.Parser, error) {
+		return parsers.NewParser(&parsers.Config{
+			DataFormat:        "csv",
+			MetricName:        "batch",
+			CSVHeaderRowCount: 1,
+			CSVTagColumns:     []string{"host"},
+		})
+	})
+
+	writeFile(t, filepath.Join(d.Directory, "1.csv"), "host,value\nserver01,1\nserver02,2\n")
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(&acc))
+
+	acc.Wait(2)
+	waitForFile(t, filepath.Join(d.FinishedDirectory, "1.csv"), true)
+
+	acc.AssertContainsTaggedFields(t, "batch",
+		map[string]interface{}{"value": int64(1)}, map[string]string{"host": "server01"})
+	acc.AssertContainsTaggedFields(t, "batch",
+		map[string]interface{}{"value": int64(2)}, map[string]string{"host": "server02"})
+}
+
+func TestLineByLineError(t *testing.T) {
+	d, cleanup := newTestDirectoryMonitor(t)
+	defer cleanup()
+	d.ParseMethod = "line-by-line"
+
+	writeFile(t, filepath.Join(d.Directory, "bad.influx"), "cpu value=1\ncpu value=\ncpu value=3\n")
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(&acc))
+
+	// The metrics of the lines before the error are added.
+	acc.WaitError(1)
+	waitForFile(t, filepath.Join(d.ErrorDirectory, "bad.influx"), true)
+	require.Equal(t, uint64(1), acc.NMetrics())
+}
+
+func TestDeleteFinished(t *testing.T) {
+	d, cleanup := newTestDirectoryMonitor(t)
+	defer cleanup()
+	d.FinishedDirectory = ""
+
+	path := filepath.Join(d.Directory, "a.influx")
+	writeFile(t, path, "cpu value=1\n")
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(&acc))
+
+	acc.Wait(1)
+	waitForFile(t, path, false)
+}
+
+func TestFileAge(t *testing.T) {
+	d, cleanup := newTestDirectoryMonitor(t)
+	defer cleanup()
+	d.MinFileAge.Duration = time.Minute
+	d.MaxFileAge.Duration = time.Hour
+
+	now := time.Now()
+	files := map[string]time.Time{
+		"new.influx":   now,
+		"ready.influx": now.Add(-10 * time.Minute),
+		"old.influx":   now.Add(-2 * time.Hour),
+	}
+	for name, mtime := range files {
+		path := filepath.Join(d.Directory, name)
+		writeFile(t, path, "cpu value=1\n")
+		require.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, d.Start(&acc))
+	defer d.Stop()
+	require.NoError(t, d.Gather(&acc))
+
+	waitForFile(t, filepath.Join(d.FinishedDirectory, "ready.influx"), true)
+	time.Sleep(100 * time.Millisecond)
+
+	require.Equal(t, uint64(1), acc.NMetrics())
+	for _, name := range []string{"new.influx", "old.influx"} {
+		_, err := os.Stat(filepath.Join(d.Directory, name))
+		require.NoError(t, err)
+	}
+}
+
+func TestInitInvalid(t *testing.T) {
+	d := NewDirectoryMonitor()
+	require.Error(t, d.Init())
+
+	d.Directory = "/var/spool/telegraf"
+	d.FinishedDirectory = "/var/spool/telegraf/"
+	require.Error(t, d.Init())
+
+	d.FinishedDirectory = "/var/spool/telegraf/finished"
+	d.FilesToMonitor = []string{"("}
+	require.Error(t, d.Init())
+
+	d.FilesToMonitor = nil
+	d.ParseMethod = "streaming"
+	require.Error(t, d.Init())
+}
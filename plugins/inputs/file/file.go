@@ -5,16 +5,19 @@ import (
 	"io/ioutil"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/encoding"
 	"github.com/influxdata/telegraf/internal/globpath"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 type File struct {
-	Files  []string `toml:"files"`
-	parser parsers.Parser
+	Files             []string `toml:"files"`
+	CharacterEncoding string   `toml:"character_encoding"`
+	parser            parsers.Parser
 
 	filenames []string
+	encoding  *encoding.Encoding
 }
 
 const sampleConfig = `
@@ -26,6 +29,12 @@ const sampleConfig = `
   ##   /var/log/apache.log -> only read the apache log file
   files = ["/var/log/apache/access.log"]
 
+  ## Character encoding of the files, the contents are decoded to UTF-8
+  ## before parsing.  A byte order mark at the start of a file overrides the
+  ## unicode encodings.  When unset the contents are parsed as is.
+  ##   Supported: utf-8, utf-16le, utf-16be, iso-8859-1
+  # character_encoding = ""
+
   ## The dataformat to be read from files
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -42,6 +51,12 @@ func (f *File) Description() string {
 	return "Reload and gather from file[s] on telegraf's interval."
 }
 
+func (f *File) Init() error {
+	var err error
+	f.encoding, err = encoding.Lookup(f.CharacterEncoding)
+	return err
+}
+
 func (f *File) Gather(acc telegraf.Accumulator) error {
 	err := f.refreshFilePaths()
 	if err != nil {
@@ -87,8 +102,13 @@ func (f *File) readMetric(filename string) ([]telegraf.Metric, error) {
 	if err != nil {
 		return nil, fmt.Errorf("E! Error file: %v could not be read, %s", filename, err)
 	}
+	if f.encoding != nil {
+		fileContents, err = f.encoding.Decode(fileContents)
+		if err != nil {
+			return nil, fmt.Errorf("E! Error file: %v could not be decoded, %s", filename, err)
+		}
+	}
 	return f.parser.Parse(fileContents)
-
 }
 
 func init() {
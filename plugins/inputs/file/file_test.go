@@ -59,3 +59,43 @@ func TestGrokParser(t *testing.T) {
 	err = r.Gather(&acc)
 	assert.Equal(t, len(acc.Metrics), 2)
 }
+
+func TestCharacterEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		encoding string
+	}{
+		{"utf-16le with byte order mark", "testdata/utf16le_bom.log", "utf-16le"},
+		{"byte order mark overrides utf-16be", "testdata/utf16le_bom.log", "utf-16be"},
+		{"utf-16be", "testdata/utf16be.log", "utf-16be"},
+		{"iso-8859-1", "testdata/latin1.log", "iso-8859-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var acc testutil.Accumulator
+			r := File{
+				Files:             []string{tt.file},
+				CharacterEncoding: tt.encoding,
+			}
+			require.NoError(t, r.Init())
+
+			parser, err := parsers.NewInfluxParser()
+			require.NoError(t, err)
+			r.SetParser(parser)
+
+			require.NoError(t, r.Gather(&acc))
+			require.Len(t, acc.Metrics, 2)
+			for i, idle := range []float64{99, 98} {
+				assert.Equal(t, "cpu", acc.Metrics[i].Measurement)
+				assert.Equal(t, map[string]string{"host": "ünïcode"}, acc.Metrics[i].Tags)
+				assert.Equal(t, map[string]interface{}{"usage_idle": idle}, acc.Metrics[i].Fields)
+			}
+		})
+	}
+}
+
+func TestCharacterEncodingUnknown(t *testing.T) {
+	r := File{CharacterEncoding: "utf-32"}
+	require.Error(t, r.Init())
+}
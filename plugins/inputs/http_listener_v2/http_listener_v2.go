@@ -1,19 +1,19 @@
 package http_listener_v2
 
 import (
-	"compress/gzip"
 	"crypto/subtle"
-	"crypto/tls"
 	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
-	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/internal/delivery"
+	"github.com/influxdata/telegraf/internal/listener"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
@@ -23,6 +23,11 @@ import (
 // 500 MB
 const defaultMaxBodySize = 500 * 1024 * 1024
 
+const (
+	defaultDeliveryTimeout       = 30 * time.Second
+	defaultMaxUndeliveredMetrics = 1000
+)
+
 type TimeFunc func() time.Time
 
 type HTTPListenerV2 struct {
@@ -33,17 +38,23 @@ type HTTPListenerV2 struct {
 	WriteTimeout   internal.Duration
 	MaxBodySize    internal.Size
 	Port           int
+	MaxConnections int
 
-	tlsint.ServerConfig
+	listener.Config
 
 	BasicUsername string
 	BasicPassword string
 
+	DeliveryTracking      bool
+	DeliveryTimeout       internal.Duration
+	MaxUndeliveredMetrics int
+
 	TimeFunc
 
 	wg sync.WaitGroup
 
 	listener net.Listener
+	tracker  *delivery.Tracker
 
 	parsers.Parser
 	acc telegraf.Accumulator
@@ -76,11 +87,38 @@ const sampleConfig = `
   # tls_cert = "/etc/telegraf/cert.pem"
   # tls_key = "/etc/telegraf/key.pem"
 
+  ## Common names of the client certificates to accept, requires
+  ## tls_allowed_cacerts.
+  # tls_allowed_cns = ["client.example.com"]
+
+  ## Source networks, in CIDR notation, to accept or refuse requests from.
+  ## If allowed_networks is set, only clients of these networks are accepted.
+  # allowed_networks = ["10.0.0.0/8", "192.168.0.0/16"]
+  # denied_networks = ["10.0.0.1"]
+
+  ## Maximum number of concurrent connections.
+  ## 0 (default) is unlimited.
+  # max_connections = 1024
+
   ## Optional username and password to accept for HTTP basic authentication.
   ## You probably want to make sure you have TLS configured above for this.
   # basic_username = "foobar"
   # basic_password = "barfoo"
 
+  ## Hold the response to a request until its metrics are written by the
+  ## outputs, answering with 503 Service Unavailable if they are rejected, as
+  ## when an output buffer overflows, are not written within the
+  ## delivery_timeout, or would exceed max_undelivered_metrics.  Senders
+  ## retrying on 503 get their metrics delivered at least once.
+  # delivery_tracking = false
+
+  ## Maximum duration to wait for the delivery, should be longer than the
+  ## flush_interval.  The write_timeout is raised above it if needed.
+  # delivery_timeout = "30s"
+
+  ## Maximum number of metrics waiting for delivery.
+  # max_undelivered_metrics = 1000
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -117,32 +155,39 @@ func (h *HTTPListenerV2) Start(acc telegraf.Accumulator) error {
 		h.WriteTimeout.Duration = time.Second * 10
 	}
 
-	h.acc = acc
-
-	tlsConf, err := h.ServerConfig.TLSConfig()
-	if err != nil {
-		return err
+	if h.DeliveryTracking {
+		if h.DeliveryTimeout.Duration == 0 {
+			h.DeliveryTimeout.Duration = defaultDeliveryTimeout
+		}
+		if h.MaxUndeliveredMetrics == 0 {
+			h.MaxUndeliveredMetrics = defaultMaxUndeliveredMetrics
+		}
+		if h.WriteTimeout.Duration <= h.DeliveryTimeout.Duration {
+			h.WriteTimeout.Duration = h.DeliveryTimeout.Duration + time.Second*5
+		}
 	}
 
+	h.acc = acc
+
 	server := &http.Server{
 		Addr:         h.ServiceAddress,
 		Handler:      h,
 		ReadTimeout:  h.ReadTimeout.Duration,
 		WriteTimeout: h.WriteTimeout.Duration,
-		TLSConfig:    tlsConf,
 	}
 
-	var listener net.Listener
-	if tlsConf != nil {
-		listener, err = tls.Listen("tcp", h.ServiceAddress, tlsConf)
-	} else {
-		listener, err = net.Listen("tcp", h.ServiceAddress)
-	}
+	l, err := h.Listen("tcp", h.ServiceAddress, listener.Options{
+		MaxConnections: h.MaxConnections,
+	})
 	if err != nil {
 		return err
 	}
-	h.listener = listener
-	h.Port = listener.Addr().(*net.TCPAddr).Port
+	h.listener = l
+	h.Port = l.Addr().(*net.TCPAddr).Port
+
+	if h.DeliveryTracking {
+		h.tracker = delivery.NewTracker(acc, h.MaxUndeliveredMetrics)
+	}
 
 	h.wg.Add(1)
 	go func() {
@@ -159,6 +204,9 @@ func (h *HTTPListenerV2) Start(acc telegraf.Accumulator) error {
 func (h *HTTPListenerV2) Stop() {
 	h.listener.Close()
 	h.wg.Wait()
+	if h.tracker != nil {
+		h.tracker.Stop()
+	}
 
 	log.Println("I! Stopped HTTP listener V2 service on ", h.ServiceAddress)
 }
@@ -191,23 +239,30 @@ func (h *HTTPListenerV2) serveWrite(res http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	// Handle gzip request bodies
-	body := req.Body
-	if req.Header.Get("Content-Encoding") == "gzip" {
-		var err error
-		body, err = gzip.NewReader(req.Body)
-		if err != nil {
-			log.Println("D! " + err.Error())
-			badRequest(res)
-			return
-		}
-		defer body.Close()
+	// Handle compressed request bodies
+	encoding := req.Header.Get("Content-Encoding")
+	if !internal.IsSupportedContentEncoding(encoding) {
+		unsupportedMediaType(res)
+		return
 	}
+	body, err := internal.NewStreamContentDecoder(encoding, req.Body)
+	if err != nil {
+		log.Println("D! " + err.Error())
+		badRequest(res)
+		return
+	}
+	defer body.Close()
 
+	// The limit applies to the decoded body.
 	body = http.MaxBytesReader(res, body, h.MaxBodySize.Size)
 	bytes, err := ioutil.ReadAll(body)
 	if err != nil {
-		tooLarge(res)
+		if strings.HasSuffix(err.Error(), "request body too large") {
+			tooLarge(res)
+		} else {
+			log.Println("D! " + err.Error())
+			badRequest(res)
+		}
 		return
 	}
 
@@ -217,12 +272,39 @@ func (h *HTTPListenerV2) serveWrite(res http.ResponseWriter, req *http.Request)
 		badRequest(res)
 		return
 	}
+
+	if h.tracker != nil {
+		h.deliver(res, metrics)
+		return
+	}
 	for _, m := range metrics {
 		h.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
 	}
 	res.WriteHeader(http.StatusNoContent)
 }
 
+// deliver adds the metrics with delivery tracking and responds once they are
+// delivered.
+func (h *HTTPListenerV2) deliver(res http.ResponseWriter, metrics []telegraf.Metric) {
+	delivered, err := h.tracker.Add(metrics)
+	if err != nil {
+		log.Println("D! " + err.Error())
+		serviceUnavailable(res)
+		return
+	}
+
+	select {
+	case ok := <-delivered:
+		if !ok {
+			serviceUnavailable(res)
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	case <-time.After(h.DeliveryTimeout.Duration):
+		serviceUnavailable(res)
+	}
+}
+
 func tooLarge(res http.ResponseWriter) {
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusRequestEntityTooLarge)
@@ -235,6 +317,18 @@ func methodNotAllowed(res http.ResponseWriter) {
 	res.Write([]byte(`{"error":"http: method not allowed"}`))
 }
 
+func unsupportedMediaType(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusUnsupportedMediaType)
+	res.Write([]byte(`{"error":"http: unsupported content encoding"}`))
+}
+
+func serviceUnavailable(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusServiceUnavailable)
+	res.Write([]byte(`{"error":"http: metrics not delivered"}`))
+}
+
 func internalServerError(res http.ResponseWriter) {
 	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusInternalServerError)
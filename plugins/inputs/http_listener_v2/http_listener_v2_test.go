@@ -2,6 +2,7 @@ package http_listener_v2
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/tls"
 	"crypto/x509"
 	"io/ioutil"
@@ -17,6 +18,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/testutil"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/require"
 )
 
@@ -72,9 +74,9 @@ func newTestHTTPSListenerV2() *HTTPListenerV2 {
 		Path:           "/write",
 		Methods:        []string{"POST"},
 		Parser:         parser,
-		ServerConfig:   *pki.TLSServerConfig(),
 		TimeFunc:       time.Now,
 	}
+	listener.ServerConfig = *pki.TLSServerConfig()
 
 	return listener
 }
@@ -140,6 +142,32 @@ func TestWriteHTTPSWithClientAuth(t *testing.T) {
 	require.EqualValues(t, 204, resp.StatusCode)
 }
 
+func TestWriteHTTPSWrongClientCN(t *testing.T) {
+	listener := newTestHTTPSListenerV2()
+	listener.TLSAllowedCNs = []string{"other.example.com"}
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	_, err := getHTTPSClient().Post(createURL(listener, "https", "/write", "db=mydb"), "", bytes.NewBuffer([]byte(testMsg)))
+	require.Error(t, err)
+	require.Empty(t, acc.Metrics)
+}
+
+func TestWriteHTTPDeniedNetwork(t *testing.T) {
+	listener := newTestHTTPListenerV2()
+	listener.DeniedNetworks = []string{"127.0.0.0/8", "::1"}
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	_, err := http.Post(createURL(listener, "http", "/write", "db=mydb"), "", bytes.NewBuffer([]byte(testMsg)))
+	require.Error(t, err)
+	require.Empty(t, acc.Metrics)
+}
+
 func TestWriteHTTPBasicAuth(t *testing.T) {
 	listener := newTestHTTPAuthListener()
 
@@ -302,6 +330,197 @@ func TestWriteHTTPGzippedData(t *testing.T) {
 	}
 }
 
+// test that writing zstd compressed data works
+func TestWriteHTTPZstdData(t *testing.T) {
+	listener := newTestHTTPListenerV2()
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	var buf bytes.Buffer
+	w, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(testMsgs))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	req, err := http.NewRequest("POST", createURL(listener, "http", "/write", ""), &buf)
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "zstd")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 204, resp.StatusCode)
+
+	hostTags := []string{"server02", "server03",
+		"server04", "server05", "server06"}
+	acc.Wait(len(hostTags))
+	for _, hostTag := range hostTags {
+		acc.AssertContainsTaggedFields(t, "cpu_load_short",
+			map[string]interface{}{"value": float64(12)},
+			map[string]string{"host": hostTag},
+		)
+	}
+}
+
+// test that the max body size applies to the decompressed body
+func TestWriteHTTPGzippedDataMaxBodySize(t *testing.T) {
+	parser, _ := parsers.NewInfluxParser()
+	listener := &HTTPListenerV2{
+		ServiceAddress: "localhost:0",
+		Path:           "/write",
+		Methods:        []string{"POST"},
+		Parser:         parser,
+		MaxBodySize:    internal.Size{Size: int64(len(testMsgs) - 1)},
+		TimeFunc:       time.Now,
+	}
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(testMsgs))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.True(t, int64(buf.Len()) < listener.MaxBodySize.Size)
+
+	req, err := http.NewRequest("POST", createURL(listener, "http", "/write", ""), &buf)
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "gzip")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 413, resp.StatusCode)
+}
+
+func TestWriteHTTPInvalidContentEncoding(t *testing.T) {
+	listener := newTestHTTPListenerV2()
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	// unknown encoding
+	req, err := http.NewRequest("POST", createURL(listener, "http", "/write", ""), bytes.NewBufferString(testMsg))
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "br")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 415, resp.StatusCode)
+
+	// body is not gzipped
+	req, err = http.NewRequest("POST", createURL(listener, "http", "/write", ""), bytes.NewBufferString(testMsg))
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 400, resp.StatusCode)
+}
+
+func newTestDeliveryListener() *HTTPListenerV2 {
+	listener := newTestHTTPListenerV2()
+	listener.DeliveryTracking = true
+	listener.DeliveryTimeout = internal.Duration{Duration: time.Second}
+	listener.MaxUndeliveredMetrics = 2
+	return listener
+}
+
+// post writes the body in the background, sending the status code.
+func post(t *testing.T, url string, body string) <-chan int {
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(url, "", bytes.NewBufferString(body))
+		if err != nil {
+			t.Error(err)
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	return status
+}
+
+func TestWriteHTTPDeliveryTracking(t *testing.T) {
+	listener := newTestDeliveryListener()
+
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	// delivered
+	status := post(t, createURL(listener, "http", "/write", ""), testMsg)
+	m := <-acc.Tracked
+	require.Equal(t, "cpu_load_short", m.Name())
+	select {
+	case <-status:
+		t.Fatal("response sent before delivery")
+	case <-time.After(10 * time.Millisecond):
+	}
+	m.Accept()
+	require.EqualValues(t, 204, <-status)
+
+	// rejected
+	status = post(t, createURL(listener, "http", "/write", ""), testMsg)
+	(<-acc.Tracked).Reject()
+	require.EqualValues(t, 503, <-status)
+
+	// dropped by a filter, which completes its processing
+	status = post(t, createURL(listener, "http", "/write", ""), testMsg)
+	(<-acc.Tracked).Drop()
+	require.EqualValues(t, 204, <-status)
+}
+
+func TestWriteHTTPDeliveryTimeout(t *testing.T) {
+	listener := newTestDeliveryListener()
+	listener.DeliveryTimeout = internal.Duration{Duration: 50 * time.Millisecond}
+
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	status := post(t, createURL(listener, "http", "/write", ""), testMsg)
+	m := <-acc.Tracked
+	require.EqualValues(t, 503, <-status)
+
+	// A late delivery releases the metric.
+	m.Accept()
+	status = post(t, createURL(listener, "http", "/write", ""), testMsgs)
+	for i := 0; i < 5; i++ {
+		(<-acc.Tracked).Accept()
+	}
+	require.EqualValues(t, 204, <-status)
+}
+
+func TestWriteHTTPMaxUndeliveredMetrics(t *testing.T) {
+	listener := newTestDeliveryListener()
+
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	status := post(t, createURL(listener, "http", "/write", ""), testMsg)
+	m := <-acc.Tracked
+
+	// Two more metrics would exceed the maximum of 2 undelivered metrics.
+	resp, err := http.Post(createURL(listener, "http", "/write", ""), "", bytes.NewBufferString(
+		"cpu_load_short,host=server02 value=12.0\ncpu_load_short,host=server03 value=12.0\n"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, 503, resp.StatusCode)
+
+	m.Accept()
+	require.EqualValues(t, 204, <-status)
+}
+
 // writes 25,000 metrics to the listener with 10 different writers
 func TestWriteHTTPHighTraffic(t *testing.T) {
 	if runtime.GOOS == "darwin" {
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"compress/gzip"
 	"crypto/subtle"
-	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
@@ -15,7 +14,8 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
-	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/internal/delivery"
+	"github.com/influxdata/telegraf/internal/listener"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/selfstat"
@@ -31,6 +31,9 @@ const (
 	// a single InfluxDB point.
 	// 64 KB
 	DEFAULT_MAX_LINE_SIZE = 64 * 1024
+
+	defaultDeliveryTimeout       = 30 * time.Second
+	defaultMaxUndeliveredMetrics = 1000
 )
 
 type TimeFunc func() time.Time
@@ -42,18 +45,25 @@ type HTTPListener struct {
 	MaxBodySize    internal.Size
 	MaxLineSize    internal.Size
 	Port           int
+	MaxConnections int
 
-	tlsint.ServerConfig
+	listener.Config
 
 	BasicUsername string
 	BasicPassword string
 
+	DeliveryTracking      bool
+	DeliveryTimeout       internal.Duration
+	MaxUndeliveredMetrics int
+
 	TimeFunc
 
 	mu sync.Mutex
 	wg sync.WaitGroup
 
-	listener net.Listener
+	listener  net.Listener
+	tracker   *delivery.Tracker
+	chunkSize int
 
 	handler *influx.MetricHandler
 	parser  *influx.Parser
@@ -99,10 +109,40 @@ const sampleConfig = `
   tls_cert = "/etc/telegraf/cert.pem"
   tls_key = "/etc/telegraf/key.pem"
 
+  ## Common names of the client certificates to accept, requires
+  ## tls_allowed_cacerts.
+  # tls_allowed_cns = ["client.example.com"]
+
+  ## Source networks, in CIDR notation, to accept or refuse requests from.
+  ## If allowed_networks is set, only clients of these networks are accepted.
+  # allowed_networks = ["10.0.0.0/8", "192.168.0.0/16"]
+  # denied_networks = ["10.0.0.1"]
+
+  ## Maximum number of concurrent connections.
+  ## 0 (default) is unlimited.
+  # max_connections = 1024
+
   ## Optional username and password to accept for HTTP basic authentication.
   ## You probably want to make sure you have TLS configured above for this.
   # basic_username = "foobar"
   # basic_password = "barfoo"
+
+  ## Hold the response to a write until its metrics are written by the
+  ## outputs, answering with 503 Service Unavailable if they are rejected, as
+  ## when an output buffer overflows, are not written within the
+  ## delivery_timeout, or would exceed max_undelivered_metrics.  Clients
+  ## retrying on 503 get their metrics delivered at least once.
+  # delivery_tracking = false
+
+  ## Maximum duration to wait for the delivery, should be longer than the
+  ## flush_interval.  The write_timeout is raised above it if needed.
+  # delivery_timeout = "30s"
+
+  ## Maximum number of metrics waiting for delivery, of all writes.  Writes
+  ## are tracked in chunks of a tenth of it, a larger write waits for its
+  ## earlier chunks to be delivered.  Should be larger than the
+  ## metric_batch_size, otherwise the outputs only write on flush_interval.
+  # max_undelivered_metrics = 1000
 `
 
 func (h *HTTPListener) SampleConfig() string {
@@ -153,37 +193,51 @@ func (h *HTTPListener) Start(acc telegraf.Accumulator) error {
 		h.WriteTimeout.Duration = time.Second * 10
 	}
 
+	if h.DeliveryTracking {
+		if h.DeliveryTimeout.Duration == 0 {
+			h.DeliveryTimeout.Duration = defaultDeliveryTimeout
+		}
+		if h.MaxUndeliveredMetrics == 0 {
+			h.MaxUndeliveredMetrics = defaultMaxUndeliveredMetrics
+		}
+		if h.WriteTimeout.Duration <= h.DeliveryTimeout.Duration {
+			h.WriteTimeout.Duration = h.DeliveryTimeout.Duration + time.Second*5
+		}
+	}
+
 	h.acc = acc
 	h.pool = NewPool(200, int(h.MaxLineSize.Size))
 
-	tlsConf, err := h.ServerConfig.TLSConfig()
-	if err != nil {
-		return err
-	}
-
 	server := &http.Server{
 		Addr:         h.ServiceAddress,
 		Handler:      h,
 		ReadTimeout:  h.ReadTimeout.Duration,
 		WriteTimeout: h.WriteTimeout.Duration,
-		TLSConfig:    tlsConf,
 	}
 
-	var listener net.Listener
-	if tlsConf != nil {
-		listener, err = tls.Listen("tcp", h.ServiceAddress, tlsConf)
-	} else {
-		listener, err = net.Listen("tcp", h.ServiceAddress)
-	}
+	l, err := h.Listen("tcp", h.ServiceAddress, listener.Options{
+		MaxConnections: h.MaxConnections,
+	})
 	if err != nil {
 		return err
 	}
-	h.listener = listener
-	h.Port = listener.Addr().(*net.TCPAddr).Port
+	h.listener = l
+	h.Port = l.Addr().(*net.TCPAddr).Port
 
 	h.handler = influx.NewMetricHandler()
 	h.parser = influx.NewParser(h.handler)
 
+	if h.DeliveryTracking {
+		h.tracker = delivery.NewTracker(acc, h.MaxUndeliveredMetrics)
+
+		// Writes are tracked in chunks well below the maximum, so that a
+		// large write does not need all other writes to be delivered first.
+		h.chunkSize = h.MaxUndeliveredMetrics / 10
+		if h.chunkSize < 1 {
+			h.chunkSize = 1
+		}
+	}
+
 	h.wg.Add(1)
 	go func() {
 		defer h.wg.Done()
@@ -202,6 +256,9 @@ func (h *HTTPListener) Stop() {
 
 	h.listener.Close()
 	h.wg.Wait()
+	if h.tracker != nil {
+		h.tracker.Stop()
+	}
 
 	log.Println("I! Stopped HTTP listener service on ", h.ServiceAddress)
 }
@@ -265,6 +322,9 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 
 	var return400 bool
 	var hangingBytes bool
+	// chunks of the write added with delivery tracking, all of them must be
+	// delivered within the timeout
+	tracked := trackedWrite{deadline: time.Now().Add(h.DeliveryTimeout.Duration)}
 	buf := h.pool.get()
 	defer h.pool.put(buf)
 	bufStart := 0
@@ -279,11 +339,7 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 		h.BytesRecv.Incr(int64(n))
 
 		if err == io.EOF {
-			if return400 {
-				badRequest(res, "")
-			} else {
-				res.WriteHeader(http.StatusNoContent)
-			}
+			h.writeResponse(res, &tracked, return400, "")
 			return
 		}
 
@@ -305,20 +361,15 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 
 		if err == io.ErrUnexpectedEOF {
 			// finished reading the request body
-			err = h.parse(buf[:n+bufStart], now, precision)
+			metrics, err := h.parse(buf[:n+bufStart], now, precision)
+			h.addMetrics(&tracked, metrics)
+			var errString string
 			if err != nil {
 				log.Println("D! "+err.Error(), bufStart+n)
 				return400 = true
+				errString = err.Error()
 			}
-			if return400 {
-				if err != nil {
-					badRequest(res, err.Error())
-				} else {
-					badRequest(res, "")
-				}
-			} else {
-				res.WriteHeader(http.StatusNoContent)
-			}
+			h.writeResponse(res, &tracked, return400, errString)
 			return
 		}
 
@@ -335,7 +386,9 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 			bufStart = 0
 			continue
 		}
-		if err := h.parse(buf[:i+1], now, precision); err != nil {
+		metrics, err := h.parse(buf[:i+1], now, precision)
+		h.addMetrics(&tracked, metrics)
+		if err != nil {
 			log.Println("D! " + err.Error())
 			return400 = true
 		}
@@ -348,7 +401,7 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func (h *HTTPListener) parse(b []byte, t time.Time, precision string) error {
+func (h *HTTPListener) parse(b []byte, t time.Time, precision string) ([]telegraf.Metric, error) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -356,14 +409,103 @@ func (h *HTTPListener) parse(b []byte, t time.Time, precision string) error {
 	h.handler.SetTimeFunc(func() time.Time { return t })
 	metrics, err := h.parser.Parse(b)
 	if err != nil {
-		return fmt.Errorf("unable to parse: %s", err.Error())
+		return nil, fmt.Errorf("unable to parse: %s", err.Error())
 	}
+	return metrics, nil
+}
+
+// trackedWrite holds the chunks of a write added with delivery tracking.
+type trackedWrite struct {
+	pending  []<-chan bool
+	failed   bool
+	deadline time.Time
+}
 
-	for _, m := range metrics {
-		h.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+// addMetrics adds the metrics, in chunks with delivery tracking if it is
+// enabled.  No further metrics of a write are added once a chunk failed, as
+// the write is answered with 503 anyway.
+func (h *HTTPListener) addMetrics(w *trackedWrite, metrics []telegraf.Metric) {
+	if h.tracker == nil {
+		for _, m := range metrics {
+			h.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+		}
+		return
 	}
 
-	return nil
+	for len(metrics) > 0 && !w.failed {
+		n := h.chunkSize
+		if n > len(metrics) {
+			n = len(metrics)
+		}
+		h.track(w, metrics[:n])
+		metrics = metrics[n:]
+	}
+}
+
+// track adds the chunk with delivery tracking.  While there are too many
+// undelivered metrics, the earlier chunks of the write are waited for.
+func (h *HTTPListener) track(w *trackedWrite, chunk []telegraf.Metric) {
+	for {
+		delivered, err := h.tracker.Add(chunk)
+		if err == nil {
+			w.pending = append(w.pending, delivered)
+			return
+		}
+
+		if err != delivery.ErrTooManyUndelivered || len(w.pending) == 0 {
+			log.Println("D! " + err.Error())
+			w.failed = true
+			return
+		}
+
+		if !w.wait(w.pending[0]) {
+			w.failed = true
+			return
+		}
+		w.pending = w.pending[1:]
+	}
+}
+
+// writeResponse responds to a write, once its tracked metrics are delivered.
+// A write containing invalid lines is answered with 400 even if its valid
+// metrics are delivered.
+func (h *HTTPListener) writeResponse(res http.ResponseWriter, w *trackedWrite, return400 bool, errString string) {
+	if h.tracker != nil && !w.delivered() {
+		serviceUnavailable(res)
+		return
+	}
+
+	if return400 {
+		badRequest(res, errString)
+	} else {
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// delivered returns true once all chunks of the write are delivered.
+func (w *trackedWrite) delivered() bool {
+	if w.failed {
+		return false
+	}
+	for _, delivered := range w.pending {
+		if !w.wait(delivered) {
+			return false
+		}
+	}
+	return true
+}
+
+// wait returns true if the chunk is delivered before the deadline.
+func (w *trackedWrite) wait(delivered <-chan bool) bool {
+	timer := time.NewTimer(time.Until(w.deadline))
+	defer timer.Stop()
+
+	select {
+	case ok := <-delivered:
+		return ok
+	case <-timer.C:
+		return false
+	}
 }
 
 func tooLarge(res http.ResponseWriter) {
@@ -374,6 +516,14 @@ func tooLarge(res http.ResponseWriter) {
 	res.Write([]byte(`{"error":"http: request body too large"}`))
 }
 
+func serviceUnavailable(res http.ResponseWriter) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("X-Influxdb-Version", "1.0")
+	res.Header().Set("X-Influxdb-Error", "http: metrics not delivered")
+	res.WriteHeader(http.StatusServiceUnavailable)
+	res.Write([]byte(`{"error":"http: metrics not delivered"}`))
+}
+
 func badRequest(res http.ResponseWriter, errString string) {
 	res.Header().Set("Content-Type", "application/json")
 	res.Header().Set("X-Influxdb-Version", "1.0")
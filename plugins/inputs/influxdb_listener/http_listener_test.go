@@ -60,9 +60,9 @@ func newTestHTTPAuthListener() *HTTPListener {
 func newTestHTTPSListener() *HTTPListener {
 	listener := &HTTPListener{
 		ServiceAddress: "localhost:0",
-		ServerConfig:   *pki.TLSServerConfig(),
 		TimeFunc:       time.Now,
 	}
+	listener.ServerConfig = *pki.TLSServerConfig()
 
 	return listener
 }
@@ -382,6 +382,100 @@ func TestReceive404ForInvalidEndpoint(t *testing.T) {
 	require.EqualValues(t, 404, resp.StatusCode)
 }
 
+// post writes the body in the background, sending the status code.
+func post(t *testing.T, url string, body string) <-chan int {
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(url, "", bytes.NewBufferString(body))
+		if err != nil {
+			t.Error(err)
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	return status
+}
+
+func TestWriteHTTPDeliveryTracking(t *testing.T) {
+	listener := newTestHTTPListener()
+	listener.DeliveryTracking = true
+	listener.DeliveryTimeout = internal.Duration{Duration: time.Second}
+
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	// delivered
+	status := post(t, createURL(listener, "http", "/write", "db=mydb"), testMsgs)
+	for i := 0; i < 4; i++ {
+		(<-acc.Tracked).Accept()
+	}
+	select {
+	case <-status:
+		t.Fatal("response sent before delivery")
+	case <-time.After(10 * time.Millisecond):
+	}
+	(<-acc.Tracked).Accept()
+	require.EqualValues(t, 204, <-status)
+
+	// rejected
+	status = post(t, createURL(listener, "http", "/write", "db=mydb"), testMsg)
+	(<-acc.Tracked).Reject()
+	require.EqualValues(t, 503, <-status)
+
+	// invalid, there is nothing to deliver
+	status = post(t, createURL(listener, "http", "/write", "db=mydb"), badMsg)
+	require.EqualValues(t, 400, <-status)
+	require.Len(t, acc.Tracked, 0)
+}
+
+func TestWriteHTTPDeliveryTrackingChunks(t *testing.T) {
+	listener := newTestHTTPListener()
+	listener.DeliveryTracking = true
+	listener.DeliveryTimeout = internal.Duration{Duration: time.Second}
+	listener.MaxUndeliveredMetrics = 2
+
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	// A write larger than the maximum is accepted while another is pending,
+	// adding its next chunk once an earlier one is delivered.
+	single := post(t, createURL(listener, "http", "/write", "db=mydb"), testMsg)
+	first := <-acc.Tracked
+	status := post(t, createURL(listener, "http", "/write", "db=mydb"), testMsgs)
+	second := <-acc.Tracked
+	select {
+	case <-acc.Tracked:
+		t.Fatal("chunk added above the maximum")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	first.Accept()
+	require.EqualValues(t, 204, <-single)
+	second.Accept()
+	for i := 0; i < 4; i++ {
+		(<-acc.Tracked).Accept()
+	}
+	require.EqualValues(t, 204, <-status)
+}
+
+func TestWriteHTTPDeliveryTimeout(t *testing.T) {
+	listener := newTestHTTPListener()
+	listener.DeliveryTracking = true
+	listener.DeliveryTimeout = internal.Duration{Duration: 50 * time.Millisecond}
+
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	status := post(t, createURL(listener, "http", "/write", "db=mydb"), testMsg)
+	<-acc.Tracked
+	require.EqualValues(t, 503, <-status)
+}
+
 func TestWriteHTTPInvalid(t *testing.T) {
 	listener := newTestHTTPListener()
 
@@ -0,0 +1,141 @@
+package journald
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxFieldSize is the maximum size of a binary field value, larger values are
+// considered corrupt.
+const maxFieldSize = 64 * 1024 * 1024
+
+// exportReader reads the entries of the journal export format, as written by
+// "journalctl -o export":
+//
+//   https://www.freedesktop.org/wiki/Software/systemd/export/
+//
+// Each field is either a line of the form NAME=value, or for values that are
+// not printable, the name on its own line followed by the length of the value
+// as a little endian 64 bit integer, the value, and a newline.  Entries are
+// separated by an empty line.
+type exportReader struct {
+	r *bufio.Reader
+}
+
+func newExportReader(r io.Reader) *exportReader {
+	return &exportReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the fields of the next entry, or io.EOF when there are no more
+// entries.  If a field occurs several times in an entry, its last value is
+// returned.  An entry is returned as soon as the empty line ending it is
+// read.
+func (e *exportReader) Next() (map[string]string, error) {
+	entry := make(map[string]string)
+	for {
+		line, err := e.r.ReadString('\n')
+		if err == io.EOF && line == "" && len(entry) == 0 {
+			return nil, io.EOF
+		}
+		if err != nil {
+			// An entry is incomplete until the empty line following it.
+			return nil, unexpectedEOF(err)
+		}
+
+		line = line[:len(line)-1]
+		if line == "" {
+			if len(entry) > 0 {
+				return entry, nil
+			}
+			continue
+		}
+
+		if i := strings.IndexByte(line, '='); i >= 0 {
+			entry[line[:i]] = line[i+1:]
+			continue
+		}
+
+		value, err := e.readBinary(line)
+		if err != nil {
+			return nil, err
+		}
+		entry[line] = value
+	}
+}
+
+// readBinary reads the length prefixed value of a binary field.
+func (e *exportReader) readBinary(name string) (string, error) {
+	var size uint64
+	if err := binary.Read(e.r, binary.LittleEndian, &size); err != nil {
+		return "", unexpectedEOF(err)
+	}
+	if size > maxFieldSize {
+		return "", fmt.Errorf("field %s of %d bytes exceeds the maximum size", name, size)
+	}
+
+	buf := make([]byte, size+1)
+	if _, err := io.ReadFull(e.r, buf); err != nil {
+		return "", unexpectedEOF(err)
+	}
+	if buf[size] != '\n' {
+		return "", fmt.Errorf("field %s is not terminated by a newline", name)
+	}
+	return string(buf[:size]), nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// cursor holds the parts of a journal cursor that order the entries.
+type cursor struct {
+	seqnumID string
+	seqnum   uint64
+	realtime uint64
+}
+
+// parseCursor parses a cursor of the form
+// "s=<seqnum id>;i=<seqnum>;b=<boot id>;m=<monotonic>;t=<realtime>;x=<hash>",
+// with the numbers in hexadecimal.
+func parseCursor(s string) (cursor, error) {
+	var c cursor
+	var err error
+	for _, part := range strings.Split(s, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return cursor{}, fmt.Errorf("invalid cursor '%s'", s)
+		}
+		switch kv[0] {
+		case "s":
+			c.seqnumID = kv[1]
+		case "i":
+			c.seqnum, err = strconv.ParseUint(kv[1], 16, 64)
+		case "t":
+			c.realtime, err = strconv.ParseUint(kv[1], 16, 64)
+		}
+		if err != nil {
+			return cursor{}, fmt.Errorf("invalid cursor '%s': %v", s, err)
+		}
+	}
+	if c.seqnumID == "" {
+		return cursor{}, fmt.Errorf("invalid cursor '%s'", s)
+	}
+	return c, nil
+}
+
+// after returns true if the entry of the cursor c comes after the entry of
+// the cursor o.  Entries of the same journal file are ordered by their
+// sequence number, others by their time.
+func (c cursor) after(o cursor) bool {
+	if c.seqnumID == o.seqnumID {
+		return c.seqnum > o.seqnum
+	}
+	return c.realtime > o.realtime
+}
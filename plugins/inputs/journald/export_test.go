@@ -0,0 +1,112 @@
+package journald
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportReader(t *testing.T) {
+	f, err := os.Open("testdata/sample.export")
+	require.NoError(t, err)
+	defer f.Close()
+
+	r := newExportReader(f)
+	var entries []map[string]string
+	for {
+		entry, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		entries = append(entries, entry)
+	}
+
+	require.Len(t, entries, 4)
+	require.Equal(t, "nginx.service", entries[0]["_SYSTEMD_UNIT"])
+	require.Equal(t, "GET /index.html 200", entries[0]["MESSAGE"])
+	require.Equal(t, "1555200000000000", entries[0]["__REALTIME_TIMESTAMP"])
+	// The message of the third entry is a binary field.
+	require.Equal(t, "Accepted publickey for admin\nfrom 10.0.0.5", entries[2]["MESSAGE"])
+	require.Equal(t, "sshd", entries[2]["SYSLOG_IDENTIFIER"])
+}
+
+func TestExportReaderIncomplete(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"no empty line", "MESSAGE=hello\n"},
+		{"partial line", "MESSAGE=hello\nPRIO"},
+		{"partial binary size", "MESSAGE\n\x05\x00\x00"},
+		{"partial binary value", "MESSAGE\n\x05\x00\x00\x00\x00\x00\x00\x00hel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newExportReader(bytes.NewBufferString(tt.data))
+			_, err := r.Next()
+			require.Equal(t, io.ErrUnexpectedEOF, err)
+		})
+	}
+}
+
+func TestExportReaderBinaryNotTerminated(t *testing.T) {
+	r := newExportReader(bytes.NewBufferString("MESSAGE\n\x05\x00\x00\x00\x00\x00\x00\x00hello!\n\n"))
+	_, err := r.Next()
+	require.Error(t, err)
+}
+
+func TestCursorAfter(t *testing.T) {
+	base := "s=739ad463348b4ceca5a9e69c95a3c93f;i=4ece8;b=6c7c6013a8494e09b1bb3bab5d4b8e0e;m=f962c60;t=5867237a4e360;x=9a3f1c2b7d5e0001"
+	tests := []struct {
+		name   string
+		cursor string
+		after  bool
+	}{
+		{
+			name:   "same entry",
+			cursor: base,
+			after:  false,
+		},
+		{
+			name:   "next sequence number",
+			cursor: "s=739ad463348b4ceca5a9e69c95a3c93f;i=4ece9;b=6c7c6013a8494e09b1bb3bab5d4b8e0e;m=f962c60;t=5867237a4e360;x=1",
+			after:  true,
+		},
+		{
+			name:   "previous sequence number",
+			cursor: "s=739ad463348b4ceca5a9e69c95a3c93f;i=4ece7;b=6c7c6013a8494e09b1bb3bab5d4b8e0e;m=f962c60;t=5867237a4e361;x=1",
+			after:  false,
+		},
+		{
+			name:   "other journal file later",
+			cursor: "s=0a4d4d1e2ad74a6bbd5b0e2bcd3e5b3f;i=1;b=6c7c6013a8494e09b1bb3bab5d4b8e0e;m=f962c60;t=5867237a4e361;x=1",
+			after:  true,
+		},
+		{
+			name:   "other journal file earlier",
+			cursor: "s=0a4d4d1e2ad74a6bbd5b0e2bcd3e5b3f;i=ffffff;b=6c7c6013a8494e09b1bb3bab5d4b8e0e;m=f962c60;t=5867237a4e35f;x=1",
+			after:  false,
+		},
+	}
+
+	after, err := parseCursor(base)
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := parseCursor(tt.cursor)
+			require.NoError(t, err)
+			require.Equal(t, tt.after, c.after(after))
+		})
+	}
+}
+
+func TestParseCursorInvalid(t *testing.T) {
+	for _, s := range []string{"", "garbage", "s=abc;i=xyz", "i=1;t=2"} {
+		_, err := parseCursor(s)
+		require.Error(t, err, s)
+	}
+}
@@ -0,0 +1,590 @@
+package journald
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/delivery"
+	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	defaultMaxUndeliveredEntries = 1000
+	defaultRestartDelay          = 10 * time.Second
+)
+
+// priorities are the names of the syslog priorities, by their level.
+var priorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// integerFields are the journal fields holding integers, which are added as
+// integer fields.
+var integerFields = map[string]bool{
+	"PRIORITY":        true,
+	"SYSLOG_FACILITY": true,
+	"SYSLOG_PID":      true,
+	"ERRNO":           true,
+	"CODE_LINE":       true,
+	"_PID":            true,
+	"_UID":            true,
+	"_GID":            true,
+}
+
+type empty struct{}
+type semaphore chan empty
+
+type Journald struct {
+	Journalctl            string            `toml:"journalctl"`
+	Directory             string            `toml:"directory"`
+	Files                 []string          `toml:"files"`
+	FromBeginning         bool              `toml:"from_beginning"`
+	Units                 []string          `toml:"units"`
+	Priority              string            `toml:"priority"`
+	TagFields             []string          `toml:"tag_fields"`
+	Fields                []string          `toml:"fields"`
+	StateFile             string            `toml:"state_file"`
+	MaxUndeliveredEntries int               `toml:"max_undelivered_entries"`
+	RestartDelay          internal.Duration `toml:"restart_delay"`
+
+	priority   int
+	unitFilter filter.Filter
+
+	acc    telegraf.TrackingAccumulator
+	sem    semaphore
+	done   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// pending holds the sequence number in progress of each undelivered
+	// entry, and cursor the cursor reached by the entries delivered, to store
+	// in the state file.
+	pending     map[telegraf.TrackingID]uint64
+	progress    *delivery.Progress
+	cursor      string
+	cursorDirty bool
+	cursorLock  sync.Mutex
+
+	sync.Mutex
+}
+
+func NewJournald() *Journald {
+	return &Journald{
+		Journalctl:            "journalctl",
+		TagFields:             []string{"_SYSTEMD_UNIT", "_HOSTNAME", "SYSLOG_IDENTIFIER"},
+		Fields:                []string{"MESSAGE", "PRIORITY"},
+		MaxUndeliveredEntries: defaultMaxUndeliveredEntries,
+		RestartDelay:          internal.Duration{Duration: defaultRestartDelay},
+	}
+}
+
+const sampleConfig = `
+  ## Path of the journalctl command, which is run to follow the journal.
+  # journalctl = "journalctl"
+
+  ## Directory of the journal files to follow, instead of the system journal.
+  # directory = "/var/log/journal"
+
+  ## Journal export files to read instead of following the journal, as
+  ## written by "journalctl -o export".  The files are read once on startup.
+  ## These accept standard unix glob matching rules, see the tail input.
+  # files = ["/var/log/export/*.export"]
+
+  ## Read the entries of the journal from its beginning, instead of only the
+  ## new entries, when no cursor is stored in the state file.
+  # from_beginning = false
+
+  ## Only read the entries of these systemd units.  Globs are supported.
+  # units = ["nginx.service", "postgresql*"]
+
+  ## Only read the entries of this priority or a more important one, either
+  ## the name or the level of the priority:
+  ##   emerg (0), alert (1), crit (2), err (3), warning (4), notice (5),
+  ##   info (6), debug (7)
+  # priority = "info"
+
+  ## Journal fields added as tags, and as fields.  The tag and field keys are
+  ## the lowercase names of the journal fields without the leading
+  ## underscores, eg. "_SYSTEMD_UNIT" is added as "systemd_unit".  Entries
+  ## without any of the fields are skipped.
+  # tag_fields = ["_SYSTEMD_UNIT", "_HOSTNAME", "SYSLOG_IDENTIFIER"]
+  # fields = ["MESSAGE", "PRIORITY"]
+
+  ## File to store the cursor of the latest entry read in, so that reading is
+  ## resumed after it when telegraf is restarted.  The cursor of an entry is
+  ## only stored once its metric has been written by the outputs.
+  # state_file = "/var/lib/telegraf/journald.state"
+
+  ## Maximum entries read that have not yet been written by the outputs.
+  # max_undelivered_entries = 1000
+
+  ## Delay before running journalctl again when it exits.
+  # restart_delay = "10s"
+`
+
+func (j *Journald) SampleConfig() string {
+	return sampleConfig
+}
+
+func (j *Journald) Description() string {
+	return "Read entries of the systemd journal"
+}
+
+func (j *Journald) Gather(acc telegraf.Accumulator) error {
+	if err := j.saveState(); err != nil {
+		acc.AddError(fmt.Errorf("error saving state file %s: %v", j.StateFile, err))
+	}
+	return nil
+}
+
+// Init parses the priority and the units filter, and sets the defaults of
+// unset options.
+func (j *Journald) Init() error {
+	var err error
+	j.priority, err = parsePriority(j.Priority)
+	if err != nil {
+		return err
+	}
+
+	if len(j.Units) > 0 {
+		j.unitFilter, err = filter.Compile(j.Units)
+		if err != nil {
+			return fmt.Errorf("invalid units: %v", err)
+		}
+	}
+
+	if j.Journalctl == "" {
+		j.Journalctl = "journalctl"
+	}
+	if j.MaxUndeliveredEntries <= 0 {
+		j.MaxUndeliveredEntries = defaultMaxUndeliveredEntries
+	}
+	if j.RestartDelay.Duration <= 0 {
+		j.RestartDelay.Duration = defaultRestartDelay
+	}
+	return nil
+}
+
+// parsePriority returns the level of the priority, or -1 if it is empty.
+func parsePriority(priority string) (int, error) {
+	if priority == "" {
+		return -1, nil
+	}
+	for level, name := range priorities {
+		if strings.EqualFold(priority, name) {
+			return level, nil
+		}
+	}
+	level, err := strconv.Atoi(priority)
+	if err != nil || level < 0 || level >= len(priorities) {
+		return 0, fmt.Errorf("unknown priority '%s'", priority)
+	}
+	return level, nil
+}
+
+func (j *Journald) Start(acc telegraf.Accumulator) error {
+	j.Lock()
+	defer j.Unlock()
+
+	if err := j.Init(); err != nil {
+		return err
+	}
+
+	var cursor string
+	if j.StateFile != "" {
+		var err error
+		cursor, err = loadState(j.StateFile)
+		if err != nil {
+			return fmt.Errorf("could not load state file %s: %v", j.StateFile, err)
+		}
+	}
+
+	var files []string
+	for _, pattern := range j.Files {
+		g, err := globpath.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid files pattern '%s': %v", pattern, err)
+		}
+		for _, path := range g.Match() {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+
+	j.acc = acc.WithTracking(j.MaxUndeliveredEntries)
+	j.sem = make(semaphore, j.MaxUndeliveredEntries)
+	j.done = make(chan struct{})
+	j.pending = make(map[telegraf.TrackingID]uint64)
+	j.progress = delivery.NewProgress()
+	j.cursor = cursor
+	j.cursorDirty = false
+
+	var ctx context.Context
+	ctx, j.cancel = context.WithCancel(context.Background())
+
+	j.wg.Add(2)
+	go func() {
+		defer j.wg.Done()
+		j.deliveries()
+	}()
+	go func() {
+		defer j.wg.Done()
+		if len(j.Files) > 0 {
+			j.readFiles(files, cursor)
+		} else {
+			j.follow(ctx, cursor)
+		}
+	}()
+
+	return nil
+}
+
+// follow runs journalctl to read the entries of the journal as they are
+// written, running it again after the restart delay whenever it exits.
+func (j *Journald) follow(ctx context.Context, cursor string) {
+	for {
+		var err error
+		cursor, err = j.runJournalctl(ctx, cursor)
+
+		select {
+		case <-j.done:
+			return
+		default:
+		}
+		j.acc.AddError(fmt.Errorf("journalctl exited: %v", err))
+
+		select {
+		case <-j.done:
+			return
+		case <-time.After(j.RestartDelay.Duration):
+		}
+	}
+}
+
+// runJournalctl reads the entries of journalctl until it exits, returning the
+// cursor of the last entry read.
+func (j *Journald) runJournalctl(ctx context.Context, cursor string) (string, error) {
+	cmd := exec.CommandContext(ctx, j.Journalctl, j.args(cursor)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return cursor, err
+	}
+	if err := cmd.Start(); err != nil {
+		return cursor, err
+	}
+
+	last, err := j.read(stdout, nil)
+	if last != "" {
+		cursor = last
+	}
+	if err != nil {
+		cmd.Process.Kill()
+	}
+
+	if werr := cmd.Wait(); werr != nil && err == nil {
+		err = werr
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", werr, msg)
+		}
+	}
+	if err == nil {
+		err = errors.New("end of output")
+	}
+	return cursor, err
+}
+
+// args returns the arguments of journalctl to follow the journal after the
+// cursor.
+func (j *Journald) args(cursor string) []string {
+	args := []string{"--output=export", "--follow"}
+	if j.Directory != "" {
+		args = append(args, "--directory="+j.Directory)
+	}
+	for _, unit := range j.Units {
+		args = append(args, "--unit="+unit)
+	}
+	if j.priority >= 0 {
+		args = append(args, "--priority="+strconv.Itoa(j.priority))
+	}
+
+	switch {
+	case cursor != "":
+		args = append(args, "--after-cursor="+cursor, "--no-tail")
+	case j.FromBeginning:
+		args = append(args, "--no-tail")
+	default:
+		args = append(args, "--lines=0")
+	}
+	return args
+}
+
+// readFiles reads the entries of the export files that come after the
+// cursor.  The units and priority are filtered here, as journalctl does when
+// following the journal.
+func (j *Journald) readFiles(files []string, stored string) {
+	var after *cursor
+	if stored != "" {
+		c, err := parseCursor(stored)
+		if err != nil {
+			j.acc.AddError(fmt.Errorf("ignoring stored cursor: %v", err))
+		} else {
+			after = &c
+		}
+	}
+	accept := func(entry map[string]string) bool {
+		if after != nil && !isAfter(entry["__CURSOR"], *after) {
+			return false
+		}
+		return j.match(entry)
+	}
+
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			j.acc.AddError(err)
+			continue
+		}
+		_, err = j.read(f, accept)
+		f.Close()
+		if err != nil {
+			j.acc.AddError(fmt.Errorf("error reading %s: %v", path, err))
+		}
+
+		select {
+		case <-j.done:
+			return
+		default:
+		}
+	}
+}
+
+// read adds the entries of the export format read from r until its end, or
+// until stopping.  If accept is set, only the entries it returns true for are
+// added.  It returns the cursor of the last entry read.
+func (j *Journald) read(r io.Reader, accept func(entry map[string]string) bool) (string, error) {
+	var last string
+	reader := newExportReader(r)
+	for {
+		entry, err := reader.Next()
+		if err == io.EOF {
+			return last, nil
+		}
+		if err != nil {
+			return last, err
+		}
+
+		cursor := entry["__CURSOR"]
+		if cursor != "" {
+			last = cursor
+		}
+
+		if accept != nil && !accept(entry) {
+			continue
+		}
+
+		m, err := j.metric(entry)
+		if err != nil {
+			j.acc.AddError(err)
+			continue
+		}
+		if m == nil {
+			continue
+		}
+		if !j.add(m, cursor) {
+			return last, nil
+		}
+	}
+}
+
+// isAfter returns true if the entry of the cursor s comes after the entry of
+// the cursor after.
+func isAfter(s string, after cursor) bool {
+	c, err := parseCursor(s)
+	if err != nil {
+		// Entries without a valid cursor cannot be skipped.
+		return true
+	}
+	return c.after(after)
+}
+
+// match returns true if the entry is of one of the units and of the priority.
+func (j *Journald) match(entry map[string]string) bool {
+	if j.unitFilter != nil {
+		unit, ok := entry["_SYSTEMD_UNIT"]
+		if !ok || !j.unitFilter.Match(unit) {
+			unit, ok = entry["UNIT"]
+			if !ok || !j.unitFilter.Match(unit) {
+				return false
+			}
+		}
+	}
+
+	if j.priority >= 0 {
+		level, err := strconv.Atoi(entry["PRIORITY"])
+		if err != nil || level > j.priority {
+			return false
+		}
+	}
+	return true
+}
+
+// metric returns the metric of the entry, or nil if it has none of the
+// fields.
+func (j *Journald) metric(entry map[string]string) (telegraf.Metric, error) {
+	fields := make(map[string]interface{})
+	for _, name := range j.Fields {
+		value, ok := entry[name]
+		if !ok {
+			continue
+		}
+		if integerFields[name] {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			fields[key(name)] = n
+			continue
+		}
+		fields[key(name)] = value
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	tags := make(map[string]string)
+	for _, name := range j.TagFields {
+		if value := entry[name]; value != "" {
+			tags[key(name)] = value
+		}
+	}
+
+	t := time.Now()
+	if us, err := strconv.ParseInt(entry["__REALTIME_TIMESTAMP"], 10, 64); err == nil {
+		t = time.Unix(0, us*int64(time.Microsecond))
+	}
+	return metric.New("journald", tags, fields, t)
+}
+
+// key returns the tag or field key of a journal field.
+func key(name string) string {
+	return strings.ToLower(strings.TrimLeft(name, "_"))
+}
+
+// add adds the metric for tracking, remembering the cursor of its entry to
+// store once it is delivered.  It returns false when stopping.
+func (j *Journald) add(m telegraf.Metric, cursor string) bool {
+	select {
+	case j.sem <- empty{}:
+	case <-j.done:
+		// Stopping, the entry is read again after a restart.
+		return false
+	}
+
+	j.cursorLock.Lock()
+	defer j.cursorLock.Unlock()
+
+	id := j.acc.AddTrackingMetricGroup([]telegraf.Metric{m})
+	j.pending[id] = j.progress.Add(cursor)
+	return true
+}
+
+// deliveries is launched as a goroutine to advance the cursor when the
+// metrics are delivered.
+func (j *Journald) deliveries() {
+	for {
+		select {
+		case <-j.done:
+			return
+		case info := <-j.acc.Delivered():
+			<-j.sem
+			j.onDelivery(info)
+		}
+	}
+}
+
+// drainDeliveries handles the deliveries already waiting.
+func (j *Journald) drainDeliveries() {
+	for {
+		select {
+		case info := <-j.acc.Delivered():
+			<-j.sem
+			j.onDelivery(info)
+		default:
+			return
+		}
+	}
+}
+
+func (j *Journald) onDelivery(info telegraf.DeliveryInfo) {
+	j.cursorLock.Lock()
+	defer j.cursorLock.Unlock()
+
+	seq, ok := j.pending[info.ID()]
+	delete(j.pending, info.ID())
+	if !ok {
+		return
+	}
+
+	// The cursor only advances once all the entries read before are
+	// delivered, and no longer after an entry is rejected, so that reading
+	// resumes at the first undelivered entry.
+	cursor, ok := j.progress.Done(seq, info.Delivered())
+	if ok && cursor.(string) != "" {
+		j.cursor = cursor.(string)
+		j.cursorDirty = true
+	}
+}
+
+// saveState writes the cursor to the state file, if it changed since the last
+// save.
+func (j *Journald) saveState() error {
+	j.cursorLock.Lock()
+	defer j.cursorLock.Unlock()
+
+	if j.StateFile == "" || !j.cursorDirty {
+		return nil
+	}
+	if err := writeState(j.StateFile, j.cursor); err != nil {
+		return err
+	}
+	j.cursorDirty = false
+	return nil
+}
+
+func (j *Journald) Stop() {
+	j.Lock()
+	defer j.Unlock()
+
+	close(j.done)
+	j.cancel()
+	j.wg.Wait()
+
+	// Store the cursor of the metrics delivered while stopping.
+	j.drainDeliveries()
+
+	if err := j.saveState(); err != nil {
+		log.Printf("E! [inputs.journald] Error saving state file %s: %v", j.StateFile, err)
+	}
+}
+
+func init() {
+	inputs.Add("journald", func() telegraf.Input {
+		return NewJournald()
+	})
+}
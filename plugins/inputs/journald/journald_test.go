@@ -0,0 +1,269 @@
+package journald
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		priority string
+		level    int
+		err      bool
+	}{
+		{priority: "", level: -1},
+		{priority: "emerg", level: 0},
+		{priority: "WARNING", level: 4},
+		{priority: "debug", level: 7},
+		{priority: "3", level: 3},
+		{priority: "8", err: true},
+		{priority: "warn", err: true},
+	}
+	for _, tt := range tests {
+		level, err := parsePriority(tt.priority)
+		if tt.err {
+			require.Error(t, err, tt.priority)
+			continue
+		}
+		require.NoError(t, err, tt.priority)
+		require.Equal(t, tt.level, level, tt.priority)
+	}
+}
+
+func TestArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		journald *Journald
+		cursor   string
+		expected []string
+	}{
+		{
+			name:     "new entries",
+			journald: NewJournald(),
+			expected: []string{"--output=export", "--follow", "--lines=0"},
+		},
+		{
+			name: "from beginning",
+			journald: func() *Journald {
+				j := NewJournald()
+				j.FromBeginning = true
+				return j
+			}(),
+			expected: []string{"--output=export", "--follow", "--no-tail"},
+		},
+		{
+			name: "after cursor",
+			journald: func() *Journald {
+				j := NewJournald()
+				j.FromBeginning = true
+				return j
+			}(),
+			cursor:   "s=1;i=2",
+			expected: []string{"--output=export", "--follow", "--after-cursor=s=1;i=2", "--no-tail"},
+		},
+		{
+			name: "filters",
+			journald: func() *Journald {
+				j := NewJournald()
+				j.Directory = "/var/log/journal"
+				j.Units = []string{"nginx.service", "postgresql*"}
+				j.Priority = "err"
+				return j
+			}(),
+			expected: []string{"--output=export", "--follow", "--directory=/var/log/journal",
+				"--unit=nginx.service", "--unit=postgresql*", "--priority=3", "--lines=0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.journald.Init())
+			require.Equal(t, tt.expected, tt.journald.args(tt.cursor))
+		})
+	}
+}
+
+func TestInitInvalidPriority(t *testing.T) {
+	j := NewJournald()
+	j.Priority = "loud"
+	require.Error(t, j.Init())
+}
+
+func TestReadFiles(t *testing.T) {
+	j := NewJournald()
+	j.Files = []string{"testdata/*.export"}
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, j.Start(&acc))
+	defer j.Stop()
+	acc.Wait(4)
+
+	acc.Lock()
+	defer acc.Unlock()
+	require.Len(t, acc.Metrics, 4)
+	require.Empty(t, acc.Errors)
+
+	m := acc.Metrics[0]
+	require.Equal(t, "journald", m.Measurement)
+	require.Equal(t, map[string]string{
+		"systemd_unit":      "nginx.service",
+		"hostname":          "web01",
+		"syslog_identifier": "nginx",
+	}, m.Tags)
+	require.Equal(t, map[string]interface{}{
+		"message":  "GET /index.html 200",
+		"priority": int64(6),
+	}, m.Fields)
+	require.Equal(t, time.Unix(1555200000, 0), m.Time)
+
+	require.Equal(t, "Accepted publickey for admin\nfrom 10.0.0.5", acc.Metrics[2].Fields["message"])
+}
+
+func TestReadFilesFilter(t *testing.T) {
+	j := NewJournald()
+	j.Files = []string{"testdata/sample.export"}
+	j.Units = []string{"nginx*", "sshd.service"}
+	j.Priority = "warning"
+	j.TagFields = []string{"_SYSTEMD_UNIT"}
+	j.Fields = []string{"MESSAGE", "_PID"}
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, j.Start(&acc))
+	defer j.Stop()
+	acc.Wait(1)
+
+	// Give the other entries the time to be read, should they pass.
+	time.Sleep(100 * time.Millisecond)
+	acc.Lock()
+	defer acc.Unlock()
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, map[string]string{"systemd_unit": "nginx.service"}, acc.Metrics[0].Tags)
+	require.Equal(t, map[string]interface{}{
+		"message": "connect() failed (111: Connection refused)",
+		"pid":     int64(812),
+	}, acc.Metrics[0].Fields)
+}
+
+func TestStateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journald")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	statefile := filepath.Join(dir, "journald.state")
+
+	j := NewJournald()
+	j.Files = []string{"testdata/sample.export"}
+	j.StateFile = statefile
+
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, j.Start(acc))
+
+	// Only the first two entries are delivered, the second one first.
+	var tracked []telegraf.Metric
+	for i := 0; i < 4; i++ {
+		tracked = append(tracked, <-acc.Tracked)
+	}
+	tracked[1].Accept()
+	tracked[0].Accept()
+	j.Stop()
+
+	cursor, err := loadState(statefile)
+	require.NoError(t, err)
+	require.Contains(t, cursor, ";i=4ece8;")
+
+	// Reading resumes after the delivered entries.
+	j = NewJournald()
+	j.Files = []string{"testdata/sample.export"}
+	j.StateFile = statefile
+
+	acc2 := testutil.AcceptingAccumulator{}
+	require.NoError(t, j.Start(&acc2))
+	defer j.Stop()
+	acc2.Wait(2)
+
+	time.Sleep(100 * time.Millisecond)
+	acc2.Lock()
+	defer acc2.Unlock()
+	require.Len(t, acc2.Metrics, 2)
+	require.Equal(t, "sshd.service", acc2.Metrics[0].Tags["systemd_unit"])
+	require.Equal(t, "nginx.service", acc2.Metrics[1].Tags["systemd_unit"])
+}
+
+func TestStateFileRejected(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journald")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	statefile := filepath.Join(dir, "journald.state")
+
+	j := NewJournald()
+	j.Files = []string{"testdata/sample.export"}
+	j.StateFile = statefile
+
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, j.Start(acc))
+
+	// The second entry is rejected, the ones after it are delivered.
+	for i := 0; i < 4; i++ {
+		m := <-acc.Tracked
+		if i == 1 {
+			m.Reject()
+		} else {
+			m.Accept()
+		}
+	}
+	j.Stop()
+
+	cursor, err := loadState(statefile)
+	require.NoError(t, err)
+	require.Contains(t, cursor, ";i=4ece7;")
+
+	// Reading resumes at the rejected entry.
+	j = NewJournald()
+	j.Files = []string{"testdata/sample.export"}
+	j.StateFile = statefile
+
+	acc2 := testutil.AcceptingAccumulator{}
+	require.NoError(t, j.Start(&acc2))
+	defer j.Stop()
+	acc2.Wait(3)
+
+	time.Sleep(100 * time.Millisecond)
+	acc2.Lock()
+	defer acc2.Unlock()
+	require.Len(t, acc2.Metrics, 3)
+	require.Equal(t, "postgresql.service", acc2.Metrics[0].Tags["systemd_unit"])
+	require.Equal(t, "sshd.service", acc2.Metrics[1].Tags["systemd_unit"])
+	require.Equal(t, "nginx.service", acc2.Metrics[2].Tags["systemd_unit"])
+}
+
+func TestJournalctl(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test using a shell script on windows")
+	}
+
+	j := NewJournald()
+	j.Journalctl = "testdata/journalctl.sh"
+	j.RestartDelay.Duration = 10 * time.Millisecond
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, j.Start(&acc))
+	defer j.Stop()
+
+	// The fake journalctl fails after printing the entries, and is run again
+	// to follow the journal after the last entry read.
+	acc.Wait(4)
+	acc.WaitError(1)
+	time.Sleep(100 * time.Millisecond)
+
+	acc.Lock()
+	defer acc.Unlock()
+	require.Len(t, acc.Metrics, 4)
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "Failed to open journal")
+}
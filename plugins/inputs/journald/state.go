@@ -0,0 +1,57 @@
+package journald
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// state is the content of the state file.
+type state struct {
+	Cursor string `json:"cursor"`
+}
+
+// loadState returns the cursor stored in the state file.  A missing state
+// file is not an error, the cursor is empty in that case.
+func loadState(filename string) (string, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var s state
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return "", err
+	}
+	return s.Cursor, nil
+}
+
+// writeState stores the cursor in the state file.
+func writeState(filename string, cursor string) error {
+	buf, err := json.Marshal(state{Cursor: cursor})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that the state file is never left
+	// partially written.
+	tmpfile, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	_, err = tmpfile.Write(buf)
+	if cerr := tmpfile.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpfile.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(tmpfile.Name())
+	}
+	return err
+}
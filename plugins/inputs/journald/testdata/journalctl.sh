@@ -0,0 +1,11 @@
+#!/bin/sh
+# Fakes journalctl, printing the recorded entries and failing, unless it is
+# run to follow the journal after a cursor.
+for arg in "$@"; do
+	case "$arg" in
+	--after-cursor=*) exec sleep 60 ;;
+	esac
+done
+cat "$(dirname "$0")/sample.export"
+echo "Failed to open journal" >&2
+exit 1
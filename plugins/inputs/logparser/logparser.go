@@ -3,6 +3,7 @@
 package logparser
 
 import (
+	"fmt"
 	"log"
 	"strings"
 	"sync"
@@ -10,16 +11,22 @@ import (
 	"github.com/influxdata/tail"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/delivery"
 	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/internal/offsets"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	// Parsers
 )
 
 const (
-	defaultWatchMethod = "inotify"
+	defaultWatchMethod         = "inotify"
+	defaultMaxUndeliveredLines = 1000
 )
 
+type empty struct{}
+type semaphore chan empty
+
 // LogParser in the primary interface for the plugin
 type GrokConfig struct {
 	MeasurementName    string `toml:"measurement"`
@@ -33,20 +40,40 @@ type GrokConfig struct {
 type logEntry struct {
 	path string
 	line string
+	// pos is the position in the file after the line, and progress the
+	// position reached by the lines of the file delivered, with a state file.
+	pos      offsets.Position
+	progress *delivery.Progress
+}
+
+// pendingLine is a line of a file whose metric is not yet delivered.
+type pendingLine struct {
+	progress *delivery.Progress
+	seq      uint64
 }
 
 // LogParserPlugin is the primary struct to implement the interface for logparser plugin
 type LogParserPlugin struct {
-	Files         []string
-	FromBeginning bool
-	WatchMethod   string
+	Files               []string
+	FromBeginning       bool
+	WatchMethod         string
+	StateFile           string `toml:"state_file"`
+	MaxUndeliveredLines int    `toml:"max_undelivered_lines"`
 
 	tailers map[string]*tail.Tail
+	offsets *offsets.Store
 	lines   chan logEntry
 	done    chan struct{}
 	wg      sync.WaitGroup
 	acc     telegraf.Accumulator
 
+	// With a state file, the metrics are added with tracking and pending
+	// holds the line of each undelivered metric.
+	tracking    telegraf.TrackingAccumulator
+	sem         semaphore
+	pending     map[telegraf.TrackingID]pendingLine
+	pendingLock sync.Mutex
+
 	sync.Mutex
 
 	GrokParser parsers.Parser
@@ -70,6 +97,17 @@ const sampleConfig = `
   ## Method used to watch for file updates.  Can be either "inotify" or "poll".
   # watch_method = "inotify"
 
+  ## File to store the offsets read up to in each file, so that reading is
+  ## resumed there when telegraf is restarted.  The offset of a line is only
+  ## stored once its metrics have been written by the outputs.  Files without
+  ## a stored offset are read according to from_beginning.
+  # state_file = "/var/lib/telegraf/logparser.state"
+
+  ## Maximum lines of the file to process that have not yet been written by
+  ## the output, only limited when a state_file is set.  For best throughput
+  ## set based on the size of the output's metric_batch_size.
+  # max_undelivered_lines = 1000
+
   ## Parse logstash-style "grok" patterns:
   [inputs.logparser.grok]
     ## This is a list of patterns to check the given log file(s) for.
@@ -117,6 +155,12 @@ func (l *LogParserPlugin) Gather(acc telegraf.Accumulator) error {
 	l.Lock()
 	defer l.Unlock()
 
+	if l.offsets != nil {
+		if err := l.offsets.Save(); err != nil {
+			acc.AddError(fmt.Errorf("E! Error saving state file %s: %v", l.StateFile, err))
+		}
+	}
+
 	// always start from the beginning of files that appear while we're running
 	return l.tailNewfiles(true)
 }
@@ -127,6 +171,20 @@ func (l *LogParserPlugin) Start(acc telegraf.Accumulator) error {
 	defer l.Unlock()
 
 	l.acc = acc
+	if l.StateFile != "" {
+		var err error
+		l.offsets, err = offsets.Load(l.StateFile)
+		if err != nil {
+			return fmt.Errorf("could not load state file %s: %v", l.StateFile, err)
+		}
+
+		if l.MaxUndeliveredLines <= 0 {
+			l.MaxUndeliveredLines = defaultMaxUndeliveredLines
+		}
+		l.tracking = acc.WithTracking(l.MaxUndeliveredLines)
+		l.sem = make(semaphore, l.MaxUndeliveredLines)
+		l.pending = make(map[telegraf.TrackingID]pendingLine)
+	}
 	l.lines = make(chan logEntry, 1000)
 	l.done = make(chan struct{})
 	l.tailers = make(map[string]*tail.Tail)
@@ -155,6 +213,10 @@ func (l *LogParserPlugin) Start(acc telegraf.Accumulator) error {
 
 	l.wg.Add(1)
 	go l.parser()
+	if l.tracking != nil {
+		l.wg.Add(1)
+		go l.deliveries()
+	}
 
 	return l.tailNewfiles(l.FromBeginning)
 }
@@ -162,12 +224,6 @@ func (l *LogParserPlugin) Start(acc telegraf.Accumulator) error {
 // check the globs against files on disk, and start tailing any new files.
 // Assumes l's lock is held!
 func (l *LogParserPlugin) tailNewfiles(fromBeginning bool) error {
-	var seek tail.SeekInfo
-	if !fromBeginning {
-		seek.Whence = 2
-		seek.Offset = 0
-	}
-
 	var poll bool
 	if l.WatchMethod == "poll" {
 		poll = true
@@ -188,6 +244,12 @@ func (l *LogParserPlugin) tailNewfiles(fromBeginning bool) error {
 				continue
 			}
 
+			seek, pos, err := l.position(file, fromBeginning)
+			if err != nil {
+				l.acc.AddError(err)
+				continue
+			}
+
 			tailer, err := tail.TailFile(file,
 				tail.Config{
 					ReOpen:    true,
@@ -206,7 +268,7 @@ func (l *LogParserPlugin) tailNewfiles(fromBeginning bool) error {
 
 			// create a goroutine for each "tailer"
 			l.wg.Add(1)
-			go l.receiver(tailer)
+			go l.receiver(tailer, pos)
 			l.tailers[file] = tailer
 		}
 	}
@@ -214,11 +276,46 @@ func (l *LogParserPlugin) tailNewfiles(fromBeginning bool) error {
 	return nil
 }
 
+// position returns where to start tailing the file.  Without a state file
+// this is the start or the end of the file.  Otherwise it is the stored
+// position of the file, if there is one.
+func (l *LogParserPlugin) position(file string, fromBeginning bool) (tail.SeekInfo, offsets.Position, error) {
+	if l.offsets == nil {
+		if fromBeginning {
+			return tail.SeekInfo{}, offsets.Position{}, nil
+		}
+		return tail.SeekInfo{Whence: 2, Offset: 0}, offsets.Position{}, nil
+	}
+
+	pos, err := l.offsets.Lookup(file, !fromBeginning)
+	if err != nil {
+		return tail.SeekInfo{}, pos, err
+	}
+	return tail.SeekInfo{Whence: 0, Offset: pos.Offset}, pos, nil
+}
+
 // receiver is launched as a goroutine to continuously watch a tailed logfile
 // for changes and send any log lines down the l.lines channel.
-func (l *LogParserPlugin) receiver(tailer *tail.Tail) {
+func (l *LogParserPlugin) receiver(tailer *tail.Tail, pos offsets.Position) {
 	defer l.wg.Done()
 
+	// With a state file, the file read is followed through truncation and
+	// rotation, and progress tracks the position reached by the lines
+	// delivered.
+	var file *offsets.File
+	var progress *delivery.Progress
+	if l.offsets != nil {
+		var err error
+		file, err = offsets.OpenFile(tailer.Filename)
+		if err != nil {
+			log.Printf("E! Error opening file %s, Error: %s\n",
+				tailer.Filename, err)
+		} else {
+			defer file.Close()
+		}
+		progress = delivery.NewProgress()
+	}
+
 	var line *tail.Line
 	for line = range tailer.Lines {
 
@@ -228,12 +325,23 @@ func (l *LogParserPlugin) receiver(tailer *tail.Tail) {
 			continue
 		}
 
+		// The line is followed by a newline, which is not part of the text.
+		n := int64(len(line.Text)) + 1
+		if file != nil && file.Reopened(pos.Offset, n) {
+			// The file was truncated or replaced, so the line is at the start
+			// of the file read from now on.
+			pos = offsets.Position{Path: pos.Path, Inode: file.Inode()}
+		}
+		pos.Offset += n
+
 		// Fix up files with Windows line endings.
 		text := strings.TrimRight(line.Text, "\r")
 
 		entry := logEntry{
-			path: tailer.Filename,
-			line: text,
+			path:     tailer.Filename,
+			line:     text,
+			pos:      pos,
+			progress: progress,
 		}
 
 		select {
@@ -258,20 +366,97 @@ func (l *LogParserPlugin) parser() {
 			return
 		case entry = <-l.lines:
 			if entry.line == "" || entry.line == "\n" {
+				l.lineDone(entry)
 				continue
 			}
 		}
 		m, err = l.GrokParser.ParseLine(entry.line)
-		if err == nil {
-			if m != nil {
-				tags := m.Tags()
-				tags["path"] = entry.path
-				l.acc.AddFields(m.Name(), m.Fields(), tags, m.Time())
-			}
-		} else {
+		if err != nil {
 			log.Println("E! Error parsing log line: " + err.Error())
 		}
+		if err != nil || m == nil {
+			// There is nothing to deliver, the line is done.
+			l.lineDone(entry)
+			continue
+		}
+
+		m.AddTag("path", entry.path)
+
+		if entry.progress == nil {
+			l.acc.AddMetric(m)
+			continue
+		}
+
+		select {
+		case <-l.done:
+			return
+		case l.sem <- empty{}:
+			l.addMetric(m, entry)
+		}
+	}
+}
+
+// addMetric adds the metric for tracking, remembering its line to advance
+// the offset of the file once it is delivered.
+func (l *LogParserPlugin) addMetric(m telegraf.Metric, entry logEntry) {
+	l.pendingLock.Lock()
+	defer l.pendingLock.Unlock()
+
+	id := l.tracking.AddTrackingMetricGroup([]telegraf.Metric{m})
+	l.pending[id] = pendingLine{progress: entry.progress, seq: entry.progress.Add(entry.pos)}
+}
+
+// lineDone marks a line without metric as done.
+func (l *LogParserPlugin) lineDone(entry logEntry) {
+	if entry.progress != nil {
+		l.advance(entry.progress, entry.progress.Add(entry.pos), true)
+	}
+}
 
+// advance marks the line as done, storing the offset of its file if reached.
+func (l *LogParserPlugin) advance(progress *delivery.Progress, seq uint64, delivered bool) {
+	if pos, ok := progress.Done(seq, delivered); ok {
+		l.offsets.Update(pos.(offsets.Position))
+	}
+}
+
+// deliveries is launched as a goroutine to advance the offsets of the files
+// when their metrics are delivered.
+func (l *LogParserPlugin) deliveries() {
+	defer l.wg.Done()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case info := <-l.tracking.Delivered():
+			<-l.sem
+			l.onDelivery(info)
+		}
+	}
+}
+
+// drainDeliveries handles the deliveries already waiting.
+func (l *LogParserPlugin) drainDeliveries() {
+	for {
+		select {
+		case info := <-l.tracking.Delivered():
+			<-l.sem
+			l.onDelivery(info)
+		default:
+			return
+		}
+	}
+}
+
+func (l *LogParserPlugin) onDelivery(info telegraf.DeliveryInfo) {
+	l.pendingLock.Lock()
+	line, ok := l.pending[info.ID()]
+	delete(l.pending, info.ID())
+	l.pendingLock.Unlock()
+
+	if ok {
+		l.advance(line.progress, line.seq, info.Delivered())
 	}
 }
 
@@ -280,6 +465,10 @@ func (l *LogParserPlugin) Stop() {
 	l.Lock()
 	defer l.Unlock()
 
+	// Stop parsing first, so that the receivers are not blocked on a parser
+	// waiting for deliveries while the tailers are stopped.
+	close(l.done)
+
 	for _, t := range l.tailers {
 		err := t.Stop()
 
@@ -291,14 +480,23 @@ func (l *LogParserPlugin) Stop() {
 		}
 		t.Cleanup()
 	}
-	close(l.done)
 	l.wg.Wait()
+
+	if l.offsets != nil {
+		// Store the offsets of the metrics delivered while stopping.
+		l.drainDeliveries()
+
+		if err := l.offsets.Save(); err != nil {
+			log.Printf("E! [inputs.logparser] Error saving state file %s: %v", l.StateFile, err)
+		}
+	}
 }
 
 func init() {
 	inputs.Add("logparser", func() telegraf.Input {
 		return &LogParserPlugin{
-			WatchMethod: defaultWatchMethod,
+			WatchMethod:         defaultWatchMethod,
+			MaxUndeliveredLines: defaultMaxUndeliveredLines,
 		}
 	})
 }
@@ -3,6 +3,7 @@ package logparser
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -157,6 +158,53 @@ func TestGrokParseLogFilesOneBad(t *testing.T) {
 		})
 }
 
+func TestGrokParseLogFilesStateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestGrokParseLogFilesStateFile")
+	defer os.RemoveAll(dir)
+	assert.NoError(t, err)
+
+	thisdir := getCurrentDir()
+	logfile := filepath.Join(dir, "test_a.log")
+	statefile := filepath.Join(dir, "logparser.state")
+	err = ioutil.WriteFile(logfile,
+		[]byte("[04/Jun/2016:12:41:45 +0100] 1.25 200 192.168.1.1 5.432µs 101\n"), 0644)
+	assert.NoError(t, err)
+
+	newLogParser := func() *LogParserPlugin {
+		return &LogParserPlugin{
+			GrokConfig: GrokConfig{
+				MeasurementName:    "logparser_grok",
+				Patterns:           []string{"%{TEST_LOG_A}"},
+				CustomPatternFiles: []string{thisdir + "testdata/test-patterns"},
+			},
+			FromBeginning: true,
+			StateFile:     statefile,
+			Files:         []string{logfile},
+		}
+	}
+
+	logparser := newLogParser()
+	acc := testutil.AcceptingAccumulator{}
+	assert.NoError(t, logparser.Start(&acc))
+	acc.Wait(1)
+	logparser.Stop()
+
+	f, err := os.OpenFile(logfile, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString("[04/Jun/2016:12:41:46 +0100] 2.5 200 192.168.1.1 5.432µs 102\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	// Parsing resumes after the delivered line.
+	logparser = newLogParser()
+	acc = testutil.AcceptingAccumulator{}
+	assert.NoError(t, logparser.Start(&acc))
+	acc.Wait(1)
+	logparser.Stop()
+
+	assert.Equal(t, int64(102), acc.Metrics[0].Fields["myint"])
+}
+
 func getCurrentDir() string {
 	_, filename, _, _ := runtime.Caller(1)
 	return strings.Replace(filename, "logparser_test.go", "", 1)
@@ -136,7 +136,7 @@ type Snmp struct {
 	Name   string
 	Fields []Field `toml:"field"`
 
-	connectionCache []snmpConnection
+	connectionCache []Connection
 	initialized     bool
 }
 
@@ -145,7 +145,7 @@ func (s *Snmp) init() error {
 		return nil
 	}
 
-	s.connectionCache = make([]snmpConnection, len(s.Agents))
+	s.connectionCache = make([]Connection, len(s.Agents))
 
 	for i := range s.Tables {
 		if err := s.Tables[i].init(); err != nil {
@@ -391,7 +391,7 @@ func (s *Snmp) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func (s *Snmp) gatherTable(acc telegraf.Accumulator, gs snmpConnection, t Table, topTags map[string]string, walk bool) error {
+func (s *Snmp) gatherTable(acc telegraf.Accumulator, gs Connection, t Table, topTags map[string]string, walk bool) error {
 	rt, err := t.Build(gs, walk)
 	if err != nil {
 		return err
@@ -421,7 +421,7 @@ func (s *Snmp) gatherTable(acc telegraf.Accumulator, gs snmpConnection, t Table,
 }
 
 // Build retrieves all the fields specified in the table and constructs the RTable.
-func (t Table) Build(gs snmpConnection, walk bool) (*RTable, error) {
+func (t Table) Build(gs Connection, walk bool) (*RTable, error) {
 	rows := map[string]RTableRow{}
 
 	tagCount := 0
@@ -541,16 +541,16 @@ func (t Table) Build(gs snmpConnection, walk bool) (*RTable, error) {
 	return &rt, nil
 }
 
-// snmpConnection is an interface which wraps a *gosnmp.GoSNMP object.
+// Connection is an interface which wraps a *gosnmp.GoSNMP object.
 // We interact through an interface so we can mock it out in tests.
-type snmpConnection interface {
+type Connection interface {
 	Host() string
 	//BulkWalkAll(string) ([]gosnmp.SnmpPDU, error)
 	Walk(string, gosnmp.WalkFunc) error
 	Get(oids []string) (*gosnmp.SnmpPacket, error)
 }
 
-// gosnmpWrapper wraps a *gosnmp.GoSNMP object so we can use it as a snmpConnection.
+// gosnmpWrapper wraps a *gosnmp.GoSNMP object so we can use it as a Connection.
 type gosnmpWrapper struct {
 	*gosnmp.GoSNMP
 }
@@ -600,19 +600,29 @@ func (gsw gosnmpWrapper) Get(oids []string) (*gosnmp.SnmpPacket, error) {
 	return nil, err
 }
 
-// getConnection creates a snmpConnection (*gosnmp.GoSNMP) object and caches the
+// getConnection creates a Connection (*gosnmp.GoSNMP) object and caches the
 // result using `agentIndex` as the cache key.  This is done to allow multiple
 // connections to a single address.  It is an error to use a connection in
 // more than one goroutine.
-func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
+func (s *Snmp) getConnection(idx int) (Connection, error) {
 	if gs := s.connectionCache[idx]; gs != nil {
 		return gs, nil
 	}
 
-	agent := s.Agents[idx]
+	gs, err := s.NewConnection(s.Agents[idx])
+	if err != nil {
+		return nil, err
+	}
+	s.connectionCache[idx] = gs
 
+	return gs, nil
+}
+
+// NewConnection creates a Connection to the agent, using the client settings
+// (version, community, security parameters, ...) of the plugin.  The result is
+// not cached and it is an error to use it in more than one goroutine.
+func (s *Snmp) NewConnection(agent string) (Connection, error) {
 	gs := gosnmpWrapper{&gosnmp.GoSNMP{}}
-	s.connectionCache[idx] = gs
 
 	host, portStr, err := net.SplitHostPort(agent)
 	if err != nil {
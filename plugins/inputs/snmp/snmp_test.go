@@ -590,7 +590,7 @@ func TestGather(t *testing.T) {
 			},
 		},
 
-		connectionCache: []snmpConnection{
+		connectionCache: []Connection{
 			tsc,
 		},
 		initialized: true,
@@ -637,7 +637,7 @@ func TestGather_host(t *testing.T) {
 			},
 		},
 
-		connectionCache: []snmpConnection{
+		connectionCache: []Connection{
 			tsc,
 		},
 		initialized: true,
@@ -2,23 +2,24 @@ package socket_listener
 
 import (
 	"bufio"
-	"crypto/tls"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"os"
 	"strings"
-	"sync"
-	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
-	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/internal/listener"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
+// defaultMaxDecompressionSize is the default maximum size of a decoded packet.
+// 500 MB
+const defaultMaxDecompressionSize = 500 * 1024 * 1024
+
 type setReadBufferer interface {
 	SetReadBuffer(bytes int) error
 }
@@ -28,14 +29,9 @@ type streamSocketListener struct {
 	*SocketListener
 
 	sockType string
-
-	connections    map[string]net.Conn
-	connectionsMtx sync.Mutex
 }
 
 func (ssl *streamSocketListener) listen() {
-	ssl.connections = map[string]net.Conn{}
-
 	for {
 		c, err := ssl.Accept()
 		if err != nil {
@@ -45,35 +41,23 @@ func (ssl *streamSocketListener) listen() {
 			break
 		}
 
-		if ssl.ReadBufferSize.Size > 0 {
-			if srb, ok := c.(setReadBufferer); ok {
-				srb.SetReadBuffer(int(ssl.ReadBufferSize.Size))
-			} else {
-				log.Printf("W! Unable to set read buffer on a %s socket", ssl.sockType)
-			}
-		}
-
-		ssl.connectionsMtx.Lock()
-		if ssl.MaxConnections > 0 && len(ssl.connections) >= ssl.MaxConnections {
-			ssl.connectionsMtx.Unlock()
-			c.Close()
-			continue
-		}
-		ssl.connections[c.RemoteAddr().String()] = c
-		ssl.connectionsMtx.Unlock()
+		go ssl.read(c)
+	}
+}
 
-		if err := ssl.setKeepAlive(c); err != nil {
-			ssl.AddError(fmt.Errorf("unable to configure keep alive (%s): %s", ssl.ServiceAddress, err))
+// setup sets the socket options of an accepted connection.
+func (ssl *streamSocketListener) setup(c net.Conn) {
+	if ssl.ReadBufferSize.Size > 0 {
+		if srb, ok := c.(setReadBufferer); ok {
+			srb.SetReadBuffer(int(ssl.ReadBufferSize.Size))
+		} else {
+			log.Printf("W! Unable to set read buffer on a %s socket", ssl.sockType)
 		}
-
-		go ssl.read(c)
 	}
 
-	ssl.connectionsMtx.Lock()
-	for _, c := range ssl.connections {
-		c.Close()
+	if err := ssl.setKeepAlive(c); err != nil {
+		ssl.AddError(fmt.Errorf("unable to configure keep alive (%s): %s", ssl.ServiceAddress, err))
 	}
-	ssl.connectionsMtx.Unlock()
 }
 
 func (ssl *streamSocketListener) setKeepAlive(c net.Conn) error {
@@ -93,21 +77,20 @@ func (ssl *streamSocketListener) setKeepAlive(c net.Conn) error {
 	return tcpc.SetKeepAlivePeriod(ssl.KeepAlivePeriod.Duration)
 }
 
-func (ssl *streamSocketListener) removeConnection(c net.Conn) {
-	ssl.connectionsMtx.Lock()
-	delete(ssl.connections, c.RemoteAddr().String())
-	ssl.connectionsMtx.Unlock()
-}
-
 func (ssl *streamSocketListener) read(c net.Conn) {
-	defer ssl.removeConnection(c)
 	defer c.Close()
 
-	scnr := bufio.NewScanner(c)
-	for {
-		if ssl.ReadTimeout != nil && ssl.ReadTimeout.Duration > 0 {
-			c.SetReadDeadline(time.Now().Add(ssl.ReadTimeout.Duration))
+	decoder, err := internal.NewStreamContentDecoder(ssl.ContentEncoding, c)
+	if err != nil {
+		if err != io.EOF && !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+			ssl.AddError(fmt.Errorf("unable to decode stream: %s", err))
 		}
+		return
+	}
+	defer decoder.Close()
+
+	scnr := bufio.NewScanner(decoder)
+	for {
 		if !scnr.Scan() {
 			break
 		}
@@ -134,20 +117,38 @@ func (ssl *streamSocketListener) read(c net.Conn) {
 type packetSocketListener struct {
 	net.PacketConn
 	*SocketListener
+
+	filter *listener.Filter
 }
 
 func (psl *packetSocketListener) listen() {
+	decoder, err := internal.NewContentDecoder(psl.ContentEncoding, psl.MaxDecompressionSize.Size)
+	if err != nil {
+		psl.AddError(err)
+		return
+	}
+	defer decoder.Close()
+
 	buf := make([]byte, 64*1024) // 64kb - maximum size of IP packet
 	for {
-		n, _, err := psl.ReadFrom(buf)
+		n, addr, err := psl.ReadFrom(buf)
 		if err != nil {
 			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
 				psl.AddError(err)
 			}
 			break
 		}
+		if !psl.filter.Allowed(addr) {
+			continue
+		}
 
-		metrics, err := psl.Parse(buf[:n])
+		body, err := decoder.Decode(buf[:n])
+		if err != nil {
+			psl.AddError(fmt.Errorf("unable to decode incoming packet: %s", err))
+			continue
+		}
+
+		metrics, err := psl.Parse(body)
 		if err != nil {
 			psl.AddError(fmt.Errorf("unable to parse incoming packet: %s", err))
 			//TODO rate limit
@@ -165,7 +166,11 @@ type SocketListener struct {
 	ReadBufferSize  internal.Size      `toml:"read_buffer_size"`
 	ReadTimeout     *internal.Duration `toml:"read_timeout"`
 	KeepAlivePeriod *internal.Duration `toml:"keep_alive_period"`
-	tlsint.ServerConfig
+	ContentEncoding string             `toml:"content_encoding"`
+
+	MaxDecompressionSize internal.Size `toml:"max_decompression_size"`
+
+	listener.Config
 
 	parsers.Parser
 	telegraf.Accumulator
@@ -206,6 +211,15 @@ func (sl *SocketListener) SampleConfig() string {
   # tls_key  = "/etc/telegraf/key.pem"
   ## Enables client authentication if set.
   # tls_allowed_cacerts = ["/etc/telegraf/clientca.pem"]
+  ## Common names of the client certificates to accept, requires
+  ## tls_allowed_cacerts.
+  # tls_allowed_cns = ["client.example.com"]
+
+  ## Source networks, in CIDR notation, to accept or refuse messages from.
+  ## If allowed_networks is set, only clients of these networks are accepted.
+  ## Does not apply to unix sockets.
+  # allowed_networks = ["10.0.0.0/8", "192.168.0.0/16"]
+  # denied_networks = ["10.0.0.1"]
 
   ## Maximum socket buffer size (in bytes when no unit specified).
   ## For stream sockets, once the buffer fills up, the sender will start backing up.
@@ -219,6 +233,17 @@ func (sl *SocketListener) SampleConfig() string {
   ## Defaults to the OS configuration.
   # keep_alive_period = "5m"
 
+  ## Content encoding for message payloads, can be set to "gzip" or "zstd" to
+  ## decode the compressed payloads, or "identity" to apply no decoding.
+  ## Stream sockets are decoded as a whole per connection, datagram sockets
+  ## per packet.
+  # content_encoding = "identity"
+
+  ## Maximum size of a decoded packet.
+  ## Only applies to datagram sockets (e.g. UDP); in streams each line is
+  ## limited to 64KiB.
+  # max_decompression_size = "500MB"
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -237,6 +262,10 @@ func (sl *SocketListener) SetParser(parser parsers.Parser) {
 
 func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 	sl.Accumulator = acc
+	if !internal.IsSupportedContentEncoding(sl.ContentEncoding) {
+		return fmt.Errorf("unknown content encoding '%s'", sl.ContentEncoding)
+	}
+
 	spl := strings.SplitN(sl.ServiceAddress, "://", 2)
 	if len(spl) != 2 {
 		return fmt.Errorf("invalid service address: %s", sl.ServiceAddress)
@@ -251,34 +280,32 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 
 	switch spl[0] {
 	case "tcp", "tcp4", "tcp6", "unix", "unixpacket":
-		var (
-			err error
-			l   net.Listener
-		)
-
-		tlsCfg, err := sl.ServerConfig.TLSConfig()
-		if err != nil {
-			return nil
+		ssl := &streamSocketListener{
+			SocketListener: sl,
+			sockType:       spl[0],
 		}
 
-		if tlsCfg == nil {
-			l, err = net.Listen(spl[0], spl[1])
-		} else {
-			l, err = tls.Listen(spl[0], spl[1], tlsCfg)
+		opts := listener.Options{
+			MaxConnections: sl.MaxConnections,
+			OnAccept:       ssl.setup,
+		}
+		if sl.ReadTimeout != nil {
+			opts.ReadTimeout = sl.ReadTimeout.Duration
 		}
+		l, err := sl.Listen(spl[0], spl[1], opts)
 		if err != nil {
 			return err
 		}
-
-		ssl := &streamSocketListener{
-			Listener:       l,
-			SocketListener: sl,
-			sockType:       spl[0],
-		}
+		ssl.Listener = l
 
 		sl.Closer = ssl
 		go ssl.listen()
 	case "udp", "udp4", "udp6", "ip", "ip4", "ip6", "unixgram":
+		filter, err := sl.Filter()
+		if err != nil {
+			return err
+		}
+
 		pc, err := net.ListenPacket(spl[0], spl[1])
 		if err != nil {
 			return err
@@ -295,6 +322,7 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 		psl := &packetSocketListener{
 			PacketConn:     pc,
 			SocketListener: sl,
+			filter:         filter,
 		}
 
 		sl.Closer = psl
@@ -321,7 +349,8 @@ func newSocketListener() *SocketListener {
 	parser, _ := parsers.NewInfluxParser()
 
 	return &SocketListener{
-		Parser: parser,
+		Parser:               parser,
+		MaxDecompressionSize: internal.Size{Size: defaultMaxDecompressionSize},
 	}
 }
 
@@ -2,6 +2,7 @@ package socket_listener
 
 import (
 	"bytes"
+	"compress/gzip"
 	"crypto/tls"
 	"io/ioutil"
 	"log"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -161,6 +163,136 @@ func TestSocketListener_unixgram(t *testing.T) {
 	testSocketListener(t, sl, client)
 }
 
+type flushWriter interface {
+	Write(p []byte) (int, error)
+	Flush() error
+	Close() error
+}
+
+func testSocketListenerStreamEncoding(t *testing.T, encoding string, newWriter func(net.Conn) flushWriter) {
+	defer testEmptyLog(t)()
+
+	sl := newSocketListener()
+	sl.ServiceAddress = "tcp://127.0.0.1:0"
+	sl.ContentEncoding = encoding
+
+	acc := &testutil.Accumulator{}
+	err := sl.Start(acc)
+	require.NoError(t, err)
+	defer sl.Stop()
+
+	client, err := net.Dial("tcp", sl.Closer.(net.Listener).Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	// Metrics are received as soon as the encoder is flushed.
+	w := newWriter(client)
+	_, err = w.Write([]byte("test,foo=bar v=1i 123456789\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	acc.Wait(1)
+
+	_, err = w.Write([]byte("test,foo=baz v=2i 123456790\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	acc.Wait(2)
+
+	acc.AssertContainsTaggedFields(t, "test",
+		map[string]interface{}{"v": int64(1)}, map[string]string{"foo": "bar"})
+	acc.AssertContainsTaggedFields(t, "test",
+		map[string]interface{}{"v": int64(2)}, map[string]string{"foo": "baz"})
+}
+
+func TestSocketListener_tcp_gzip(t *testing.T) {
+	testSocketListenerStreamEncoding(t, "gzip", func(c net.Conn) flushWriter {
+		return gzip.NewWriter(c)
+	})
+}
+
+func TestSocketListener_tcp_zstd(t *testing.T) {
+	testSocketListenerStreamEncoding(t, "zstd", func(c net.Conn) flushWriter {
+		w, err := zstd.NewWriter(c)
+		require.NoError(t, err)
+		return w
+	})
+}
+
+func TestSocketListener_udp_gzip(t *testing.T) {
+	sl := newSocketListener()
+	sl.ServiceAddress = "udp://127.0.0.1:0"
+	sl.ContentEncoding = "gzip"
+	sl.MaxDecompressionSize = internal.Size{Size: 64}
+
+	acc := &testutil.Accumulator{}
+	err := sl.Start(acc)
+	require.NoError(t, err)
+	defer sl.Stop()
+
+	client, err := net.Dial("udp", sl.Closer.(net.PacketConn).LocalAddr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	for _, data := range []string{
+		"test,foo=bar v=1i 123456789\ntest,foo=baz v=2i 123456790\n",
+		"test,foo=bar v=1i 123456789\ntest,foo=baz v=2i 123456790\ntest,foo=zab v=3i 123456791\n",
+	} {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write([]byte(data))
+		w.Close()
+		_, err = client.Write(buf.Bytes())
+		require.NoError(t, err)
+	}
+
+	// The second packet exceeds the maximum decompression size.
+	acc.Wait(2)
+	acc.WaitError(1)
+	require.Len(t, acc.Metrics, 2)
+	require.Contains(t, acc.Errors[0].Error(), internal.ErrContentTooLarge.Error())
+}
+
+func TestSocketListener_udp_deniedNetworks(t *testing.T) {
+	sl := newSocketListener()
+	sl.ServiceAddress = "udp://127.0.0.1:0"
+	sl.DeniedNetworks = []string{"127.0.0.0/8"}
+
+	acc := &testutil.Accumulator{}
+	err := sl.Start(acc)
+	require.NoError(t, err)
+	defer sl.Stop()
+
+	client, err := net.Dial("udp", sl.Closer.(net.PacketConn).LocalAddr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Write([]byte("test,foo=bar v=1i 123456789\n"))
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	acc.Lock()
+	defer acc.Unlock()
+	require.Empty(t, acc.Metrics)
+	require.Empty(t, acc.Errors)
+}
+
+func TestSocketListener_invalidNetwork(t *testing.T) {
+	sl := newSocketListener()
+	sl.ServiceAddress = "udp://127.0.0.1:0"
+	sl.AllowedNetworks = []string{"10.0.0.0/33"}
+
+	acc := &testutil.Accumulator{}
+	require.Error(t, sl.Start(acc))
+}
+
+func TestSocketListener_unknownContentEncoding(t *testing.T) {
+	sl := newSocketListener()
+	sl.ServiceAddress = "tcp://127.0.0.1:0"
+	sl.ContentEncoding = "br"
+
+	acc := &testutil.Accumulator{}
+	require.Error(t, sl.Start(acc))
+}
+
 func testSocketListener(t *testing.T, sl *SocketListener, client net.Conn) {
 	mstr12 := "test,foo=bar v=1i 123456789\ntest,foo=baz v=2i 123456790\n"
 	mstr3 := "test,foo=zab v=3i 123456791"
@@ -17,6 +17,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/listener"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/selfstat"
 )
@@ -78,14 +79,10 @@ type Statsd struct {
 
 	ReadBufferSize int `toml:"read_buffer_size"`
 
+	listener.Config
+
 	sync.Mutex
-	// Lock for preventing a data race during resource cleanup
-	cleanup sync.Mutex
-	wg      sync.WaitGroup
-	// accept channel tracks how many active connections there are, if there
-	// is an available bool in accept, then we are below the maximum and can
-	// accept the connection
-	accept chan bool
+	wg sync.WaitGroup
 	// drops tracks the number of dropped metrics.
 	drops int
 	// malformed tracks the number of malformed packets
@@ -108,10 +105,9 @@ type Statsd struct {
 
 	// Protocol listeners
 	UDPlistener *net.UDPConn
-	TCPlistener *net.TCPListener
+	TCPlistener net.Listener
 
-	// track current connections so we can close them in Stop()
-	conns map[string]*net.TCPConn
+	udpFilter *listener.Filter
 
 	MaxTCPConnections int `toml:"max_tcp_connections"`
 
@@ -193,6 +189,21 @@ const sampleConfig = `
   ## Address and port to host UDP listener on
   service_address = ":8125"
 
+  ## Optional TLS configuration.
+  ## Only applies when protocol is set to tcp.
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key  = "/etc/telegraf/key.pem"
+  ## Enables client authentication if set.
+  # tls_allowed_cacerts = ["/etc/telegraf/clientca.pem"]
+  ## Common names of the client certificates to accept, requires
+  ## tls_allowed_cacerts.
+  # tls_allowed_cns = ["client.example.com"]
+
+  ## Source networks, in CIDR notation, to accept or refuse messages from.
+  ## If allowed_networks is set, only clients of these networks are accepted.
+  # allowed_networks = ["10.0.0.0/8", "192.168.0.0/16"]
+  # denied_networks = ["10.0.0.1"]
+
   ## The following configuration options control when telegraf clears it's cache
   ## of previous values. If set to false, then telegraf will only clear it's
   ## cache when the daemon is restarted.
@@ -318,16 +329,11 @@ func (s *Statsd) Start(_ telegraf.Accumulator) error {
 
 	s.in = make(chan *bytes.Buffer, s.AllowedPendingMessages)
 	s.done = make(chan struct{})
-	s.accept = make(chan bool, s.MaxTCPConnections)
-	s.conns = make(map[string]*net.TCPConn)
 	s.bufPool = sync.Pool{
 		New: func() interface{} {
 			return new(bytes.Buffer)
 		},
 	}
-	for i := 0; i < s.MaxTCPConnections; i++ {
-		s.accept <- true
-	}
 
 	if s.ConvertNames {
 		log.Printf("I! WARNING statsd: convert_names config option is deprecated," +
@@ -338,6 +344,19 @@ func (s *Statsd) Start(_ telegraf.Accumulator) error {
 		s.MetricSeparator = defaultSeparator
 	}
 
+	var err error
+	if s.isUDP() {
+		s.udpFilter, err = s.Filter()
+	} else {
+		s.TCPlistener, err = s.Listen("tcp", s.ServiceAddress, listener.Options{
+			MaxConnections: s.MaxTCPConnections,
+			OnAccept:       s.setKeepAlive,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
 	s.wg.Add(2)
 	// Start the UDP listener
 	if s.isUDP() {
@@ -351,17 +370,9 @@ func (s *Statsd) Start(_ telegraf.Accumulator) error {
 	return nil
 }
 
-// tcpListen() starts listening for udp packets on the configured port.
+// tcpListen() accepts the connections of the tcp listener.
 func (s *Statsd) tcpListen() error {
 	defer s.wg.Done()
-	// Start listener
-	var err error
-	address, _ := net.ResolveTCPAddr("tcp", s.ServiceAddress)
-	s.TCPlistener, err = net.ListenTCP("tcp", address)
-	if err != nil {
-		log.Fatalf("ERROR: ListenTCP - %s", err)
-		return err
-	}
 	log.Println("I! TCP Statsd listening on: ", s.TCPlistener.Addr().String())
 	for {
 		select {
@@ -369,35 +380,31 @@ func (s *Statsd) tcpListen() error {
 			return nil
 		default:
 			// Accept connection:
-			conn, err := s.TCPlistener.AcceptTCP()
+			conn, err := s.TCPlistener.Accept()
 			if err != nil {
 				return err
 			}
 
-			if s.TCPKeepAlive {
-				if err = conn.SetKeepAlive(true); err != nil {
-					return err
-				}
+			s.wg.Add(1)
+			go s.handler(conn)
+		}
+	}
+}
 
-				if s.TCPKeepAlivePeriod != nil {
-					if err = conn.SetKeepAlivePeriod(s.TCPKeepAlivePeriod.Duration); err != nil {
-						return err
-					}
-				}
-			}
+// setKeepAlive enables keep alive probes on the TCP connection if configured.
+func (s *Statsd) setKeepAlive(c net.Conn) {
+	conn, ok := c.(*net.TCPConn)
+	if !ok || !s.TCPKeepAlive {
+		return
+	}
 
-			select {
-			case <-s.accept:
-				// not over connection limit, handle the connection properly.
-				s.wg.Add(1)
-				// generate a random id for this TCPConn
-				id := internal.RandomString(6)
-				s.remember(id, conn)
-				go s.handler(conn, id)
-			default:
-				// We are over the connection limit, refuse & close.
-				s.refuser(conn)
-			}
+	if err := conn.SetKeepAlive(true); err != nil {
+		log.Printf("E! Unable to enable keep alive on %s: %s", conn.RemoteAddr(), err)
+		return
+	}
+	if s.TCPKeepAlivePeriod != nil {
+		if err := conn.SetKeepAlivePeriod(s.TCPKeepAlivePeriod.Duration); err != nil {
+			log.Printf("E! Unable to set keep alive period on %s: %s", conn.RemoteAddr(), err)
 		}
 	}
 }
@@ -423,11 +430,14 @@ func (s *Statsd) udpListen() error {
 		case <-s.done:
 			return nil
 		default:
-			n, _, err := s.UDPlistener.ReadFromUDP(buf)
+			n, addr, err := s.UDPlistener.ReadFromUDP(buf)
 			if err != nil && !strings.Contains(err.Error(), "closed network") {
 				log.Printf("E! Error READ: %s\n", err.Error())
 				continue
 			}
+			if addr != nil && !s.udpFilter.Allowed(addr) {
+				continue
+			}
 			b := s.bufPool.Get().(*bytes.Buffer)
 			b.Reset()
 			b.Write(buf[:n])
@@ -780,16 +790,13 @@ func (s *Statsd) aggregate(m metric) {
 }
 
 // handler handles a single TCP Connection
-func (s *Statsd) handler(conn *net.TCPConn, id string) {
+func (s *Statsd) handler(conn net.Conn) {
 	s.CurrentConnections.Incr(1)
 	s.TotalConnections.Incr(1)
 	// connection cleanup function
 	defer func() {
 		s.wg.Done()
 		conn.Close()
-		// Add one connection potential back to channel when this one closes
-		s.accept <- true
-		s.forget(id)
 		s.CurrentConnections.Incr(-1)
 	}()
 
@@ -827,28 +834,6 @@ func (s *Statsd) handler(conn *net.TCPConn, id string) {
 	}
 }
 
-// refuser refuses a TCP connection
-func (s *Statsd) refuser(conn *net.TCPConn) {
-	conn.Close()
-	log.Printf("I! Refused TCP Connection from %s", conn.RemoteAddr())
-	log.Printf("I! WARNING: Maximum TCP Connections reached, you may want to" +
-		" adjust max_tcp_connections")
-}
-
-// forget a TCP connection
-func (s *Statsd) forget(id string) {
-	s.cleanup.Lock()
-	defer s.cleanup.Unlock()
-	delete(s.conns, id)
-}
-
-// remember a TCP connection
-func (s *Statsd) remember(id string, conn *net.TCPConn) {
-	s.cleanup.Lock()
-	defer s.cleanup.Unlock()
-	s.conns[id] = conn
-}
-
 func (s *Statsd) Stop() {
 	s.Lock()
 	log.Println("I! Stopping the statsd service")
@@ -856,20 +841,8 @@ func (s *Statsd) Stop() {
 	if s.isUDP() {
 		s.UDPlistener.Close()
 	} else {
+		// Closing the listener closes all open TCP connections
 		s.TCPlistener.Close()
-		// Close all open TCP connections
-		//  - get all conns from the s.conns map and put into slice
-		//  - this is so the forget() function doesnt conflict with looping
-		//    over the s.conns map
-		var conns []*net.TCPConn
-		s.cleanup.Lock()
-		for _, conn := range s.conns {
-			conns = append(conns, conn)
-		}
-		s.cleanup.Unlock()
-		for _, conn := range conns {
-			conn.Close()
-		}
 	}
 	s.Unlock()
 
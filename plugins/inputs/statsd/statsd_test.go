@@ -2,6 +2,7 @@ package statsd
 
 import (
 	"bytes"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -17,6 +18,8 @@ const (
 	testMsg = "test.tcp.msg:100|c"
 )
 
+var pki = testutil.NewPKI("../../../testutil/pki")
+
 func newTestTcpListener() (*Statsd, chan *bytes.Buffer) {
 	in := make(chan *bytes.Buffer, 1500)
 	listener := &Statsd{
@@ -125,6 +128,65 @@ func TestCloseConcurrentConns(t *testing.T) {
 	listener.Stop()
 }
 
+func TestTCPTLS(t *testing.T) {
+	listener := Statsd{
+		Protocol:               "tcp",
+		ServiceAddress:         "127.0.0.1:0",
+		AllowedPendingMessages: 10000,
+		MaxTCPConnections:      2,
+	}
+	listener.ServerConfig = *pki.TLSServerConfig()
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	tlsCfg, err := pki.TLSClientConfig().TLSConfig()
+	require.NoError(t, err)
+
+	conn, err := tls.Dial("tcp", listener.TCPlistener.Addr().String(), tlsCfg)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte(testMsg + "\n"))
+	require.NoError(t, err)
+	conn.Close()
+
+	for i := 0; i < 100; i++ {
+		listener.Lock()
+		n := len(listener.counters)
+		listener.Unlock()
+		if n == 1 {
+			return
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	t.Fatal("metric not received")
+}
+
+func TestUDPDeniedNetworks(t *testing.T) {
+	listener := Statsd{
+		Protocol:               "udp",
+		ServiceAddress:         "localhost:8125",
+		AllowedPendingMessages: 10000,
+	}
+	listener.DeniedNetworks = []string{"127.0.0.1"}
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	time.Sleep(time.Millisecond * 100)
+	conn, err := net.Dial("udp", "127.0.0.1:8125")
+	require.NoError(t, err)
+	_, err = conn.Write([]byte(testMsg))
+	require.NoError(t, err)
+	conn.Close()
+
+	time.Sleep(time.Millisecond * 100)
+	listener.Lock()
+	defer listener.Unlock()
+	require.Empty(t, listener.counters)
+}
+
 // benchmark how long it takes to accept & process 100,000 metrics:
 func BenchmarkUDP(b *testing.B) {
 	listener := Statsd{
@@ -0,0 +1,263 @@
+package syslog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/go-syslog"
+	"github.com/influxdata/go-syslog/nontransparent"
+	"github.com/influxdata/go-syslog/rfc5424"
+	framing "github.com/influxdata/telegraf/internal/syslog"
+)
+
+// rfc3164DefaultPriority is the priority of messages without one, user-level
+// notice as specified in RFC3164#section-4.3.3.
+const rfc3164DefaultPriority = 13
+
+// rfc3164TimestampLayouts are the layouts tried for the timestamp of a
+// message.  Besides the timestamp of RFC3164, which lacks the year, some
+// senders add the year or use a RFC3339 timestamp.
+var rfc3164TimestampLayouts = []string{
+	time.RFC3339Nano,
+	"Jan _2 2006 15:04:05",
+	time.Stamp,
+}
+
+// rfc3164Parser parses messages in the BSD syslog format (RFC3164):
+//
+//   <PRI>TIMESTAMP HOSTNAME TAG[PID]: CONTENT
+//
+// The messages are returned as RFC5424 messages without version, so that they
+// produce the same metrics.  The hostname is optional, as it is left out by
+// the local syslog() function.  In best effort mode a message without valid
+// priority gets the default one, and without valid timestamp the remainder of
+// the message is its content.
+type rfc3164Parser struct {
+	location   *time.Location
+	now        func() time.Time
+	bestEffort bool
+}
+
+func newRFC3164Parser(location *time.Location, now func() time.Time) *rfc3164Parser {
+	if now == nil {
+		now = time.Now
+	}
+	return &rfc3164Parser{
+		location: location,
+		now:      now,
+	}
+}
+
+// WithBestEffort enables best effort mode.
+func (p *rfc3164Parser) WithBestEffort() {
+	p.bestEffort = true
+}
+
+// HasBestEffort tells whether best effort mode is enabled.
+func (p *rfc3164Parser) HasBestEffort() bool {
+	return p.bestEffort
+}
+
+// Parse parses a single message.
+func (p *rfc3164Parser) Parse(input []byte) (syslog.Message, error) {
+	msg := &rfc5424.SyslogMessage{}
+	rest := strings.TrimRight(string(input), "\r\n\x00")
+
+	priority, rest, err := parsePriority(rest)
+	if err != nil {
+		if !p.bestEffort {
+			return nil, err
+		}
+		priority = rfc3164DefaultPriority
+	}
+	msg.SetPriority(priority)
+
+	timestamp, rest, err := p.parseTimestamp(rest)
+	if err != nil {
+		if !p.bestEffort {
+			return nil, err
+		}
+		if rest != "" {
+			msg.SetMessage(rest)
+		}
+		return msg, nil
+	}
+	msg.SetTimestamp(timestamp.Format("2006-01-02T15:04:05.999999Z07:00"))
+
+	if hostname, r, ok := parseHostname(rest); ok {
+		msg.SetHostname(hostname)
+		rest = r
+	}
+
+	if tag, procid, r, ok := parseTag(rest); ok {
+		msg.SetAppname(tag)
+		if procid != "" {
+			msg.SetProcID(procid)
+		}
+		rest = r
+	}
+
+	if rest != "" {
+		msg.SetMessage(rest)
+	}
+	return msg, nil
+}
+
+// parsePriority parses the <PRI> part of the message.
+func parsePriority(input string) (uint8, string, error) {
+	end := strings.IndexByte(input, '>')
+	if !strings.HasPrefix(input, "<") || end < 2 || end > 4 {
+		return 0, input, fmt.Errorf("expecting a priority value within angle brackets")
+	}
+	priority, err := strconv.ParseUint(input[1:end], 10, 8)
+	if err != nil || priority > 191 {
+		return 0, input, fmt.Errorf("expecting a priority value in the range 0-191")
+	}
+	return uint8(priority), input[end+1:], nil
+}
+
+// parseTimestamp parses the timestamp followed by a space.  Timestamps
+// without year are placed in the last year, so that messages sent before the
+// turn of the year are not placed in the future.
+func (p *rfc3164Parser) parseTimestamp(input string) (time.Time, string, error) {
+	n := timestampLength(input)
+	for _, layout := range rfc3164TimestampLayouts {
+		if n == 0 {
+			break
+		}
+
+		t, err := time.ParseInLocation(layout, input[:n], p.location)
+		if err != nil {
+			continue
+		}
+
+		if layout == time.Stamp {
+			now := p.now().In(p.location)
+			t = time.Date(now.Year(), t.Month(), t.Day(),
+				t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), p.location)
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+		}
+		return t, strings.TrimLeft(input[n:], " "), nil
+	}
+	return time.Time{}, input, fmt.Errorf("expecting a timestamp")
+}
+
+// timestampLength returns the length of the timestamp at the start of input,
+// which ends at the first space after the time.  The day may be padded with a
+// space or not, so the length of the layout does not tell where it ends.
+func timestampLength(input string) int {
+	colon := strings.IndexByte(input, ':')
+	if colon < 0 {
+		return 0
+	}
+	n := strings.IndexByte(input[colon:], ' ')
+	if n < 0 {
+		return len(input)
+	}
+	return colon + n
+}
+
+// parseHostname parses the hostname followed by a space.  A tag terminated
+// by a colon, or including the process id, is not a hostname.
+func parseHostname(input string) (string, string, bool) {
+	n := strings.IndexByte(input, ' ')
+	if n <= 0 {
+		return "", input, false
+	}
+	hostname := input[:n]
+	if strings.HasSuffix(hostname, ":") || strings.ContainsAny(hostname, "[]") {
+		return "", input, false
+	}
+	return hostname, strings.TrimLeft(input[n:], " "), true
+}
+
+// parseTag parses the tag, with the process id in square brackets,
+// terminated by a colon.
+func parseTag(input string) (string, string, string, bool) {
+	n := strings.IndexAny(input, ":[ ")
+	if n <= 0 || input[n] == ' ' {
+		return "", "", input, false
+	}
+	tag := input[:n]
+	rest := input[n:]
+
+	var procid string
+	if rest[0] == '[' {
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return "", "", input, false
+		}
+		procid = rest[1:end]
+		rest = rest[end+1:]
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return "", "", input, false
+	}
+	return tag, procid, strings.TrimPrefix(rest[1:], " "), true
+}
+
+// parseStream parses the messages framed in the stream, calling emit with
+// each result.  It is used for the formats without a stream parser in
+// go-syslog.  Messages are limited to ipMaxPacketSize bytes, a longer message
+// is reported as an error and skipped.
+func parseStream(r io.Reader, f framing.Framing, trailer nontransparent.TrailerType, machine syslog.Machine, emit syslog.ParserListener) {
+	reader := bufio.NewReaderSize(r, ipMaxPacketSize)
+
+	if f == framing.OctetCounting {
+		for {
+			length, err := reader.ReadSlice(' ')
+			if err == bufio.ErrBufferFull {
+				emit(&syslog.Result{Error: fmt.Errorf("expecting a message length")})
+				return
+			}
+			if err != nil {
+				return
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(string(length)))
+			if err != nil || n <= 0 || n > ipMaxPacketSize {
+				emit(&syslog.Result{Error: fmt.Errorf("expecting a message length, found %q", length)})
+				return
+			}
+
+			buf := make([]byte, n)
+			if _, err := io.ReadFull(reader, buf); err != nil {
+				return
+			}
+			message, err := machine.Parse(buf)
+			emit(&syslog.Result{Message: message, Error: err})
+		}
+	}
+
+	delim := byte('\n')
+	if trailer == nontransparent.NUL {
+		delim = 0
+	}
+	for {
+		buf, err := reader.ReadSlice(delim)
+		if err == bufio.ErrBufferFull {
+			emit(&syslog.Result{Error: fmt.Errorf("message longer than %d bytes", ipMaxPacketSize)})
+			for err == bufio.ErrBufferFull {
+				_, err = reader.ReadSlice(delim)
+			}
+			if err != nil {
+				return
+			}
+			continue
+		}
+		if len(buf) > 1 || (len(buf) == 1 && buf[0] != delim) {
+			// The buffer is overwritten by the next read.
+			message, perr := machine.Parse(append([]byte(nil), buf...))
+			emit(&syslog.Result{Message: message, Error: perr})
+		}
+		if err != nil {
+			return
+		}
+	}
+}
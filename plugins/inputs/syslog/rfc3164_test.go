@@ -0,0 +1,369 @@
+package syslog
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/go-syslog"
+	"github.com/influxdata/go-syslog/nontransparent"
+	"github.com/influxdata/go-syslog/rfc5424"
+	"github.com/influxdata/telegraf/internal"
+	framing "github.com/influxdata/telegraf/internal/syslog"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRFC3164Parser(t *testing.T) {
+	now := time.Date(2003, time.October, 12, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		input      string
+		location   *time.Location
+		now        time.Time
+		bestEffort bool
+		fields     map[string]interface{}
+		tags       map[string]string
+		err        bool
+	}{
+		{
+			name:  "complete",
+			input: "<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8",
+			fields: map[string]interface{}{
+				"severity_code": 2,
+				"facility_code": 4,
+				"timestamp":     time.Date(2003, time.October, 11, 22, 14, 15, 0, time.UTC).UnixNano(),
+				"message":       "'su root' failed for lonvick on /dev/pts/8",
+			},
+			tags: map[string]string{
+				"severity": "crit",
+				"facility": "auth",
+				"hostname": "mymachine",
+				"appname":  "su",
+			},
+		},
+		{
+			name:  "unpadded day",
+			input: "<13>Oct 1 17:32:18 mymachine sshd: Connection closed",
+			fields: map[string]interface{}{
+				"severity_code": 5,
+				"facility_code": 1,
+				"timestamp":     time.Date(2003, time.October, 1, 17, 32, 18, 0, time.UTC).UnixNano(),
+				"message":       "Connection closed",
+			},
+			tags: map[string]string{
+				"severity": "notice",
+				"facility": "user",
+				"hostname": "mymachine",
+				"appname":  "sshd",
+			},
+		},
+		{
+			name:  "process id and padded day",
+			input: "<13>Oct  1 17:32:18 10.0.0.99 sshd[4123]: Connection closed",
+			fields: map[string]interface{}{
+				"severity_code": 5,
+				"facility_code": 1,
+				"timestamp":     time.Date(2003, time.October, 1, 17, 32, 18, 0, time.UTC).UnixNano(),
+				"procid":        "4123",
+				"message":       "Connection closed",
+			},
+			tags: map[string]string{
+				"severity": "notice",
+				"facility": "user",
+				"hostname": "10.0.0.99",
+				"appname":  "sshd",
+			},
+		},
+		{
+			name:  "without hostname",
+			input: "<13>Oct 11 22:14:15 sshd[4123]: Connection closed\n",
+			fields: map[string]interface{}{
+				"severity_code": 5,
+				"facility_code": 1,
+				"timestamp":     time.Date(2003, time.October, 11, 22, 14, 15, 0, time.UTC).UnixNano(),
+				"procid":        "4123",
+				"message":       "Connection closed",
+			},
+			tags: map[string]string{
+				"severity": "notice",
+				"facility": "user",
+				"appname":  "sshd",
+			},
+		},
+		{
+			name:  "without tag",
+			input: "<13>Oct 11 22:14:15 mymachine just a message",
+			fields: map[string]interface{}{
+				"severity_code": 5,
+				"facility_code": 1,
+				"timestamp":     time.Date(2003, time.October, 11, 22, 14, 15, 0, time.UTC).UnixNano(),
+				"message":       "just a message",
+			},
+			tags: map[string]string{
+				"severity": "notice",
+				"facility": "user",
+				"hostname": "mymachine",
+			},
+		},
+		{
+			name:  "last year",
+			input: "<13>Dec 31 23:59:59.250 mymachine app: message",
+			now:   time.Date(2004, time.January, 1, 0, 0, 10, 0, time.UTC),
+			fields: map[string]interface{}{
+				"severity_code": 5,
+				"facility_code": 1,
+				"timestamp":     time.Date(2003, time.December, 31, 23, 59, 59, 250000000, time.UTC).UnixNano(),
+				"message":       "message",
+			},
+			tags: map[string]string{
+				"severity": "notice",
+				"facility": "user",
+				"hostname": "mymachine",
+				"appname":  "app",
+			},
+		},
+		{
+			name:     "timezone",
+			input:    "<13>Oct 11 22:14:15 mymachine app: message",
+			location: time.FixedZone("CEST", 2*60*60),
+			fields: map[string]interface{}{
+				"severity_code": 5,
+				"facility_code": 1,
+				"timestamp":     time.Date(2003, time.October, 11, 20, 14, 15, 0, time.UTC).UnixNano(),
+				"message":       "message",
+			},
+			tags: map[string]string{
+				"severity": "notice",
+				"facility": "user",
+				"hostname": "mymachine",
+				"appname":  "app",
+			},
+		},
+		{
+			name:     "timestamp with year",
+			input:    "<13>Oct 11 2002 22:14:15 mymachine app: message",
+			location: time.FixedZone("CEST", 2*60*60),
+			fields: map[string]interface{}{
+				"severity_code": 5,
+				"facility_code": 1,
+				"timestamp":     time.Date(2002, time.October, 11, 20, 14, 15, 0, time.UTC).UnixNano(),
+				"message":       "message",
+			},
+			tags: map[string]string{
+				"severity": "notice",
+				"facility": "user",
+				"hostname": "mymachine",
+				"appname":  "app",
+			},
+		},
+		{
+			name:     "RFC3339 timestamp",
+			input:    "<13>2003-10-11T22:14:15.003+01:00 mymachine app: message",
+			location: time.FixedZone("CEST", 2*60*60),
+			fields: map[string]interface{}{
+				"severity_code": 5,
+				"facility_code": 1,
+				"timestamp":     time.Date(2003, time.October, 11, 21, 14, 15, 3000000, time.UTC).UnixNano(),
+				"message":       "message",
+			},
+			tags: map[string]string{
+				"severity": "notice",
+				"facility": "user",
+				"hostname": "mymachine",
+				"appname":  "app",
+			},
+		},
+		{
+			name:  "missing priority",
+			input: "Oct 11 22:14:15 mymachine app: message",
+			err:   true,
+		},
+		{
+			name:       "missing priority in best effort mode",
+			input:      "Oct 11 22:14:15 mymachine app: message",
+			bestEffort: true,
+			fields: map[string]interface{}{
+				"severity_code": 5,
+				"facility_code": 1,
+				"timestamp":     time.Date(2003, time.October, 11, 22, 14, 15, 0, time.UTC).UnixNano(),
+				"message":       "message",
+			},
+			tags: map[string]string{
+				"severity": "notice",
+				"facility": "user",
+				"hostname": "mymachine",
+				"appname":  "app",
+			},
+		},
+		{
+			name:  "invalid timestamp",
+			input: "<34>yesterday mymachine su: message",
+			err:   true,
+		},
+		{
+			name:       "invalid timestamp in best effort mode",
+			input:      "<34>yesterday mymachine su: message",
+			bestEffort: true,
+			fields: map[string]interface{}{
+				"severity_code": 2,
+				"facility_code": 4,
+				"message":       "yesterday mymachine su: message",
+			},
+			tags: map[string]string{
+				"severity": "crit",
+				"facility": "auth",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := time.UTC
+			if tt.location != nil {
+				location = tt.location
+			}
+			ts := now
+			if !tt.now.IsZero() {
+				ts = tt.now
+			}
+			p := newRFC3164Parser(location, func() time.Time { return ts })
+			if tt.bestEffort {
+				p.WithBestEffort()
+			}
+
+			msg, err := p.Parse([]byte(tt.input))
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.fields, fields(msg, &Syslog{Separator: "_"}))
+			require.Equal(t, tt.tags, tags(msg))
+		})
+	}
+}
+
+func TestRFC3164Init(t *testing.T) {
+	s := &Syslog{SyslogStandard: "rfc3164", Timezone: "Local"}
+	require.NoError(t, s.Init())
+	require.Equal(t, rfc3164Standard, s.SyslogStandard)
+
+	s = &Syslog{SyslogStandard: "RFC3339"}
+	require.Error(t, s.Init())
+
+	s = &Syslog{Timezone: "Nowhere/Special"}
+	require.Error(t, s.Init())
+}
+
+func TestRFC3164MessageTooLong(t *testing.T) {
+	data := strings.Repeat("x", ipMaxPacketSize+10) + "\n" +
+		"<34>Oct 11 22:14:15 mymachine su: first\n"
+
+	var results []*syslog.Result
+	parser := newRFC3164Parser(time.UTC, nil)
+	parseStream(strings.NewReader(data), framing.NonTransparent, nontransparent.LF, parser,
+		func(r *syslog.Result) { results = append(results, r) })
+
+	require.Len(t, results, 2)
+	require.Error(t, results[0].Error)
+	require.NoError(t, results[1].Error)
+	message := results[1].Message.(*rfc5424.SyslogMessage).Message()
+	require.Equal(t, "first", *message)
+}
+
+func newRFC3164Receiver(address string, f framing.Framing) *Syslog {
+	return &Syslog{
+		Address: address,
+		now: func() time.Time {
+			return time.Date(2003, time.October, 12, 8, 0, 0, 0, time.UTC)
+		},
+		Framing:        f,
+		ReadTimeout:    &internal.Duration{Duration: defaultReadTimeout},
+		Separator:      "_",
+		SyslogStandard: "RFC3164",
+	}
+}
+
+func testRFC3164(t *testing.T, protocol string, address string, f framing.Framing, data string) {
+	receiver := newRFC3164Receiver(protocol+"://"+address, f)
+	acc := &testutil.Accumulator{}
+	require.NoError(t, receiver.Start(acc))
+	defer receiver.Stop()
+
+	conn, err := net.Dial(protocol, address)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte(data))
+	require.NoError(t, err)
+	conn.Close()
+
+	acc.Wait(2)
+	for i, message := range []string{"first", "second"} {
+		require.Equal(t, "syslog", acc.Metrics[i].Measurement)
+		require.Equal(t, map[string]interface{}{
+			"severity_code": 2,
+			"facility_code": 4,
+			"timestamp":     time.Date(2003, time.October, 11, 22, 14, 15, 0, time.UTC).UnixNano(),
+			"message":       message,
+		}, acc.Metrics[i].Fields)
+		require.Equal(t, map[string]string{
+			"severity": "crit",
+			"facility": "auth",
+			"hostname": "mymachine",
+			"appname":  "su",
+		}, acc.Metrics[i].Tags)
+	}
+}
+
+func TestRFC3164OctetCounting_tcp(t *testing.T) {
+	testRFC3164(t, "tcp", address, framing.OctetCounting,
+		"39 <34>Oct 11 22:14:15 mymachine su: first"+
+			"40 <34>Oct 11 22:14:15 mymachine su: second")
+}
+
+func TestRFC3164NonTransparent_unix(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	sock := filepath.Join(tmpdir, "syslog.TestRFC3164NonTransparent_unix.sock")
+
+	testRFC3164(t, "unix", sock, framing.NonTransparent,
+		"<34>Oct 11 22:14:15 mymachine su: first\n"+
+			"<34>Oct 11 22:14:15 mymachine su: second\n")
+}
+
+func TestRFC3164_unixgram(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	sock := filepath.Join(tmpdir, "syslog.TestRFC3164_unixgram.sock")
+
+	receiver := newRFC3164Receiver("unixgram://"+sock, framing.OctetCounting)
+	acc := &testutil.Accumulator{}
+	require.NoError(t, receiver.Start(acc))
+	defer receiver.Stop()
+
+	// Each datagram holds one message, as sent by the syslog() function.
+	conn, err := net.Dial("unixgram", sock)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("<13>Oct 11 22:14:15 sshd[4123]: Connection closed"))
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	require.Equal(t, map[string]interface{}{
+		"severity_code": 5,
+		"facility_code": 1,
+		"timestamp":     time.Date(2003, time.October, 11, 22, 14, 15, 0, time.UTC).UnixNano(),
+		"procid":        "4123",
+		"message":       "Connection closed",
+	}, acc.Metrics[0].Fields)
+	require.Equal(t, map[string]string{
+		"severity": "notice",
+		"facility": "user",
+		"appname":  "sshd",
+	}, acc.Metrics[0].Tags)
+}
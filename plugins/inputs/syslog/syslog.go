@@ -1,7 +1,6 @@
 package syslog
 
 import (
-	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
@@ -18,39 +17,47 @@ import (
 	"github.com/influxdata/go-syslog/rfc5424"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
-	tlsConfig "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/internal/listener"
+	framing "github.com/influxdata/telegraf/internal/syslog"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
 const defaultReadTimeout = time.Second * 5
 const ipMaxPacketSize = 64 * 1024
 
+// Syslog standards of the messages
+const (
+	rfc5424Standard = "RFC5424"
+	rfc3164Standard = "RFC3164"
+)
+
 // Syslog is a syslog plugin
 type Syslog struct {
-	tlsConfig.ServerConfig
+	listener.Config
 	Address         string `toml:"server"`
 	KeepAlivePeriod *internal.Duration
 	MaxConnections  int
 	ReadTimeout     *internal.Duration
-	Framing         Framing
+	Framing         framing.Framing
 	Trailer         nontransparent.TrailerType
 	BestEffort      bool
 	Separator       string `toml:"sdparam_separator"`
+	SyslogStandard  string `toml:"syslog_standard"`
+	Timezone        string `toml:"timezone"`
 
 	now      func() time.Time
+	location *time.Location
 	lastTime time.Time
 
 	mu sync.Mutex
 	wg sync.WaitGroup
 	io.Closer
 
-	isStream      bool
-	tcpListener   net.Listener
-	tlsConfig     *tls.Config
-	connections   map[string]net.Conn
-	connectionsMu sync.Mutex
+	isStream    bool
+	tcpListener net.Listener
 
 	udpListener net.PacketConn
+	udpFilter   *listener.Filter
 }
 
 var sampleConfig = `
@@ -58,12 +65,23 @@ var sampleConfig = `
   ## Protocol, address and port to host the syslog receiver.
   ## If no host is specified, then localhost is used.
   ## If no port is specified, 6514 is used (RFC5425#section-4.1).
+  ## Unix sockets are given by their path - eg., unix:///var/run/telegraf-syslog.sock,
+  ## or unixgram:///dev/log for datagrams.
   server = "tcp://:6514"
 
   ## TLS Config
   # tls_allowed_cacerts = ["/etc/telegraf/ca.pem"]
   # tls_cert = "/etc/telegraf/cert.pem"
   # tls_key = "/etc/telegraf/key.pem"
+  ## Common names of the client certificates to accept, requires
+  ## tls_allowed_cacerts.
+  # tls_allowed_cns = ["client.example.com"]
+
+  ## Source networks, in CIDR notation, to accept or refuse messages from.
+  ## If allowed_networks is set, only clients of these networks are accepted.
+  ## Does not apply to unix sockets.
+  # allowed_networks = ["10.0.0.0/8", "192.168.0.0/16"]
+  # denied_networks = ["10.0.0.1"]
 
   ## Period between keep alive probes.
   ## 0 disables keep alive probes.
@@ -94,6 +112,15 @@ var sampleConfig = `
   ## By default best effort parsing is off.
   # best_effort = false
 
+  ## The syslog standard of the messages (default = "RFC5424").
+  ## Must be one of "RFC5424", or "RFC3164".
+  # syslog_standard = "RFC5424"
+
+  ## Timezone of RFC3164 timestamps, which lack the offset (default = "UTC").
+  ## Either "UTC", "Local", or a name of the IANA Time Zone database,
+  ## eg. "Europe/Berlin".
+  # timezone = "UTC"
+
   ## Character to prepend to SD-PARAMs (default = "_").
   ## A syslog message can contain multiple parameters and multiple identifiers within structured data section.
   ## Eg., [id1 name1="val1" name2="val2"][id2 name1="val1" nameA="valA"]
@@ -109,7 +136,26 @@ func (s *Syslog) SampleConfig() string {
 
 // Description returns the plugin description
 func (s *Syslog) Description() string {
-	return "Accepts syslog messages following RFC5424 format with transports as per RFC5426, RFC5425, or RFC6587"
+	return "Accepts syslog messages following RFC5424 or RFC3164 format with transports as per RFC5426, RFC5425, or RFC6587"
+}
+
+// Init checks the syslog standard and loads the timezone.
+func (s *Syslog) Init() error {
+	switch strings.ToUpper(s.SyslogStandard) {
+	case "", rfc5424Standard:
+		s.SyslogStandard = rfc5424Standard
+	case rfc3164Standard:
+		s.SyslogStandard = rfc3164Standard
+	default:
+		return fmt.Errorf("unknown syslog standard '%s'", s.SyslogStandard)
+	}
+
+	var err error
+	s.location, err = time.LoadLocation(s.Timezone)
+	if err != nil {
+		return fmt.Errorf("unknown timezone '%s': %v", s.Timezone, err)
+	}
+	return nil
 }
 
 // Gather ...
@@ -122,6 +168,10 @@ func (s *Syslog) Start(acc telegraf.Accumulator) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.Init(); err != nil {
+		return err
+	}
+
 	scheme, host, err := getAddressParts(s.Address)
 	if err != nil {
 		return err
@@ -142,20 +192,32 @@ func (s *Syslog) Start(acc telegraf.Accumulator) error {
 	}
 
 	if s.isStream {
-		l, err := net.Listen(scheme, s.Address)
+		opts := listener.Options{
+			MaxConnections: s.MaxConnections,
+			OnAccept: func(c net.Conn) {
+				if err := s.setKeepAlive(c); err != nil {
+					acc.AddError(fmt.Errorf("unable to configure keep alive (%s): %s", s.Address, err))
+				}
+			},
+		}
+		if s.ReadTimeout != nil {
+			opts.ReadTimeout = s.ReadTimeout.Duration
+		}
+		l, err := s.Listen(scheme, s.Address, opts)
 		if err != nil {
 			return err
 		}
 		s.Closer = l
 		s.tcpListener = l
-		s.tlsConfig, err = s.TLSConfig()
-		if err != nil {
-			return err
-		}
 
 		s.wg.Add(1)
 		go s.listenStream(acc)
 	} else {
+		s.udpFilter, err = s.Filter()
+		if err != nil {
+			return err
+		}
+
 		l, err := net.ListenPacket(scheme, s.Address)
 		if err != nil {
 			return err
@@ -217,20 +279,18 @@ func getAddressParts(a string) (string, string, error) {
 func (s *Syslog) listenPacket(acc telegraf.Accumulator) {
 	defer s.wg.Done()
 	b := make([]byte, ipMaxPacketSize)
-	var p syslog.Machine
-	if s.BestEffort {
-		p = rfc5424.NewParser(rfc5424.WithBestEffort())
-	} else {
-		p = rfc5424.NewParser()
-	}
+	p := s.newMachine()
 	for {
-		n, _, err := s.udpListener.ReadFrom(b)
+		n, addr, err := s.udpListener.ReadFrom(b)
 		if err != nil {
 			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
 				acc.AddError(err)
 			}
 			break
 		}
+		if !s.udpFilter.Allowed(addr) {
+			continue
+		}
 
 		message, err := p.Parse(b[:n])
 		if message != nil {
@@ -242,11 +302,25 @@ func (s *Syslog) listenPacket(acc telegraf.Accumulator) {
 	}
 }
 
+// newMachine returns the parser for single messages of the syslog standard.
+func (s *Syslog) newMachine() syslog.Machine {
+	if s.SyslogStandard == rfc3164Standard {
+		p := newRFC3164Parser(s.location, s.now)
+		if s.BestEffort {
+			p.WithBestEffort()
+		}
+		return p
+	}
+
+	if s.BestEffort {
+		return rfc5424.NewParser(rfc5424.WithBestEffort())
+	}
+	return rfc5424.NewParser()
+}
+
 func (s *Syslog) listenStream(acc telegraf.Accumulator) {
 	defer s.wg.Done()
 
-	s.connections = map[string]net.Conn{}
-
 	for {
 		conn, err := s.tcpListener.Accept()
 		if err != nil {
@@ -255,53 +329,24 @@ func (s *Syslog) listenStream(acc telegraf.Accumulator) {
 			}
 			break
 		}
-		var tcpConn, _ = conn.(*net.TCPConn)
-		if s.tlsConfig != nil {
-			conn = tls.Server(conn, s.tlsConfig)
-		}
-
-		s.connectionsMu.Lock()
-		if s.MaxConnections > 0 && len(s.connections) >= s.MaxConnections {
-			s.connectionsMu.Unlock()
-			conn.Close()
-			continue
-		}
-		s.connections[conn.RemoteAddr().String()] = conn
-		s.connectionsMu.Unlock()
-
-		if err := s.setKeepAlive(tcpConn); err != nil {
-			acc.AddError(fmt.Errorf("unable to configure keep alive (%s): %s", s.Address, err))
-		}
 
 		go s.handle(conn, acc)
 	}
-
-	s.connectionsMu.Lock()
-	for _, c := range s.connections {
-		c.Close()
-	}
-	s.connectionsMu.Unlock()
-}
-
-func (s *Syslog) removeConnection(c net.Conn) {
-	s.connectionsMu.Lock()
-	delete(s.connections, c.RemoteAddr().String())
-	s.connectionsMu.Unlock()
 }
 
 func (s *Syslog) handle(conn net.Conn, acc telegraf.Accumulator) {
-	defer func() {
-		s.removeConnection(conn)
-		conn.Close()
-	}()
+	defer conn.Close()
 
 	var p syslog.Parser
 
 	emit := func(r *syslog.Result) {
 		s.store(*r, acc)
-		if s.ReadTimeout != nil && s.ReadTimeout.Duration > 0 {
-			conn.SetReadDeadline(time.Now().Add(s.ReadTimeout.Duration))
-		}
+	}
+
+	if s.SyslogStandard == rfc3164Standard {
+		// go-syslog only parses RFC5424 messages in streams.
+		parseStream(conn, s.Framing, s.Trailer, s.newMachine(), emit)
+		return
 	}
 
 	// Create parser options
@@ -313,7 +358,7 @@ func (s *Syslog) handle(conn net.Conn, acc telegraf.Accumulator) {
 	}
 
 	// Select the parser to use depeding on transport framing
-	if s.Framing == OctetCounting {
+	if s.Framing == framing.OctetCounting {
 		// Octet counting transparent framing
 		p = octetcounting.NewParser(opts...)
 	} else {
@@ -323,24 +368,25 @@ func (s *Syslog) handle(conn net.Conn, acc telegraf.Accumulator) {
 	}
 
 	p.Parse(conn)
-
-	if s.ReadTimeout != nil && s.ReadTimeout.Duration > 0 {
-		conn.SetReadDeadline(time.Now().Add(s.ReadTimeout.Duration))
-	}
 }
 
-func (s *Syslog) setKeepAlive(c *net.TCPConn) error {
+func (s *Syslog) setKeepAlive(c net.Conn) error {
 	if s.KeepAlivePeriod == nil {
 		return nil
 	}
 
+	tcpConn, ok := c.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+
 	if s.KeepAlivePeriod.Duration == 0 {
-		return c.SetKeepAlive(false)
+		return tcpConn.SetKeepAlive(false)
 	}
-	if err := c.SetKeepAlive(true); err != nil {
+	if err := tcpConn.SetKeepAlive(true); err != nil {
 		return err
 	}
-	return c.SetKeepAlivePeriod(s.KeepAlivePeriod.Duration)
+	return tcpConn.SetKeepAlivePeriod(s.KeepAlivePeriod.Duration)
 }
 
 func (s *Syslog) store(res syslog.Result, acc telegraf.Accumulator) {
@@ -372,8 +418,10 @@ func tags(msg syslog.Message) map[string]string {
 
 func fields(msg syslog.Message, s *Syslog) map[string]interface{} {
 	// Not checking assuming a minimally valid message
-	flds := map[string]interface{}{
-		"version": msg.Version(),
+	flds := map[string]interface{}{}
+	// RFC3164 messages have no version
+	if msg.Version() > 0 {
+		flds["version"] = msg.Version()
 	}
 	flds["severity_code"] = int(*msg.Severity())
 	flds["facility_code"] = int(*msg.Facility())
@@ -444,9 +492,11 @@ func init() {
 		ReadTimeout: &internal.Duration{
 			Duration: defaultReadTimeout,
 		},
-		Framing:   OctetCounting,
-		Trailer:   nontransparent.LF,
-		Separator: "_",
+		Framing:        framing.OctetCounting,
+		Trailer:        nontransparent.LF,
+		Separator:      "_",
+		SyslogStandard: rfc5424Standard,
+		Timezone:       "UTC",
 	}
 
 	inputs.Add("syslog", func() telegraf.Input { return receiver })
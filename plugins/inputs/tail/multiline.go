@@ -0,0 +1,104 @@
+// +build !solaris
+
+package tail
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+const (
+	// matchPrevious joins lines matching the pattern to the previous line.
+	matchPrevious = "previous"
+	// matchNext joins lines matching the pattern to the next line.
+	matchNext = "next"
+
+	defaultMultilineTimeout = 5 * time.Second
+)
+
+// MultilineConfig configures how lines are joined into multiline events.
+type MultilineConfig struct {
+	Pattern        string             `toml:"pattern"`
+	MatchWhichLine string             `toml:"match_which_line"`
+	InvertMatch    bool               `toml:"invert_match"`
+	Timeout        *internal.Duration `toml:"timeout"`
+}
+
+// multiline joins lines into events according to its configuration.
+type multiline struct {
+	pattern     *regexp.Regexp
+	matchNext   bool
+	invertMatch bool
+	timeout     time.Duration
+}
+
+func newMultiline(config *MultilineConfig) (*multiline, error) {
+	pattern, err := regexp.Compile(config.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multiline pattern %q: %v", config.Pattern, err)
+	}
+
+	m := &multiline{
+		pattern:     pattern,
+		invertMatch: config.InvertMatch,
+		timeout:     defaultMultilineTimeout,
+	}
+
+	switch config.MatchWhichLine {
+	case "", matchPrevious:
+	case matchNext:
+		m.matchNext = true
+	default:
+		return nil, fmt.Errorf("invalid multiline match_which_line %q, must be %q or %q",
+			config.MatchWhichLine, matchPrevious, matchNext)
+	}
+
+	if config.Timeout != nil {
+		m.timeout = config.Timeout.Duration
+	}
+	return m, nil
+}
+
+// processLine adds the line to the event in the buffer.  If this completes
+// an event, the event is returned and the buffer holds the start of the next
+// event, if any.
+func (m *multiline) processLine(text string, buffer *bytes.Buffer) (string, bool) {
+	if m.pattern.MatchString(text) != m.invertMatch {
+		// The line is joined with the adjacent lines.
+		appendLine(buffer, text)
+		return "", false
+	}
+
+	if m.matchNext {
+		// The line is the last line of the event.
+		appendLine(buffer, text)
+		return flush(buffer), true
+	}
+
+	// The line is the first line of the next event.
+	if buffer.Len() == 0 {
+		buffer.WriteString(text)
+		return "", false
+	}
+	event := flush(buffer)
+	buffer.WriteString(text)
+	return event, true
+}
+
+func appendLine(buffer *bytes.Buffer, text string) {
+	if buffer.Len() > 0 {
+		buffer.WriteByte('\n')
+	}
+	buffer.WriteString(text)
+}
+
+// flush returns the event in the buffer and empties it.
+func flush(buffer *bytes.Buffer) string {
+	event := buffer.String()
+	buffer.Reset()
+	return event
+}
@@ -0,0 +1,94 @@
+package tail
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultilineConfig(t *testing.T) {
+	_, err := newMultiline(&MultilineConfig{Pattern: "^\\s"})
+	require.NoError(t, err)
+
+	_, err = newMultiline(&MultilineConfig{Pattern: "^(\\s"})
+	require.Error(t, err)
+
+	_, err = newMultiline(&MultilineConfig{Pattern: "^\\s", MatchWhichLine: "last"})
+	require.Error(t, err)
+}
+
+func TestMultilineProcessLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   MultilineConfig
+		lines    []string
+		expected []string
+		buffered string
+	}{
+		{
+			name:   "previous",
+			config: MultilineConfig{Pattern: "^\\s"},
+			lines: []string{
+				"Exception in thread \"main\" java.lang.NullPointerException",
+				"    at Book.getTitle(Book.java:16)",
+				"    at Author.getBookTitles(Author.java:25)",
+				"second event",
+				"third event",
+				"    continued",
+			},
+			expected: []string{
+				"Exception in thread \"main\" java.lang.NullPointerException\n" +
+					"    at Book.getTitle(Book.java:16)\n" +
+					"    at Author.getBookTitles(Author.java:25)",
+				"second event",
+			},
+			buffered: "third event\n    continued",
+		},
+		{
+			name:   "previous inverted",
+			config: MultilineConfig{Pattern: "^\\[", InvertMatch: true},
+			lines: []string{
+				"[2019-01-01] first",
+				"details",
+				"[2019-01-01] second",
+			},
+			expected: []string{
+				"[2019-01-01] first\ndetails",
+			},
+			buffered: "[2019-01-01] second",
+		},
+		{
+			name:   "next",
+			config: MultilineConfig{Pattern: "\\\\$", MatchWhichLine: "next"},
+			lines: []string{
+				"first \\",
+				"line",
+				"second",
+				"third \\",
+			},
+			expected: []string{
+				"first \\\nline",
+				"second",
+			},
+			buffered: "third \\",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newMultiline(&tt.config)
+			require.NoError(t, err)
+
+			var buffer bytes.Buffer
+			var events []string
+			for _, line := range tt.lines {
+				if event, ok := m.processLine(line, &buffer); ok {
+					events = append(events, event)
+				}
+			}
+			require.Equal(t, tt.expected, events)
+			require.Equal(t, tt.buffered, buffer.String())
+		})
+	}
+}
@@ -3,40 +3,74 @@
 package tail
 
 import (
+	"bytes"
 	"fmt"
 	"log"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/influxdata/tail"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/delivery"
+	"github.com/influxdata/telegraf/internal/encoding"
 	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/internal/offsets"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 const (
-	defaultWatchMethod = "inotify"
+	defaultWatchMethod         = "inotify"
+	defaultMaxUndeliveredLines = 1000
 )
 
+type empty struct{}
+type semaphore chan empty
+
 type Tail struct {
-	Files         []string
-	FromBeginning bool
-	Pipe          bool
-	WatchMethod   string
+	Files               []string
+	FromBeginning       bool
+	Pipe                bool
+	WatchMethod         string
+	CharacterEncoding   string `toml:"character_encoding"`
+	StateFile           string `toml:"state_file"`
+	MaxUndeliveredLines int    `toml:"max_undelivered_lines"`
+
+	Multiline *MultilineConfig `toml:"multiline"`
 
 	tailers    map[string]*tail.Tail
+	multiline  *multiline
+	encoding   *encoding.Encoding
+	offsets    *offsets.Store
 	parserFunc parsers.ParserFunc
 	wg         sync.WaitGroup
 	acc        telegraf.Accumulator
+	done       chan struct{}
+
+	// With a state file, the metrics are added with tracking and pending
+	// holds the line of each undelivered metric.
+	tracking    telegraf.TrackingAccumulator
+	sem         semaphore
+	pending     map[telegraf.TrackingID]pendingLine
+	pendingLock sync.Mutex
 
 	sync.Mutex
 }
 
+// pendingLine is a line, or multiline event, of a file whose metric is not
+// yet delivered.
+type pendingLine struct {
+	progress *delivery.Progress
+	seq      uint64
+}
+
 func NewTail() *Tail {
 	return &Tail{
-		FromBeginning: false,
+		FromBeginning:       false,
+		MaxUndeliveredLines: defaultMaxUndeliveredLines,
 	}
 }
 
@@ -59,11 +93,48 @@ const sampleConfig = `
   ## Method used to watch for file updates.  Can be either "inotify" or "poll".
   # watch_method = "inotify"
 
+  ## Character encoding of the files, the lines are decoded to UTF-8 before
+  ## parsing.  A byte order mark at the start of a file overrides the unicode
+  ## encodings.  When unset the lines are parsed as is.
+  ##   Supported: utf-8, utf-16le, utf-16be, iso-8859-1
+  # character_encoding = ""
+
+  ## File to store the offsets read up to in each file, so that reading is
+  ## resumed there when telegraf is restarted.  The offset of a line is only
+  ## stored once its metrics have been written by the outputs.  Files without
+  ## a stored offset are read according to from_beginning.
+  # state_file = "/var/lib/telegraf/tail.state"
+
+  ## Maximum lines of the file to process that have not yet been written by
+  ## the output, only limited when a state_file is set.  For best throughput
+  ## set based on the number of metrics on each line and the size of the
+  ## output's metric_batch_size.
+  # max_undelivered_lines = 1000
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
   data_format = "influx"
+
+  ## Join lines into multiline events, such as stack traces, before parsing
+  ## them.  The lines of an event are joined with a newline.
+  # [inputs.tail.multiline]
+    ## Regular expression matching the lines joined with the adjacent lines,
+    ## for example lines starting with whitespace.
+    # pattern = '^\s'
+
+    ## Either "previous" or "next".  With "previous" a matching line belongs
+    ## to the event of the previous line, with "next" it belongs to the event
+    ## of the next line.
+    # match_which_line = "previous"
+
+    ## If true, the lines not matching the pattern are joined instead.
+    # invert_match = false
+
+    ## Maximum time to wait for the next line before an incomplete event is
+    ## parsed as is.
+    # timeout = "5s"
 `
 
 func (t *Tail) SampleConfig() string {
@@ -78,28 +149,71 @@ func (t *Tail) Gather(acc telegraf.Accumulator) error {
 	t.Lock()
 	defer t.Unlock()
 
+	if t.offsets != nil {
+		if err := t.offsets.Save(); err != nil {
+			acc.AddError(fmt.Errorf("E! Error saving state file %s: %v", t.StateFile, err))
+		}
+	}
+
 	return t.tailNewFiles(true)
 }
 
+// Init checks that files are set, compiles the multiline pattern and looks up
+// the character encoding.
+func (t *Tail) Init() error {
+	if len(t.Files) == 0 {
+		return fmt.Errorf("files is required")
+	}
+
+	var err error
+	if t.Multiline != nil {
+		t.multiline, err = newMultiline(t.Multiline)
+		if err != nil {
+			return err
+		}
+	}
+	t.encoding, err = encoding.Lookup(t.CharacterEncoding)
+	return err
+}
+
 func (t *Tail) Start(acc telegraf.Accumulator) error {
 	t.Lock()
 	defer t.Unlock()
 
+	if err := t.Init(); err != nil {
+		return err
+	}
+
 	t.acc = acc
+	t.done = make(chan struct{})
 	t.tailers = make(map[string]*tail.Tail)
 
-	return t.tailNewFiles(t.FromBeginning)
-}
+	// The offsets of pipes are not known.
+	if t.StateFile != "" && !t.Pipe {
+		var err error
+		t.offsets, err = offsets.Load(t.StateFile)
+		if err != nil {
+			return fmt.Errorf("could not load state file %s: %v", t.StateFile, err)
+		}
 
-func (t *Tail) tailNewFiles(fromBeginning bool) error {
-	var seek *tail.SeekInfo
-	if !t.Pipe && !fromBeginning {
-		seek = &tail.SeekInfo{
-			Whence: 2,
-			Offset: 0,
+		if t.MaxUndeliveredLines <= 0 {
+			t.MaxUndeliveredLines = defaultMaxUndeliveredLines
 		}
+		t.tracking = acc.WithTracking(t.MaxUndeliveredLines)
+		t.sem = make(semaphore, t.MaxUndeliveredLines)
+		t.pending = make(map[telegraf.TrackingID]pendingLine)
+
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.deliveries()
+		}()
 	}
 
+	return t.tailNewFiles(t.FromBeginning)
+}
+
+func (t *Tail) tailNewFiles(fromBeginning bool) error {
 	var poll bool
 	if t.WatchMethod == "poll" {
 		poll = true
@@ -117,6 +231,12 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 				continue
 			}
 
+			seek, pos, err := t.position(file, fromBeginning)
+			if err != nil {
+				t.acc.AddError(err)
+				continue
+			}
+
 			tailer, err := tail.TailFile(file,
 				tail.Config{
 					ReOpen:    true,
@@ -139,61 +259,188 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 				t.acc.AddError(fmt.Errorf("error creating parser: %v", err))
 			}
 
+			var decoder *encoding.LineDecoder
+			if t.encoding != nil {
+				enc := t.encoding
+				fromStart := seek == nil || (seek.Whence == 0 && seek.Offset == 0)
+				if !fromStart {
+					// The byte order mark at the start is not read.
+					enc = enc.Detect(peekFile(file, 0, 3))
+				}
+				decoder = enc.NewLineDecoder(fromStart)
+			}
+
 			// create a goroutine for each "tailer"
 			t.wg.Add(1)
-			go t.receiver(parser, tailer)
+			go t.receiver(parser, tailer, decoder, pos)
 			t.tailers[tailer.Filename] = tailer
 		}
 	}
 	return nil
 }
 
+// position returns where to start tailing the file.  Without a state file,
+// or for pipes, this is the start or the end of the file.  Otherwise it is
+// the stored position of the file, if there is one.  The returned position is
+// not known for pipes.
+func (t *Tail) position(file string, fromBeginning bool) (*tail.SeekInfo, offsets.Position, error) {
+	if t.Pipe {
+		return nil, offsets.Position{}, nil
+	}
+
+	if t.offsets == nil {
+		if fromBeginning {
+			return nil, offsets.Position{Path: file}, nil
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, offsets.Position{}, err
+		}
+		return &tail.SeekInfo{Whence: 0, Offset: info.Size()}, offsets.Position{Path: file, Offset: info.Size()}, nil
+	}
+
+	pos, err := t.offsets.Lookup(file, !fromBeginning)
+	if err != nil {
+		return nil, pos, err
+	}
+	return &tail.SeekInfo{Whence: 0, Offset: pos.Offset}, pos, nil
+}
+
 // this is launched as a goroutine to continuously watch a tailed logfile
 // for changes, parse any incoming msgs, and add to the accumulator.
-func (t *Tail) receiver(parser parsers.Parser, tailer *tail.Tail) {
+func (t *Tail) receiver(parser parsers.Parser, tailer *tail.Tail, decoder *encoding.LineDecoder, pos offsets.Position) {
 	defer t.wg.Done()
 
 	var firstLine = true
-	var metrics []telegraf.Metric
-	var m telegraf.Metric
-	var err error
-	var line *tail.Line
-	for line = range tailer.Lines {
+
+	// offset is the position in the file after the text read.
+	offset := pos.Offset
+
+	// With a state file, the file read is followed through truncation and
+	// rotation, and progress tracks the position reached by the lines
+	// delivered.
+	var file *offsets.File
+	var progress *delivery.Progress
+	if t.offsets != nil {
+		var err error
+		file, err = offsets.OpenFile(tailer.Filename)
+		if err != nil {
+			t.acc.AddError(fmt.Errorf("E! Error opening file %s, Error: %s\n",
+				tailer.Filename, err))
+		} else {
+			defer file.Close()
+		}
+		progress = delivery.NewProgress()
+	}
+
+	// buffer holds the lines of the multiline event being read, which end at
+	// bufferPos in the file.
+	var buffer bytes.Buffer
+	var bufferPos offsets.Position
+
+	// timeout fires if the buffered event is not completed by a new line in
+	// time.
+	var timer *time.Timer
+	var timeout <-chan time.Time
+
+	for {
+		var line *tail.Line
+		var ok bool
+		select {
+		case line, ok = <-tailer.Lines:
+		case <-timeout:
+			timeout = nil
+			t.parse(parser, progress, tailer.Filename, flush(&buffer), bufferPos, firstLine)
+			firstLine = false
+			continue
+		}
+		if !ok {
+			break
+		}
+
 		if line.Err != nil {
 			t.acc.AddError(fmt.Errorf("E! Error tailing file %s, Error: %s\n",
-				tailer.Filename, err))
+				tailer.Filename, line.Err))
 			continue
 		}
-		// Fix up files with Windows line endings.
-		text := strings.TrimRight(line.Text, "\r")
-
-		if firstLine {
-			metrics, err = parser.Parse([]byte(text))
-			if err == nil {
-				if len(metrics) == 0 {
-					firstLine = false
-					continue
-				} else {
-					m = metrics[0]
+
+		// The line is followed by a newline, which is not part of the text.
+		n := int64(len(line.Text)) + 1
+		if file != nil && file.Reopened(offset, n) {
+			// The file was truncated or replaced, so the line is at the start
+			// of the file read from now on.  A buffered event ends with the
+			// previous file.
+			if buffer.Len() > 0 {
+				t.parse(parser, progress, tailer.Filename, flush(&buffer), bufferPos, firstLine)
+				firstLine = false
+			}
+			offset = 0
+			pos = offsets.Position{Path: pos.Path, Inode: file.Inode()}
+			if t.encoding != nil {
+				decoder = t.encoding.NewLineDecoder(true)
+			}
+		}
+		offset += n
+
+		lines, err := t.decode(decoder, tailer.Filename, line.Text, offset)
+		if err != nil {
+			t.acc.AddError(fmt.Errorf("E! Error decoding file %s, Error: %s\n",
+				tailer.Filename, err))
+		}
+
+		for _, l := range lines {
+			pos.Offset += int64(l.Size)
+
+			// Fix up files with Windows line endings.
+			text := strings.TrimRight(l.Text, "\r")
+
+			if t.multiline == nil {
+				t.parse(parser, progress, tailer.Filename, text, pos, firstLine)
+				firstLine = false
+				continue
+			}
+
+			eventPos := bufferPos
+			bufferPos = pos
+			if event, ok := t.multiline.processLine(text, &buffer); ok {
+				if t.multiline.matchNext {
+					eventPos = pos
 				}
+				t.parse(parser, progress, tailer.Filename, event, eventPos, firstLine)
+				firstLine = false
 			}
-			firstLine = false
-		} else {
-			m, err = parser.ParseLine(text)
 		}
 
-		if err == nil {
-			if m != nil {
-				tags := m.Tags()
-				tags["path"] = tailer.Filename
-				t.acc.AddFields(m.Name(), m.Fields(), tags, m.Time())
+		if t.multiline == nil {
+			continue
+		}
+
+		timeout = nil
+		if buffer.Len() > 0 && t.multiline.timeout > 0 {
+			if timer == nil {
+				timer = time.NewTimer(t.multiline.timeout)
+			} else {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(t.multiline.timeout)
 			}
-		} else {
-			t.acc.AddError(fmt.Errorf("E! Malformed log line in %s: [%s], Error: %s\n",
-				tailer.Filename, line.Text, err))
+			timeout = timer.C
 		}
 	}
 
+	if timer != nil {
+		timer.Stop()
+	}
+
+	// The file is no longer read, so the buffered event is complete.
+	if buffer.Len() > 0 {
+		t.parse(parser, progress, tailer.Filename, flush(&buffer), bufferPos, firstLine)
+	}
+
 	log.Printf("D! [inputs.tail] tail removed for file: %v", tailer.Filename)
 
 	if err := tailer.Err(); err != nil {
@@ -202,10 +449,142 @@ func (t *Tail) receiver(parser parsers.Parser, tailer *tail.Tail) {
 	}
 }
 
+// decode returns the lines completed by the text read up to a newline byte
+// ending at offset in the file.  Without a decoder this is the text itself.
+func (t *Tail) decode(decoder *encoding.LineDecoder, filename string, text string, offset int64) ([]encoding.Line, error) {
+	if decoder == nil {
+		// The line is followed by a newline, which is not part of the text.
+		return []encoding.Line{{Text: text, Size: len(text) + 1}}, nil
+	}
+
+	var peek func(n int) []byte
+	if !t.Pipe {
+		peek = func(n int) []byte {
+			return peekFile(filename, offset, n)
+		}
+	}
+	return decoder.Decode([]byte(text+"\n"), peek)
+}
+
+// peekFile returns up to n bytes of the file at the offset.
+func peekFile(filename string, offset int64, n int) []byte {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	buf := make([]byte, n)
+	n, _ = f.ReadAt(buf, offset)
+	return buf[:n]
+}
+
+// parse parses the text of a line, or of a multiline event, ending at pos in
+// the file and adds the resulting metric.  With a state file the metric is
+// added with tracking, so that the offset of the file advances once it is
+// delivered.
+func (t *Tail) parse(parser parsers.Parser, progress *delivery.Progress, filename string, text string, pos offsets.Position, firstLine bool) {
+	var m telegraf.Metric
+	var err error
+	if firstLine {
+		var metrics []telegraf.Metric
+		metrics, err = parser.Parse([]byte(text))
+		if err == nil && len(metrics) > 0 {
+			m = metrics[0]
+		}
+	} else {
+		m, err = parser.ParseLine(text)
+	}
+
+	if err != nil {
+		t.acc.AddError(fmt.Errorf("E! Malformed log line in %s: [%s], Error: %s\n",
+			filename, text, err))
+	}
+
+	if err != nil || m == nil {
+		// There is nothing to deliver, the line is done.
+		if progress != nil {
+			t.advance(progress, progress.Add(pos), true)
+		}
+		return
+	}
+
+	m.AddTag("path", filename)
+
+	if progress == nil {
+		t.acc.AddMetric(m)
+		return
+	}
+
+	select {
+	case t.sem <- empty{}:
+		t.addMetric(m, progress, pos)
+	case <-t.done:
+		// Stopping, the remaining lines are read again after a restart.
+	}
+}
+
+// addMetric adds the metric for tracking, remembering its line to advance
+// the offset of the file once it is delivered.
+func (t *Tail) addMetric(m telegraf.Metric, progress *delivery.Progress, pos offsets.Position) {
+	t.pendingLock.Lock()
+	defer t.pendingLock.Unlock()
+
+	id := t.tracking.AddTrackingMetricGroup([]telegraf.Metric{m})
+	t.pending[id] = pendingLine{progress: progress, seq: progress.Add(pos)}
+}
+
+// advance marks the line as done, storing the offset of its file if reached.
+func (t *Tail) advance(progress *delivery.Progress, seq uint64, delivered bool) {
+	if pos, ok := progress.Done(seq, delivered); ok {
+		t.offsets.Update(pos.(offsets.Position))
+	}
+}
+
+// deliveries is launched as a goroutine to advance the offsets of the files
+// when their metrics are delivered.
+func (t *Tail) deliveries() {
+	for {
+		select {
+		case <-t.done:
+			return
+		case info := <-t.tracking.Delivered():
+			<-t.sem
+			t.onDelivery(info)
+		}
+	}
+}
+
+// drainDeliveries handles the deliveries already waiting.
+func (t *Tail) drainDeliveries() {
+	for {
+		select {
+		case info := <-t.tracking.Delivered():
+			<-t.sem
+			t.onDelivery(info)
+		default:
+			return
+		}
+	}
+}
+
+func (t *Tail) onDelivery(info telegraf.DeliveryInfo) {
+	t.pendingLock.Lock()
+	line, ok := t.pending[info.ID()]
+	delete(t.pending, info.ID())
+	t.pendingLock.Unlock()
+
+	if ok {
+		t.advance(line.progress, line.seq, info.Delivered())
+	}
+}
+
 func (t *Tail) Stop() {
 	t.Lock()
 	defer t.Unlock()
 
+	close(t.done)
+
 	for _, tailer := range t.tailers {
 		err := tailer.Stop()
 		if err != nil {
@@ -217,6 +596,15 @@ func (t *Tail) Stop() {
 		tailer.Cleanup()
 	}
 	t.wg.Wait()
+
+	if t.offsets != nil {
+		// Store the offsets of the metrics delivered while stopping.
+		t.drainDeliveries()
+
+		if err := t.offsets.Save(); err != nil {
+			log.Printf("E! [inputs.tail] Error saving state file %s: %v", t.StateFile, err)
+		}
+	}
 }
 
 func (t *Tail) SetParserFunc(fn parsers.ParserFunc) {
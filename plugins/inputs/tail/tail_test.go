@@ -3,14 +3,20 @@ package tail
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
 )
 
 func TestTailFromBeginning(t *testing.T) {
@@ -139,3 +145,347 @@ func TestTailDosLineendings(t *testing.T) {
 			"usage_idle": float64(200),
 		})
 }
+
+func TestTailStateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tail")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logfile := filepath.Join(dir, "test.log")
+	statefile := filepath.Join(dir, "tail.state")
+	err = ioutil.WriteFile(logfile, []byte("cpu value=1\ncpu value=2\n"), 0644)
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.FromBeginning = true
+	tt.StateFile = statefile
+	tt.Files = []string{logfile}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+
+	acc := testutil.AcceptingAccumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(2)
+	tt.Stop()
+
+	f, err := os.OpenFile(logfile, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("cpu value=3\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// Reading resumes after the delivered lines.
+	tt = NewTail()
+	tt.FromBeginning = true
+	tt.StateFile = statefile
+	tt.Files = []string{logfile}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+
+	acc = testutil.AcceptingAccumulator{}
+	require.NoError(t, tt.Start(&acc))
+	defer tt.Stop()
+	acc.Wait(1)
+
+	require.Equal(t, map[string]interface{}{"value": float64(3)}, acc.Metrics[0].Fields)
+}
+
+func TestTailStateFileUndelivered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tail")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logfile := filepath.Join(dir, "test.log")
+	statefile := filepath.Join(dir, "tail.state")
+	err = ioutil.WriteFile(logfile, []byte("cpu value=1\n"), 0644)
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.FromBeginning = true
+	tt.StateFile = statefile
+	tt.Files = []string{logfile}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+
+	// The metric is never delivered, so the offset is not advanced.
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(1)
+	tt.Stop()
+
+	tt = NewTail()
+	tt.FromBeginning = true
+	tt.StateFile = statefile
+	tt.Files = []string{logfile}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+
+	acc = testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	defer tt.Stop()
+	acc.Wait(1)
+
+	require.Equal(t, map[string]interface{}{"value": float64(1)}, acc.Metrics[0].Fields)
+}
+
+func newStateFileTail(logfile string, statefile string) *Tail {
+	tt := NewTail()
+	tt.FromBeginning = true
+	tt.StateFile = statefile
+	tt.Files = []string{logfile}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+	return tt
+}
+
+func appendFile(t *testing.T, filename string, text string) {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(text)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func TestTailStateFileRejected(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tail")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logfile := filepath.Join(dir, "test.log")
+	statefile := filepath.Join(dir, "tail.state")
+	err = ioutil.WriteFile(logfile, []byte("cpu value=1\ncpu value=2\ncpu value=3\n"), 0644)
+	require.NoError(t, err)
+
+	tt := newStateFileTail(logfile, statefile)
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, tt.Start(acc))
+
+	// The offset does not advance past the rejected second line, although
+	// the third line is delivered.
+	(<-acc.Tracked).Accept()
+	(<-acc.Tracked).Reject()
+	(<-acc.Tracked).Accept()
+	tt.Stop()
+
+	tt = newStateFileTail(logfile, statefile)
+	acc2 := testutil.AcceptingAccumulator{}
+	require.NoError(t, tt.Start(&acc2))
+	defer tt.Stop()
+	acc2.Wait(2)
+
+	require.Equal(t, map[string]interface{}{"value": float64(2)}, acc2.Metrics[0].Fields)
+	require.Equal(t, map[string]interface{}{"value": float64(3)}, acc2.Metrics[1].Fields)
+}
+
+func TestTailStateFileRotation(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test renaming a tailed file on windows")
+	}
+
+	tests := []struct {
+		name   string
+		rotate func(t *testing.T, logfile string)
+	}{
+		{
+			name: "copytruncate",
+			rotate: func(t *testing.T, logfile string) {
+				require.NoError(t, os.Truncate(logfile, 0))
+				appendFile(t, logfile, "cpu value=3\n")
+			},
+		},
+		{
+			name: "rename",
+			rotate: func(t *testing.T, logfile string) {
+				require.NoError(t, os.Rename(logfile, logfile+".1"))
+				require.NoError(t, ioutil.WriteFile(logfile, []byte("cpu value=3\n"), 0644))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tail")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			logfile := filepath.Join(dir, "test.log")
+			statefile := filepath.Join(dir, "tail.state")
+			err = ioutil.WriteFile(logfile, []byte("cpu value=1\ncpu value=2\n"), 0644)
+			require.NoError(t, err)
+
+			plugin := newStateFileTail(logfile, statefile)
+			plugin.WatchMethod = "poll"
+			acc := testutil.AcceptingAccumulator{}
+			require.NoError(t, plugin.Start(&acc))
+			acc.Wait(2)
+
+			// Give the tailer the time to poll the file once read to its
+			// end, it misses a rotation happening before.
+			time.Sleep(500 * time.Millisecond)
+			tt.rotate(t, logfile)
+			acc.Wait(3)
+			plugin.Stop()
+			require.Equal(t, map[string]interface{}{"value": float64(3)}, acc.Metrics[2].Fields)
+
+			// Reading resumes after the line read from the rotated file.
+			appendFile(t, logfile, "cpu value=4\n")
+			plugin = newStateFileTail(logfile, statefile)
+			acc = testutil.AcceptingAccumulator{}
+			require.NoError(t, plugin.Start(&acc))
+			defer plugin.Stop()
+			acc.Wait(1)
+
+			require.Equal(t, map[string]interface{}{"value": float64(4)}, acc.Metrics[0].Fields)
+		})
+	}
+}
+
+func TestTailNoStateFileNotTracked(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString("cpu value=1\ncpu value=2\ncpu value=3\n")
+	require.NoError(t, err)
+	require.NoError(t, tmpfile.Close())
+
+	tt := NewTail()
+	tt.FromBeginning = true
+	tt.MaxUndeliveredLines = 1
+	tt.Files = []string{tmpfile.Name()}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+
+	// Without a state file, reading does not wait for the metrics to be
+	// delivered.
+	acc := testutil.NewDeliveryAccumulator()
+	require.NoError(t, tt.Start(acc))
+	defer tt.Stop()
+	acc.Wait(3)
+	require.Len(t, acc.Tracked, 0)
+}
+
+func TestTailMultiline(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString(
+		"Exception in thread \"main\" java.lang.NullPointerException\n" +
+			"    at Book.getTitle(Book.java:16)\n" +
+			"    at Author.getBookTitles(Author.java:25)\n" +
+			"done\n")
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.FromBeginning = true
+	tt.Files = []string{tmpfile.Name()}
+	tt.Multiline = &MultilineConfig{
+		Pattern: `^\s`,
+		Timeout: &internal.Duration{Duration: 100 * time.Millisecond},
+	}
+	tt.SetParserFunc(func() (parsers.Parser, error) {
+		return parsers.NewValueParser("log", "string", nil)
+	})
+	defer tt.Stop()
+	defer tmpfile.Close()
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+
+	// The last event is parsed once the timeout expired.
+	acc.Wait(2)
+	require.Equal(t,
+		"Exception in thread \"main\" java.lang.NullPointerException\n"+
+			"    at Book.getTitle(Book.java:16)\n"+
+			"    at Author.getBookTitles(Author.java:25)",
+		acc.Metrics[0].Fields["value"])
+	require.Equal(t, "done", acc.Metrics[1].Fields["value"])
+}
+
+func TestTailCharacterEncoding(t *testing.T) {
+	tests := []struct {
+		name              string
+		file              string
+		characterEncoding string
+		encoding          encoding.Encoding
+	}{
+		{
+			name:              "utf-16le with byte order mark",
+			file:              "testdata/utf16le_bom.log",
+			characterEncoding: "utf-16le",
+			encoding:          unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+		},
+		{
+			name:              "byte order mark overrides utf-16be",
+			file:              "testdata/utf16le_bom.log",
+			characterEncoding: "utf-16be",
+			encoding:          unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+		},
+		{
+			name:              "utf-16be",
+			file:              "testdata/utf16be.log",
+			characterEncoding: "utf-16be",
+			encoding:          unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+		},
+		{
+			name:              "iso-8859-1",
+			file:              "testdata/latin1.log",
+			characterEncoding: "iso-8859-1",
+			encoding:          charmap.ISO8859_1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tail")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			contents, err := ioutil.ReadFile(tt.file)
+			require.NoError(t, err)
+			logfile := filepath.Join(dir, "test.log")
+			statefile := filepath.Join(dir, "tail.state")
+			require.NoError(t, ioutil.WriteFile(logfile, contents, 0644))
+
+			newTail := func() *Tail {
+				plugin := NewTail()
+				plugin.FromBeginning = true
+				plugin.CharacterEncoding = tt.characterEncoding
+				plugin.StateFile = statefile
+				plugin.Files = []string{logfile}
+				plugin.SetParserFunc(parsers.NewInfluxParser)
+				return plugin
+			}
+
+			plugin := newTail()
+			acc := testutil.AcceptingAccumulator{}
+			require.NoError(t, plugin.Start(&acc))
+			acc.Wait(2)
+			plugin.Stop()
+
+			for i, value := range []float64{1, 2} {
+				require.Equal(t, map[string]string{"host": "ünïcode", "path": logfile}, acc.Metrics[i].Tags)
+				require.Equal(t, map[string]interface{}{"value": value}, acc.Metrics[i].Fields)
+			}
+
+			line, err := tt.encoding.NewEncoder().String("cpu,host=ünïcode value=3\n")
+			require.NoError(t, err)
+			f, err := os.OpenFile(logfile, os.O_APPEND|os.O_WRONLY, 0644)
+			require.NoError(t, err)
+			_, err = f.WriteString(line)
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			// Reading resumes after the decoded lines.
+			plugin = newTail()
+			acc = testutil.AcceptingAccumulator{}
+			require.NoError(t, plugin.Start(&acc))
+			defer plugin.Stop()
+			acc.Wait(1)
+
+			require.Equal(t, map[string]string{"host": "ünïcode", "path": logfile}, acc.Metrics[0].Tags)
+			require.Equal(t, map[string]interface{}{"value": float64(3)}, acc.Metrics[0].Fields)
+		})
+	}
+}
+
+func TestTailInitInvalid(t *testing.T) {
+	tt := NewTail()
+	require.Error(t, tt.Init())
+
+	tt = NewTail()
+	tt.Files = []string{"/var/log/syslog"}
+	tt.CharacterEncoding = "utf-7"
+	require.Error(t, tt.Init())
+}
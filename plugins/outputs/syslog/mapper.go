@@ -0,0 +1,190 @@
+package syslog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/go-syslog/rfc5424"
+	"github.com/influxdata/telegraf"
+)
+
+// reservedKeys are the tags and fields mapped to the header of the message,
+// which are not added to the structured data.
+var reservedKeys = map[string]bool{
+	"version":       true,
+	"severity_code": true,
+	"facility_code": true,
+	"severity":      true,
+	"facility":      true,
+	"timestamp":     true,
+	"hostname":      true,
+	"source":        true,
+	"host":          true,
+	"appname":       true,
+	"procid":        true,
+	"msgid":         true,
+	"message":       true,
+}
+
+// paramValueEscaper escapes the characters not allowed in an SD-PARAM value
+// (RFC5424#section-6.3.3), as expected by the message builder.
+var paramValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// mapMetric maps the metric to a syslog message.  The tags and fields are
+// named as the ones produced by the syslog input.
+func (s *Syslog) mapMetric(metric telegraf.Metric) (*rfc5424.SyslogMessage, error) {
+	msg := &rfc5424.SyslogMessage{}
+
+	s.mapPriority(metric, msg)
+	s.mapStructuredData(metric, msg)
+
+	msg.SetVersion(1)
+	if value, ok := metric.GetField("version"); ok {
+		if v, ok := toUint(value); ok && v > 0 && v <= 999 {
+			msg.SetVersion(uint16(v))
+		}
+	}
+
+	timestamp := metric.Time()
+	if value, ok := metric.GetField("timestamp"); ok {
+		if v, ok := value.(int64); ok {
+			timestamp = time.Unix(0, v)
+		}
+	}
+	msg.SetTimestamp(timestamp.UTC().Format("2006-01-02T15:04:05.999999Z07:00"))
+
+	msg.SetHostname(s.hostname)
+	for _, key := range []string{"hostname", "source", "host"} {
+		if value, ok := metric.GetTag(key); ok {
+			msg.SetHostname(value)
+			break
+		}
+	}
+
+	if value, ok := metric.GetTag("appname"); ok {
+		msg.SetAppname(value)
+	} else {
+		msg.SetAppname(s.DefaultAppname)
+	}
+
+	if value, ok := getString(metric, "procid"); ok {
+		msg.SetProcID(value)
+	}
+	if value, ok := getString(metric, "msgid"); ok {
+		msg.SetMsgID(value)
+	}
+	if value, ok := metric.GetField("message"); ok {
+		msg.SetMessage(formatValue(value))
+	}
+
+	if !msg.Valid() {
+		return nil, fmt.Errorf("metric could not produce a valid syslog message")
+	}
+	return msg, nil
+}
+
+func (s *Syslog) mapPriority(metric telegraf.Metric, msg *rfc5424.SyslogMessage) {
+	severity := s.DefaultSeverityCode
+	if value, ok := metric.GetField("severity_code"); ok {
+		if v, ok := toUint(value); ok && v <= 7 {
+			severity = uint8(v)
+		}
+	}
+
+	facility := s.DefaultFacilityCode
+	if value, ok := metric.GetField("facility_code"); ok {
+		if v, ok := toUint(value); ok && v <= 23 {
+			facility = uint8(v)
+		}
+	}
+
+	msg.SetPriority(facility*8 + severity)
+}
+
+// mapStructuredData adds the tags and fields not mapped to the header as
+// parameters.  Keys prefixed with one of the configured SD-IDs and the
+// separator belong to that element, all others to the default element.  A
+// boolean field named as a configured SD-ID adds the element without
+// parameters, as produced by the syslog input.
+func (s *Syslog) mapStructuredData(metric telegraf.Metric, msg *rfc5424.SyslogMessage) {
+	for _, tag := range metric.TagList() {
+		s.mapStructuredDataItem(tag.Key, tag.Value, msg)
+	}
+	for _, field := range metric.FieldList() {
+		if v, ok := field.Value.(bool); ok && v && s.isSdid(field.Key) {
+			msg.SetElementID(field.Key)
+			continue
+		}
+		s.mapStructuredDataItem(field.Key, formatValue(field.Value), msg)
+	}
+}
+
+func (s *Syslog) mapStructuredDataItem(key string, value string, msg *rfc5424.SyslogMessage) {
+	if reservedKeys[key] {
+		return
+	}
+	value = paramValueEscaper.Replace(value)
+
+	for _, sdid := range s.Sdids {
+		prefix := sdid + s.Separator
+		if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+			msg.SetParameter(sdid, key[len(prefix):], value)
+			return
+		}
+	}
+
+	if s.DefaultSdid != "" {
+		msg.SetParameter(s.DefaultSdid, key, value)
+	}
+}
+
+func (s *Syslog) isSdid(key string) bool {
+	for _, sdid := range s.Sdids {
+		if key == sdid {
+			return true
+		}
+	}
+	return false
+}
+
+// getString returns the tag, or else the field, with the key.
+func getString(metric telegraf.Metric, key string) (string, bool) {
+	if value, ok := metric.GetTag(key); ok {
+		return value, true
+	}
+	if value, ok := metric.GetField(key); ok {
+		return formatValue(value), true
+	}
+	return "", false
+}
+
+func toUint(value interface{}) (uint64, bool) {
+	switch v := value.(type) {
+	case int64:
+		if v < 0 {
+			return 0, false
+		}
+		return uint64(v), true
+	case uint64:
+		return v, true
+	}
+	return 0, false
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
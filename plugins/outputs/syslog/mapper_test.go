@@ -0,0 +1,140 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapMetricDefaults(t *testing.T) {
+	s := newSyslog()
+	s.Address = "tcp://localhost:6514"
+	require.NoError(t, s.Init())
+	s.hostname = "testhost"
+
+	m := testutil.MustMetric(
+		"testmetric",
+		map[string]string{},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC),
+	)
+
+	msg, err := s.mapMetric(m)
+	require.NoError(t, err)
+	str, err := msg.String()
+	require.NoError(t, err)
+	require.Equal(t, "<13>1 2010-11-10T23:30:00Z testhost Telegraf - - -", str)
+}
+
+func TestMapMetricHeader(t *testing.T) {
+	s := newSyslog()
+	s.Address = "tcp://localhost:6514"
+	require.NoError(t, s.Init())
+
+	m := testutil.MustMetric(
+		"syslog",
+		map[string]string{
+			"appname":  "sshd",
+			"hostname": "example.org",
+			"host":     "telegraf.example.org",
+			"severity": "warning",
+			"facility": "auth",
+		},
+		map[string]interface{}{
+			"version":       int64(1),
+			"severity_code": int64(4),
+			"facility_code": int64(4),
+			"timestamp":     time.Date(2018, time.October, 1, 12, 0, 0, 123456000, time.UTC).UnixNano(),
+			"procid":        "25",
+			"msgid":         "ID47",
+			"message":       "Connection closed",
+		},
+		time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC),
+	)
+
+	msg, err := s.mapMetric(m)
+	require.NoError(t, err)
+	str, err := msg.String()
+	require.NoError(t, err)
+	require.Equal(t, "<36>1 2018-10-01T12:00:00.123456Z example.org sshd 25 ID47 - Connection closed", str)
+}
+
+func TestMapMetricInvalidCodes(t *testing.T) {
+	s := newSyslog()
+	s.Address = "tcp://localhost:6514"
+	s.DefaultFacilityCode = 3
+	s.DefaultSeverityCode = 2
+	require.NoError(t, s.Init())
+
+	m := testutil.MustMetric(
+		"testmetric",
+		map[string]string{"host": "testhost"},
+		map[string]interface{}{
+			"severity_code": int64(8),
+			"facility_code": int64(-1),
+			"version":       int64(1000),
+		},
+		time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC),
+	)
+
+	msg, err := s.mapMetric(m)
+	require.NoError(t, err)
+	str, err := msg.String()
+	require.NoError(t, err)
+	require.Equal(t, "<26>1 2010-11-10T23:30:00Z testhost Telegraf - - -", str)
+}
+
+func TestMapMetricStructuredData(t *testing.T) {
+	s := newSyslog()
+	s.Address = "tcp://localhost:6514"
+	s.DefaultSdid = "default@32473"
+	s.Sdids = []string{"foo@123", "bar@456", "empty@789"}
+	require.NoError(t, s.Init())
+
+	m := testutil.MustMetric(
+		"xyzzy",
+		map[string]string{
+			"host": "testhost",
+			"x":    "y",
+		},
+		map[string]interface{}{
+			"foo@123_value":  int64(42),
+			"bar@456_value2": 84.5,
+			"something_else": true,
+			"empty@789":      true,
+		},
+		time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC),
+	)
+
+	msg, err := s.mapMetric(m)
+	require.NoError(t, err)
+	str, err := msg.String()
+	require.NoError(t, err)
+	require.Equal(t, `<13>1 2010-11-10T23:30:00Z testhost Telegraf - - `+
+		`[bar@456 value2="84.5"][default@32473 something_else="true" x="y"][empty@789][foo@123 value="42"]`, str)
+}
+
+func TestMapMetricStructuredDataWithoutDefault(t *testing.T) {
+	s := newSyslog()
+	s.Address = "tcp://localhost:6514"
+	s.Sdids = []string{"foo@123"}
+	require.NoError(t, s.Init())
+
+	m := testutil.MustMetric(
+		"xyzzy",
+		map[string]string{"host": "testhost"},
+		map[string]interface{}{
+			"foo@123_value":  `a "quoted" \value]`,
+			"something_else": int64(1),
+		},
+		time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC),
+	)
+
+	msg, err := s.mapMetric(m)
+	require.NoError(t, err)
+	str, err := msg.String()
+	require.NoError(t, err)
+	require.Equal(t, `<13>1 2010-11-10T23:30:00Z testhost Telegraf - - [foo@123 value="a \"quoted\" \\value\]"]`, str)
+}
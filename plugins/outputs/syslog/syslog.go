@@ -0,0 +1,321 @@
+package syslog
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/go-syslog/nontransparent"
+	"github.com/influxdata/go-syslog/rfc5424"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	framing "github.com/influxdata/telegraf/internal/syslog"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+type Syslog struct {
+	Address             string
+	KeepAlivePeriod     *internal.Duration
+	DefaultSdid         string
+	DefaultSeverityCode uint8
+	DefaultFacilityCode uint8
+	DefaultAppname      string
+	Sdids               []string
+	Separator           string `toml:"sdparam_separator"`
+	Framing             framing.Framing
+	Trailer             nontransparent.TrailerType
+	tlsint.ClientConfig
+
+	net.Conn
+
+	network  string
+	address  string
+	isStream bool
+	hostname string
+}
+
+var sampleConfig = `
+  ## URL to connect to
+  ## ex: address = "tcp://127.0.0.1:6514"
+  ## ex: address = "tcp4://127.0.0.1:6514"
+  ## ex: address = "tcp6://127.0.0.1:6514"
+  ## ex: address = "tcp6://[2001:db8::1]:6514"
+  ## ex: address = "udp://127.0.0.1:6514"
+  ## ex: address = "udp4://127.0.0.1:6514"
+  ## ex: address = "udp6://127.0.0.1:6514"
+  ## ex: address = "unix:///var/run/syslog.sock"
+  ## ex: address = "unixgram:///dev/log"
+  ## If no port is specified, 6514 is used (RFC5425#section-4.1).
+  address = "tcp://127.0.0.1:6514"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Period between keep alive probes.
+  ## Only applies to TCP sockets.
+  ## 0 disables keep alive probes.
+  ## Defaults to the OS configuration.
+  # keep_alive_period = "5m"
+
+  ## The framing technique with which messages are transported (default = "octet-counting").
+  ## Whether to use the octet-counting (RFC5425#section-4.3.1, RFC6587#section-3.4.1),
+  ## or the non-transparent framing technique (RFC6587#section-3.4.2).
+  ## Must be one of "octet-counting", "non-transparent".
+  ## Only applies to stream sockets (e.g. TCP).
+  # framing = "octet-counting"
+
+  ## The trailer to be used in case of non-transparent framing (default = "LF").
+  ## Must be one of "LF", or "NUL".
+  # trailer = "LF"
+
+  ## SD-PARAMs settings
+  ## Syslog messages can contain key/value pairs within zero or more
+  ## structured data sections.  For each unrecognized metric tag/field a
+  ## SD-PARAM is created.
+  ##
+  ## Example:
+  ##   [[outputs.syslog]]
+  ##     sdparam_separator = "_"
+  ##     default_sdid = "default@32473"
+  ##     sdids = ["foo@123", "bar@456"]
+  ##
+  ##   input => xyzzy,x=y foo@123_value=42,bar@456_value2=84,something_else=1
+  ##   output (structured data only) => [bar@456 value2="84"][default@32473 something_else="1" x="y"][foo@123 value="42"]
+
+  ## SD-PARAMs separator between the sdid and tag/field key (default = "_")
+  # sdparam_separator = "_"
+
+  ## Default sdid used for tags/fields that don't contain a prefix defined in
+  ## the explicit sdids setting below.  If no default is specified, no SD-PARAMs
+  ## will be used for unrecognized fields.
+  # default_sdid = "default@32473"
+
+  ## List of explicit prefixes to extract from tag/field keys and use as the
+  ## SDID, if they match (see above example for more details):
+  # sdids = ["foo@123", "bar@456"]
+
+  ## Default severity value. Severity and Facility are used to calculate the
+  ## message PRI value (RFC5424#section-6.2.1).  Used when no metric field
+  ## with key "severity_code" is defined.  If unset, 5 (notice) is the default
+  # default_severity_code = 5
+
+  ## Default facility value. Facility and Severity are used to calculate the
+  ## message PRI value (RFC5424#section-6.2.1).  Used when no metric field with
+  ## key "facility_code" is defined.  If unset, 1 (user-level) is the default
+  # default_facility_code = 1
+
+  ## Default APP-NAME value (RFC5424#section-6.2.5)
+  ## Used when no metric tag with key "appname" is defined.
+  ## If unset, "Telegraf" is the default
+  # default_appname = "Telegraf"
+`
+
+func (s *Syslog) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Syslog) Description() string {
+	return "Configuration for Syslog server to send metrics to"
+}
+
+// Init parses the address and checks the framing, trailer and default
+// codes.
+func (s *Syslog) Init() error {
+	if s.Address == "" {
+		return fmt.Errorf("address is required")
+	}
+
+	var err error
+	s.network, s.address, err = getAddressParts(s.Address)
+	if err != nil {
+		return err
+	}
+
+	switch s.network {
+	case "tcp", "tcp4", "tcp6", "unix", "unixpacket":
+		s.isStream = true
+	case "udp", "udp4", "udp6", "ip", "ip4", "ip6", "unixgram":
+		s.isStream = false
+	default:
+		return fmt.Errorf("unknown protocol '%s' in '%s'", s.network, s.Address)
+	}
+
+	if s.Framing != framing.OctetCounting && s.Framing != framing.NonTransparent {
+		return fmt.Errorf("unknown framing")
+	}
+	if s.Trailer != nontransparent.LF && s.Trailer != nontransparent.NUL {
+		return fmt.Errorf("unknown trailer")
+	}
+	if s.DefaultSeverityCode > 7 {
+		return fmt.Errorf("default_severity_code must be within 0 and 7")
+	}
+	if s.DefaultFacilityCode > 23 {
+		return fmt.Errorf("default_facility_code must be within 0 and 23")
+	}
+
+	s.hostname, err = os.Hostname()
+	return err
+}
+
+func (s *Syslog) Connect() error {
+	if s.network == "" {
+		if err := s.Init(); err != nil {
+			return err
+		}
+	}
+
+	tlsCfg, err := s.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	var c net.Conn
+	if tlsCfg == nil {
+		c, err = net.Dial(s.network, s.address)
+	} else {
+		c, err = tls.Dial(s.network, s.address, tlsCfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.setKeepAlive(c); err != nil {
+		log.Printf("W! [outputs.syslog] unable to configure keep alive (%s): %s", s.Address, err)
+	}
+
+	s.Conn = c
+	return nil
+}
+
+func (s *Syslog) setKeepAlive(c net.Conn) error {
+	if s.KeepAlivePeriod == nil {
+		return nil
+	}
+	tcpc, ok := c.(*net.TCPConn)
+	if !ok {
+		return fmt.Errorf("cannot set keep alive on a %s socket", s.network)
+	}
+	if s.KeepAlivePeriod.Duration == 0 {
+		return tcpc.SetKeepAlive(false)
+	}
+	if err := tcpc.SetKeepAlive(true); err != nil {
+		return err
+	}
+	return tcpc.SetKeepAlivePeriod(s.KeepAlivePeriod.Duration)
+}
+
+// Write sends each metric as a syslog message.  Metrics that do not map to a
+// valid syslog message are dropped.
+func (s *Syslog) Write(metrics []telegraf.Metric) error {
+	if s.Conn == nil {
+		// previous write failed with permanent error and socket was closed.
+		if err := s.Connect(); err != nil {
+			return err
+		}
+	}
+
+	for _, metric := range metrics {
+		msg, err := s.mapMetric(metric)
+		if err != nil {
+			log.Printf("E! [outputs.syslog] Dropping metric %s: %v", metric.Name(), err)
+			continue
+		}
+
+		buf, err := s.frame(msg)
+		if err != nil {
+			log.Printf("E! [outputs.syslog] Dropping metric %s: %v", metric.Name(), err)
+			continue
+		}
+
+		if _, err := s.Conn.Write(buf); err != nil {
+			if err, ok := err.(net.Error); !ok || !err.Temporary() {
+				// permanent error. close the connection
+				s.Close()
+				return fmt.Errorf("closing connection: %v", err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// frame returns the message framed for the transport.  Datagrams hold a
+// single message, so only messages in streams are framed.
+func (s *Syslog) frame(msg *rfc5424.SyslogMessage) ([]byte, error) {
+	text, err := msg.String()
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.isStream {
+		return []byte(text), nil
+	}
+
+	if s.Framing == framing.OctetCounting {
+		return []byte(strconv.Itoa(len(text)) + " " + text), nil
+	}
+
+	if s.Trailer == nontransparent.NUL {
+		return []byte(text + "\x00"), nil
+	}
+	return []byte(text + "\n"), nil
+}
+
+// Close closes the connection. Noop if already closed.
+func (s *Syslog) Close() error {
+	if s.Conn == nil {
+		return nil
+	}
+	err := s.Conn.Close()
+	s.Conn = nil
+	return err
+}
+
+// getAddressParts returns the network and the address to dial.  For IP
+// networks the port defaults to 6514.
+func getAddressParts(a string) (string, string, error) {
+	parts := strings.SplitN(a, "://", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("missing protocol within address '%s'", a)
+	}
+
+	switch parts[0] {
+	case "unix", "unixpacket", "unixgram":
+		return parts[0], parts[1], nil
+	}
+
+	u, err := url.Parse(a)
+	if err != nil {
+		return "", "", err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "6514"
+	}
+	return u.Scheme, net.JoinHostPort(u.Hostname(), port), nil
+}
+
+func newSyslog() *Syslog {
+	return &Syslog{
+		Framing:             framing.OctetCounting,
+		Trailer:             nontransparent.LF,
+		Separator:           "_",
+		DefaultSeverityCode: uint8(5), // notice
+		DefaultFacilityCode: uint8(1), // user-level
+		DefaultAppname:      "Telegraf",
+	}
+}
+
+func init() {
+	outputs.Add("syslog", func() telegraf.Output { return newSyslog() })
+}
@@ -0,0 +1,183 @@
+package syslog
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/go-syslog/nontransparent"
+	"github.com/influxdata/telegraf"
+	framing "github.com/influxdata/telegraf/internal/syslog"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var pki = testutil.NewPKI("../../../testutil/pki")
+
+func testMetrics() []telegraf.Metric {
+	return []telegraf.Metric{
+		testutil.MustMetric(
+			"testmetric",
+			map[string]string{"host": "testhost"},
+			map[string]interface{}{"message": "first"},
+			time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC),
+		),
+		testutil.MustMetric(
+			"testmetric",
+			map[string]string{"host": "testhost"},
+			map[string]interface{}{"message": "second"},
+			time.Date(2010, time.November, 10, 23, 30, 1, 0, time.UTC),
+		),
+	}
+}
+
+func TestGetAddressParts(t *testing.T) {
+	tests := []struct {
+		address string
+		network string
+		host    string
+	}{
+		{"tcp://127.0.0.1:514", "tcp", "127.0.0.1:514"},
+		{"udp://example.org", "udp", "example.org:6514"},
+		{"tcp6://[2001:db8::1]:514", "tcp6", "[2001:db8::1]:514"},
+		{"unixgram:///dev/log", "unixgram", "/dev/log"},
+	}
+	for _, tt := range tests {
+		network, host, err := getAddressParts(tt.address)
+		require.NoError(t, err)
+		require.Equal(t, tt.network, network)
+		require.Equal(t, tt.host, host)
+	}
+
+	_, _, err := getAddressParts("127.0.0.1:514")
+	require.Error(t, err)
+}
+
+func TestInitInvalid(t *testing.T) {
+	s := newSyslog()
+	require.Error(t, s.Init())
+
+	s = newSyslog()
+	s.Address = "http://127.0.0.1:514"
+	require.Error(t, s.Init())
+
+	s = newSyslog()
+	s.Address = "tcp://127.0.0.1:514"
+	s.DefaultSeverityCode = 8
+	require.Error(t, s.Init())
+}
+
+func TestWriteOctetCounting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := newSyslog()
+	s.Address = "tcp://" + listener.Addr().String()
+	require.NoError(t, s.Init())
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	defer lconn.Close()
+
+	require.NoError(t, s.Write(testMetrics()))
+
+	expected := "56 <13>1 2010-11-10T23:30:00Z testhost Telegraf - - - first" +
+		"57 <13>1 2010-11-10T23:30:01Z testhost Telegraf - - - second"
+	buf := make([]byte, len(expected))
+	_, err = io.ReadFull(lconn, buf)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(buf))
+}
+
+func TestWriteTLS(t *testing.T) {
+	serverConfig, err := pki.TLSServerConfig().TLSConfig()
+	require.NoError(t, err)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	// The handshake is done while connecting, so the messages are read
+	// concurrently.
+	expected := "56 <13>1 2010-11-10T23:30:00Z testhost Telegraf - - - first"
+	received := make(chan string, 1)
+	go func() {
+		defer close(received)
+		lconn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer lconn.Close()
+		buf := make([]byte, len(expected))
+		io.ReadFull(lconn, buf)
+		received <- string(buf)
+	}()
+
+	s := newSyslog()
+	s.Address = "tcp://" + listener.Addr().String()
+	s.ClientConfig = *pki.TLSClientConfig()
+	require.NoError(t, s.Init())
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	require.NoError(t, s.Write(testMetrics()[:1]))
+	require.Equal(t, expected, <-received)
+}
+
+func TestWriteNonTransparent(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := newSyslog()
+	s.Address = "tcp://" + listener.Addr().String()
+	s.Framing = framing.NonTransparent
+	s.Trailer = nontransparent.LF
+	require.NoError(t, s.Init())
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	defer lconn.Close()
+
+	require.NoError(t, s.Write(testMetrics()))
+
+	scanner := bufio.NewScanner(lconn)
+	require.True(t, scanner.Scan())
+	require.Equal(t, "<13>1 2010-11-10T23:30:00Z testhost Telegraf - - - first", scanner.Text())
+	require.True(t, scanner.Scan())
+	require.Equal(t, "<13>1 2010-11-10T23:30:01Z testhost Telegraf - - - second", scanner.Text())
+}
+
+func TestWriteUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := newSyslog()
+	s.Address = "udp://" + listener.LocalAddr().String()
+	require.NoError(t, s.Init())
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	require.NoError(t, s.Write(testMetrics()))
+
+	// Datagrams are not framed.
+	buf := make([]byte, 256)
+	var messages []string
+	for len(messages) < 2 {
+		n, _, err := listener.ReadFrom(buf)
+		require.NoError(t, err)
+		messages = append(messages, string(buf[:n]))
+	}
+	require.Equal(t, []string{
+		"<13>1 2010-11-10T23:30:00Z testhost Telegraf - - - first",
+		"<13>1 2010-11-10T23:30:01Z testhost Telegraf - - - second",
+	}, messages)
+}
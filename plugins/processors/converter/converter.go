@@ -3,11 +3,10 @@ package converter
 import (
 	"fmt"
 	"log"
-	"math"
-	"strconv"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
 
@@ -164,7 +163,7 @@ func (p *Converter) convertTags(metric telegraf.Metric) {
 		}
 
 		if p.tagConversions.Integer != nil && p.tagConversions.Integer.Match(key) {
-			v, ok := toInteger(value)
+			v, ok := internal.ToInt64(value)
 			if !ok {
 				metric.RemoveTag(key)
 				logPrintf("error converting to integer [%T]: %v\n", value, value)
@@ -176,7 +175,7 @@ func (p *Converter) convertTags(metric telegraf.Metric) {
 		}
 
 		if p.tagConversions.Unsigned != nil && p.tagConversions.Unsigned.Match(key) {
-			v, ok := toUnsigned(value)
+			v, ok := internal.ToUint64(value)
 			if !ok {
 				metric.RemoveTag(key)
 				logPrintf("error converting to unsigned [%T]: %v\n", value, value)
@@ -189,7 +188,7 @@ func (p *Converter) convertTags(metric telegraf.Metric) {
 		}
 
 		if p.tagConversions.Boolean != nil && p.tagConversions.Boolean.Match(key) {
-			v, ok := toBool(value)
+			v, ok := internal.ToBool(value)
 			if !ok {
 				metric.RemoveTag(key)
 				logPrintf("error converting to boolean [%T]: %v\n", value, value)
@@ -202,7 +201,7 @@ func (p *Converter) convertTags(metric telegraf.Metric) {
 		}
 
 		if p.tagConversions.Float != nil && p.tagConversions.Float.Match(key) {
-			v, ok := toFloat(value)
+			v, ok := internal.ToFloat64(value)
 			if !ok {
 				metric.RemoveTag(key)
 				logPrintf("error converting to float [%T]: %v\n", value, value)
@@ -224,7 +223,7 @@ func (p *Converter) convertFields(metric telegraf.Metric) {
 
 	for key, value := range metric.Fields() {
 		if p.fieldConversions.Tag != nil && p.fieldConversions.Tag.Match(key) {
-			v, ok := toString(value)
+			v, ok := internal.ToString(value)
 			if !ok {
 				metric.RemoveField(key)
 				logPrintf("error converting to tag [%T]: %v\n", value, value)
@@ -237,7 +236,7 @@ func (p *Converter) convertFields(metric telegraf.Metric) {
 		}
 
 		if p.fieldConversions.Float != nil && p.fieldConversions.Float.Match(key) {
-			v, ok := toFloat(value)
+			v, ok := internal.ToFloat64(value)
 			if !ok {
 				metric.RemoveField(key)
 				logPrintf("error converting to integer [%T]: %v\n", value, value)
@@ -250,7 +249,7 @@ func (p *Converter) convertFields(metric telegraf.Metric) {
 		}
 
 		if p.fieldConversions.Integer != nil && p.fieldConversions.Integer.Match(key) {
-			v, ok := toInteger(value)
+			v, ok := internal.ToInt64(value)
 			if !ok {
 				metric.RemoveField(key)
 				logPrintf("error converting to integer [%T]: %v\n", value, value)
@@ -263,7 +262,7 @@ func (p *Converter) convertFields(metric telegraf.Metric) {
 		}
 
 		if p.fieldConversions.Unsigned != nil && p.fieldConversions.Unsigned.Match(key) {
-			v, ok := toUnsigned(value)
+			v, ok := internal.ToUint64(value)
 			if !ok {
 				metric.RemoveField(key)
 				logPrintf("error converting to unsigned [%T]: %v\n", value, value)
@@ -276,7 +275,7 @@ func (p *Converter) convertFields(metric telegraf.Metric) {
 		}
 
 		if p.fieldConversions.Boolean != nil && p.fieldConversions.Boolean.Match(key) {
-			v, ok := toBool(value)
+			v, ok := internal.ToBool(value)
 			if !ok {
 				metric.RemoveField(key)
 				logPrintf("error converting to bool [%T]: %v\n", value, value)
@@ -289,7 +288,7 @@ func (p *Converter) convertFields(metric telegraf.Metric) {
 		}
 
 		if p.fieldConversions.String != nil && p.fieldConversions.String.Match(key) {
-			v, ok := toString(value)
+			v, ok := internal.ToString(value)
 			if !ok {
 				metric.RemoveField(key)
 				logPrintf("error converting to string [%T]: %v\n", value, value)
@@ -303,122 +302,6 @@ func (p *Converter) convertFields(metric telegraf.Metric) {
 	}
 }
 
-func toBool(v interface{}) (bool, bool) {
-	switch value := v.(type) {
-	case int64, uint64, float64:
-		if value != 0 {
-			return true, true
-		} else {
-			return false, false
-		}
-	case bool:
-		return value, true
-	case string:
-		result, err := strconv.ParseBool(value)
-		return result, err == nil
-	}
-	return false, false
-}
-
-func toInteger(v interface{}) (int64, bool) {
-	switch value := v.(type) {
-	case int64:
-		return value, true
-	case uint64:
-		if value <= uint64(math.MaxInt64) {
-			return int64(value), true
-		} else {
-			return math.MaxInt64, true
-		}
-	case float64:
-		if value < float64(math.MinInt64) {
-			return math.MinInt64, true
-		} else if value > float64(math.MaxInt64) {
-			return math.MaxInt64, true
-		} else {
-			return int64(value), true
-		}
-	case bool:
-		if value {
-			return 1, true
-		} else {
-			return 0, true
-		}
-	case string:
-		result, err := strconv.ParseInt(value, 10, 64)
-		return result, err == nil
-	}
-	return 0, false
-}
-
-func toUnsigned(v interface{}) (uint64, bool) {
-	switch value := v.(type) {
-	case uint64:
-		return value, true
-	case int64:
-		if value < 0 {
-			return 0, true
-		} else {
-			return uint64(value), true
-		}
-	case float64:
-		if value < 0.0 {
-			return 0, true
-		} else if value > float64(math.MaxUint64) {
-			return math.MaxUint64, true
-		} else {
-			return uint64(value), true
-		}
-	case bool:
-		if value {
-			return 1, true
-		} else {
-			return 0, true
-		}
-	case string:
-		result, err := strconv.ParseUint(value, 10, 64)
-		return result, err == nil
-	}
-	return 0, false
-}
-
-func toFloat(v interface{}) (float64, bool) {
-	switch value := v.(type) {
-	case int64:
-		return float64(value), true
-	case uint64:
-		return float64(value), true
-	case float64:
-		return value, true
-	case bool:
-		if value {
-			return 1.0, true
-		} else {
-			return 0.0, true
-		}
-	case string:
-		result, err := strconv.ParseFloat(value, 64)
-		return result, err == nil
-	}
-	return 0.0, false
-}
-
-func toString(v interface{}) (string, bool) {
-	switch value := v.(type) {
-	case int64:
-		return strconv.FormatInt(value, 10), true
-	case uint64:
-		return strconv.FormatUint(value, 10), true
-	case float64:
-		return strconv.FormatFloat(value, 'f', -1, 64), true
-	case bool:
-		return strconv.FormatBool(value), true
-	case string:
-		return value, true
-	}
-	return "", false
-}
-
 func logPrintf(format string, v ...interface{}) {
 	log.Printf("D! [processors.converter] "+format, v...)
 }
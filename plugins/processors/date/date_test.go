@@ -0,0 +1,184 @@
+package date
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func MustMetric(name string, tags map[string]string, fields map[string]interface{}, metricTime time.Time) telegraf.Metric {
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	m, _ := metric.New(name, tags, fields, metricTime)
+	return m
+}
+
+func TestMonthTag(t *testing.T) {
+	dateFormatMonth := Date{
+		TagKey:     "month",
+		DateFormat: "Jan",
+	}
+
+	currentTime := time.Now()
+	month := currentTime.UTC().Format("Jan")
+
+	m1 := MustMetric("foo", nil, nil, currentTime)
+	m2 := MustMetric("bar", nil, nil, currentTime)
+	m3 := MustMetric("baz", nil, nil, currentTime)
+	require.NoError(t, dateFormatMonth.Init())
+	monthApply := dateFormatMonth.Apply(m1, m2, m3)
+	assert.Equal(t, map[string]string{"month": month}, monthApply[0].Tags(), "should add tag 'month'")
+	assert.Equal(t, map[string]string{"month": month}, monthApply[1].Tags(), "should add tag 'month'")
+	assert.Equal(t, map[string]string{"month": month}, monthApply[2].Tags(), "should add tag 'month'")
+}
+
+func TestYearTag(t *testing.T) {
+	dateFormatYear := Date{
+		TagKey:     "year",
+		DateFormat: "2006",
+	}
+	currentTime := time.Now()
+	year := currentTime.UTC().Format("2006")
+
+	m4 := MustMetric("foo", nil, nil, currentTime)
+	m5 := MustMetric("bar", nil, nil, currentTime)
+	m6 := MustMetric("baz", nil, nil, currentTime)
+	require.NoError(t, dateFormatYear.Init())
+	yearApply := dateFormatYear.Apply(m4, m5, m6)
+	assert.Equal(t, map[string]string{"year": year}, yearApply[0].Tags(), "should add tag 'year'")
+	assert.Equal(t, map[string]string{"year": year}, yearApply[1].Tags(), "should add tag 'year'")
+	assert.Equal(t, map[string]string{"year": year}, yearApply[2].Tags(), "should add tag 'year'")
+}
+
+func TestOldDateTag(t *testing.T) {
+	dateFormatYear := Date{
+		TagKey:     "year",
+		DateFormat: "2006",
+	}
+
+	m7 := MustMetric("foo", nil, nil, time.Date(1993, 05, 27, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, dateFormatYear.Init())
+	customDateApply := dateFormatYear.Apply(m7)
+	assert.Equal(t, map[string]string{"year": "1993"}, customDateApply[0].Tags(), "should add tag 'year'")
+}
+
+func TestFieldUnix(t *testing.T) {
+	dateFormatUnix := Date{
+		FieldKey:   "unix",
+		DateFormat: "unix",
+	}
+
+	currentTime := time.Now()
+	unixTime := currentTime.Unix()
+
+	m8 := MustMetric("foo", nil, nil, currentTime)
+	require.NoError(t, dateFormatUnix.Init())
+	unixApply := dateFormatUnix.Apply(m8)
+	assert.Equal(t, map[string]interface{}{"unix": unixTime}, unixApply[0].Fields(), "should add unix time in s as field 'unix'")
+}
+
+func TestFieldUnixNano(t *testing.T) {
+	dateFormatUnixNano := Date{
+		FieldKey:   "unix_ns",
+		DateFormat: "unix_ns",
+	}
+
+	currentTime := time.Now()
+	unixNanoTime := currentTime.UnixNano()
+
+	m9 := MustMetric("foo", nil, nil, currentTime)
+	require.NoError(t, dateFormatUnixNano.Init())
+	unixNanoApply := dateFormatUnixNano.Apply(m9)
+	assert.Equal(t, map[string]interface{}{"unix_ns": unixNanoTime}, unixNanoApply[0].Fields(), "should add unix time in ns as field 'unix_ns'")
+}
+
+func TestFieldUnixMillis(t *testing.T) {
+	dateFormatUnixMillis := Date{
+		FieldKey:   "unix_ms",
+		DateFormat: "unix_ms",
+	}
+
+	currentTime := time.Now()
+	unixMillisTime := currentTime.UnixNano() / 1000000
+
+	m10 := MustMetric("foo", nil, nil, currentTime)
+	require.NoError(t, dateFormatUnixMillis.Init())
+	unixMillisApply := dateFormatUnixMillis.Apply(m10)
+	assert.Equal(t, map[string]interface{}{"unix_ms": unixMillisTime}, unixMillisApply[0].Fields(), "should add unix time in ms as field 'unix_ms'")
+}
+
+func TestFieldUnixMicros(t *testing.T) {
+	dateFormatUnixMicros := Date{
+		FieldKey:   "unix_us",
+		DateFormat: "unix_us",
+	}
+
+	currentTime := time.Now()
+	unixMicrosTime := currentTime.UnixNano() / 1000
+
+	m11 := MustMetric("foo", nil, nil, currentTime)
+	require.NoError(t, dateFormatUnixMicros.Init())
+	unixMicrosApply := dateFormatUnixMicros.Apply(m11)
+	assert.Equal(t, map[string]interface{}{"unix_us": unixMicrosTime}, unixMicrosApply[0].Fields(), "should add unix time in us as field 'unix_us'")
+}
+
+func TestDateOffset(t *testing.T) {
+	plugin := &Date{
+		TagKey:     "hour",
+		DateFormat: "15",
+		DateOffset: internal.Duration{Duration: 2 * time.Hour},
+	}
+
+	metric := MustMetric("cpu", nil, nil, time.Unix(1578603600, 0))
+	require.NoError(t, plugin.Init())
+	plugin.Apply(metric)
+	assert.Equal(t, map[string]string{"hour": "23"}, metric.Tags())
+}
+
+func TestTimezone(t *testing.T) {
+	plugin := &Date{
+		TagKey:     "weekday",
+		DateFormat: "Monday 15",
+		Timezone:   "Asia/Tokyo",
+	}
+
+	// 2020-01-09 21:00:00 UTC is 2020-01-10 06:00:00 in Tokyo
+	metric := MustMetric("cpu", nil, nil, time.Unix(1578603600, 0))
+	require.NoError(t, plugin.Init())
+	plugin.Apply(metric)
+	assert.Equal(t, map[string]string{"weekday": "Friday 06"}, metric.Tags())
+}
+
+func TestInvalidTimezone(t *testing.T) {
+	plugin := &Date{
+		TagKey:     "month",
+		DateFormat: "Jan",
+		Timezone:   "Mars/Olympus_Mons",
+	}
+	require.Error(t, plugin.Init())
+}
+
+func TestNoTagOrFieldKey(t *testing.T) {
+	plugin := &Date{
+		DateFormat: "Jan",
+	}
+	require.Error(t, plugin.Init())
+}
+
+func TestTagAndFieldKey(t *testing.T) {
+	plugin := &Date{
+		TagKey:     "month",
+		FieldKey:   "month",
+		DateFormat: "Jan",
+	}
+	require.Error(t, plugin.Init())
+}
@@ -0,0 +1,107 @@
+package dedup
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Maximum time to suppress output
+  dedup_interval = "600s"
+`
+
+type Dedup struct {
+	DedupInterval internal.Duration `toml:"dedup_interval"`
+
+	flushTime time.Time
+	cache     map[uint64]telegraf.Metric
+}
+
+func (d *Dedup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Dedup) Description() string {
+	return "Deduplicate repetitive metrics"
+}
+
+// Remove expired items from cache
+func (d *Dedup) cleanup() {
+	// No need to cleanup cache too often. Lets save some CPU
+	if time.Since(d.flushTime) < d.DedupInterval.Duration {
+		return
+	}
+	d.flushTime = time.Now()
+	keep := make(map[uint64]telegraf.Metric)
+	for id, metric := range d.cache {
+		if time.Since(metric.Time()) < d.DedupInterval.Duration {
+			keep[id] = metric
+		}
+	}
+	d.cache = keep
+}
+
+// Save item to cache
+func (d *Dedup) save(metric telegraf.Metric, id uint64) {
+	d.cache[id] = metric.Copy()
+	d.cache[id].Accept()
+}
+
+func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	idx := 0
+	for _, metric := range metrics {
+		id := metric.HashID()
+		m, ok := d.cache[id]
+
+		// If not in cache then just save it
+		if !ok {
+			d.save(metric, id)
+			metrics[idx] = metric
+			idx++
+			continue
+		}
+
+		// If cache item has expired then refresh it
+		if metric.Time().Sub(m.Time()) >= d.DedupInterval.Duration {
+			d.save(metric, id)
+			metrics[idx] = metric
+			idx++
+			continue
+		}
+
+		// For each field compare value with the cached one
+		changed := false
+		for _, f := range metric.FieldList() {
+			if value, ok := m.GetField(f.Key); !ok || value != f.Value {
+				changed = true
+				break
+			}
+		}
+		// If any field value has changed then refresh the cache
+		if changed || len(metric.FieldList()) != len(m.FieldList()) {
+			d.save(metric, id)
+			metrics[idx] = metric
+			idx++
+			continue
+		}
+
+		// In any other case remove metric from the output
+		metric.Drop()
+	}
+	metrics = metrics[:idx]
+	d.cleanup()
+	return metrics
+}
+
+func init() {
+	processors.Add("dedup", func() telegraf.Processor {
+		return &Dedup{
+			DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+			flushTime:     time.Now(),
+			cache:         make(map[uint64]telegraf.Metric),
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package expression
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+// functions lists the functions available in expressions and their number of
+// arguments.
+var functions = map[string]int{
+	"abs":   1,
+	"ceil":  1,
+	"exp":   1,
+	"field": 1,
+	"floor": 1,
+	"log":   1,
+	"log10": 1,
+	"max":   2,
+	"min":   2,
+	"pow":   2,
+	"round": 1,
+	"sqrt":  1,
+}
+
+// missingFieldError is returned if a field used in the expression does not
+// exist in the metric.
+type missingFieldError struct {
+	field string
+}
+
+func (e *missingFieldError) Error() string {
+	return fmt.Sprintf("field %q not found", e.field)
+}
+
+// compileExpression parses the expression and checks that it only consists
+// of the supported operators, functions and literals.
+func compileExpression(expr string) (ast.Expr, error) {
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	return node, check(node)
+}
+
+func check(node ast.Expr) error {
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		if n.Kind != token.INT && n.Kind != token.FLOAT {
+			return fmt.Errorf("unsupported literal %s", n.Value)
+		}
+		if _, err := strconv.ParseFloat(n.Value, 64); err != nil {
+			return fmt.Errorf("unsupported number %s", n.Value)
+		}
+		return nil
+	case *ast.Ident:
+		return nil
+	case *ast.ParenExpr:
+		return check(n.X)
+	case *ast.UnaryExpr:
+		if n.Op != token.ADD && n.Op != token.SUB {
+			return fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		return check(n.X)
+	case *ast.BinaryExpr:
+		switch n.Op {
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM:
+		default:
+			return fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		if err := check(n.X); err != nil {
+			return err
+		}
+		return check(n.Y)
+	case *ast.CallExpr:
+		fn, ok := n.Fun.(*ast.Ident)
+		if !ok {
+			return fmt.Errorf("unsupported function call")
+		}
+		nargs, ok := functions[fn.Name]
+		if !ok {
+			return fmt.Errorf("unknown function %q", fn.Name)
+		}
+		if len(n.Args) != nargs {
+			return fmt.Errorf("function %q expects %d argument(s)", fn.Name, nargs)
+		}
+		if fn.Name == "field" {
+			if lit, ok := n.Args[0].(*ast.BasicLit); !ok || lit.Kind != token.STRING {
+				return fmt.Errorf("function \"field\" expects a quoted field key")
+			}
+			return nil
+		}
+		for _, arg := range n.Args {
+			if err := check(arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported expression %T", node)
+}
+
+// evaluate computes the value of the compiled expression using the fields of
+// the metric.
+func evaluate(node ast.Expr, metric telegraf.Metric) (float64, error) {
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		return strconv.ParseFloat(n.Value, 64)
+	case *ast.Ident:
+		return fieldValue(metric, n.Name)
+	case *ast.ParenExpr:
+		return evaluate(n.X, metric)
+	case *ast.UnaryExpr:
+		x, err := evaluate(n.X, metric)
+		if err != nil {
+			return 0, err
+		}
+		if n.Op == token.SUB {
+			return -x, nil
+		}
+		return x, nil
+	case *ast.BinaryExpr:
+		x, err := evaluate(n.X, metric)
+		if err != nil {
+			return 0, err
+		}
+		y, err := evaluate(n.Y, metric)
+		if err != nil {
+			return 0, err
+		}
+		switch n.Op {
+		case token.ADD:
+			return x + y, nil
+		case token.SUB:
+			return x - y, nil
+		case token.MUL:
+			return x * y, nil
+		case token.QUO:
+			if y == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return x / y, nil
+		case token.REM:
+			if y == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return math.Mod(x, y), nil
+		}
+	case *ast.CallExpr:
+		return call(n, metric)
+	}
+	return 0, fmt.Errorf("unsupported expression %T", node)
+}
+
+func call(n *ast.CallExpr, metric telegraf.Metric) (float64, error) {
+	name := n.Fun.(*ast.Ident).Name
+	if name == "field" {
+		key, err := strconv.Unquote(n.Args[0].(*ast.BasicLit).Value)
+		if err != nil {
+			return 0, err
+		}
+		return fieldValue(metric, key)
+	}
+
+	args := make([]float64, 0, len(n.Args))
+	for _, arg := range n.Args {
+		v, err := evaluate(arg, metric)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, v)
+	}
+
+	switch name {
+	case "abs":
+		return math.Abs(args[0]), nil
+	case "ceil":
+		return math.Ceil(args[0]), nil
+	case "exp":
+		return math.Exp(args[0]), nil
+	case "floor":
+		return math.Floor(args[0]), nil
+	case "log":
+		return math.Log(args[0]), nil
+	case "log10":
+		return math.Log10(args[0]), nil
+	case "max":
+		return math.Max(args[0], args[1]), nil
+	case "min":
+		return math.Min(args[0], args[1]), nil
+	case "pow":
+		return math.Pow(args[0], args[1]), nil
+	case "round":
+		return math.Floor(args[0] + 0.5), nil
+	case "sqrt":
+		return math.Sqrt(args[0]), nil
+	}
+	return 0, fmt.Errorf("unknown function %q", name)
+}
+
+func fieldValue(metric telegraf.Metric, key string) (float64, error) {
+	value, ok := metric.GetField(key)
+	if !ok {
+		return 0, &missingFieldError{field: key}
+	}
+
+	v, ok := internal.ToFloat64(value)
+	if !ok {
+		return 0, fmt.Errorf("field %q is not numeric", key)
+	}
+	return v, nil
+}
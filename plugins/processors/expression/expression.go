@@ -0,0 +1,183 @@
+package expression
+
+import (
+	"fmt"
+	"go/ast"
+	"log"
+	"math"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  [[processors.expression.field]]
+    ## Key of the field to write the result to.  An existing field with the
+    ## same key is overwritten.
+    key = "used_percent"
+
+    ## Arithmetic expression to compute.  Fields are referenced by their key,
+    ## keys which are not valid identifiers can be referenced using
+    ## field("some-key").  Supported are the operators + - * / % and the
+    ## functions abs, ceil, exp, floor, log, log10, max, min, pow, round and
+    ## sqrt.
+    expression = "used / total * 100"
+
+    ## Type of the resulting field, one of "float", "integer", "unsigned",
+    ## "boolean" and "string".
+    # type = "float"
+
+    ## Only compute the field for metrics whose tags match.  The table key is
+    ## the tag key and the array contains the allowed values, which may
+    ## contain globs.
+    # [processors.expression.field.tags]
+    #   device = ["sd*"]
+`
+
+type Field struct {
+	Key        string              `toml:"key"`
+	Expression string              `toml:"expression"`
+	Type       string              `toml:"type"`
+	Tags       map[string][]string `toml:"tags"`
+
+	expr       ast.Expr
+	tagFilters map[string]filter.Filter
+}
+
+type Expression struct {
+	Fields []*Field `toml:"field"`
+
+	initialized bool
+}
+
+func (p *Expression) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Expression) Description() string {
+	return "Compute new fields from arithmetic expressions over numeric fields"
+}
+
+// Init compiles the expressions and tag filters of the fields, which are
+// float unless another type is set.
+func (p *Expression) Init() error {
+	if p.initialized {
+		return nil
+	}
+	return p.compile()
+}
+
+func (p *Expression) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	if !p.initialized {
+		err := p.compile()
+		if err != nil {
+			log.Printf("E! [processors.expression] initialization error: %v", err)
+			return metrics
+		}
+	}
+
+	for _, metric := range metrics {
+		for _, field := range p.Fields {
+			if !field.matchTags(metric) {
+				continue
+			}
+
+			value, err := field.compute(metric)
+			if err != nil {
+				log.Printf("D! [processors.expression] could not compute field %q: %v", field.Key, err)
+				continue
+			}
+			metric.AddField(field.Key, value)
+		}
+	}
+	return metrics
+}
+
+func (p *Expression) compile() error {
+	for _, field := range p.Fields {
+		if field.Key == "" {
+			return fmt.Errorf("missing key for expression %q", field.Expression)
+		}
+
+		switch field.Type {
+		case "":
+			field.Type = "float"
+		case "float", "integer", "unsigned", "boolean", "string":
+		default:
+			return fmt.Errorf("invalid type %q for field %q", field.Type, field.Key)
+		}
+
+		expr, err := compileExpression(field.Expression)
+		if err != nil {
+			return fmt.Errorf("invalid expression for field %q: %v", field.Key, err)
+		}
+		field.expr = expr
+
+		field.tagFilters = make(map[string]filter.Filter, len(field.Tags))
+		for key, values := range field.Tags {
+			f, err := filter.Compile(values)
+			if err != nil {
+				return fmt.Errorf("invalid tag filter for field %q: %v", field.Key, err)
+			}
+			field.tagFilters[key] = f
+		}
+	}
+
+	p.initialized = true
+	return nil
+}
+
+// matchTags checks that the metric has all tags with matching values.
+func (f *Field) matchTags(metric telegraf.Metric) bool {
+	for key, tagFilter := range f.tagFilters {
+		value, ok := metric.GetTag(key)
+		if !ok {
+			return false
+		}
+		if tagFilter != nil && !tagFilter.Match(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// compute evaluates the expression and converts the result to the type of
+// the field.
+func (f *Field) compute(metric telegraf.Metric) (interface{}, error) {
+	result, err := evaluate(f.expr, metric)
+	if err != nil {
+		return nil, err
+	}
+
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return nil, fmt.Errorf("result is not a finite number")
+	}
+
+	var value interface{}
+	var ok bool
+	switch f.Type {
+	case "integer":
+		value, ok = internal.ToInt64(result)
+	case "unsigned":
+		value, ok = internal.ToUint64(result)
+	case "boolean":
+		value, ok = result != 0, true
+	case "string":
+		value, ok = internal.ToString(result)
+	default:
+		value, ok = result, true
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("could not convert %v to %s", result, f.Type)
+	}
+	return value, nil
+}
+
+func init() {
+	processors.Add("expression", func() telegraf.Processor {
+		return &Expression{}
+	})
+}
@@ -0,0 +1,196 @@
+package expression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpression(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		plugin   *Expression
+		input    telegraf.Metric
+		expected telegraf.Metric
+	}{
+		{
+			name: "percentage",
+			plugin: &Expression{
+				Fields: []*Field{
+					{Key: "used_percent", Expression: "used / total * 100"},
+				},
+			},
+			input: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": int64(25), "total": int64(200)},
+				now,
+			),
+			expected: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": int64(25), "total": int64(200), "used_percent": 12.5},
+				now,
+			),
+		},
+		{
+			name: "bytes to bits as integer",
+			plugin: &Expression{
+				Fields: []*Field{
+					{Key: "bits_recv", Expression: "field(\"bytes-recv\") * 8", Type: "integer"},
+				},
+			},
+			input: testutil.MustMetric("net",
+				map[string]string{},
+				map[string]interface{}{"bytes-recv": uint64(1024)},
+				now,
+			),
+			expected: testutil.MustMetric("net",
+				map[string]string{},
+				map[string]interface{}{"bytes-recv": uint64(1024), "bits_recv": int64(8192)},
+				now,
+			),
+		},
+		{
+			name: "functions and unary operators",
+			plugin: &Expression{
+				Fields: []*Field{
+					{Key: "value", Expression: "round(max(-raw, 0) * 0.5 + pow(2, 3))", Type: "unsigned"},
+				},
+			},
+			input: testutil.MustMetric("sensor",
+				map[string]string{},
+				map[string]interface{}{"raw": -5.0},
+				now,
+			),
+			expected: testutil.MustMetric("sensor",
+				map[string]string{},
+				map[string]interface{}{"raw": -5.0, "value": uint64(11)},
+				now,
+			),
+		},
+		{
+			name: "missing field is skipped",
+			plugin: &Expression{
+				Fields: []*Field{
+					{Key: "used_percent", Expression: "used / total * 100"},
+					{Key: "used_mb", Expression: "used / 1024 / 1024"},
+				},
+			},
+			input: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": int64(1048576)},
+				now,
+			),
+			expected: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": int64(1048576), "used_mb": 1.0},
+				now,
+			),
+		},
+		{
+			name: "division by zero is skipped",
+			plugin: &Expression{
+				Fields: []*Field{
+					{Key: "used_percent", Expression: "used / total * 100"},
+				},
+			},
+			input: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": int64(0), "total": int64(0)},
+				now,
+			),
+			expected: testutil.MustMetric("mem",
+				map[string]string{},
+				map[string]interface{}{"used": int64(0), "total": int64(0)},
+				now,
+			),
+		},
+		{
+			name: "non numeric field is skipped",
+			plugin: &Expression{
+				Fields: []*Field{
+					{Key: "doubled", Expression: "value * 2"},
+				},
+			},
+			input: testutil.MustMetric("foo",
+				map[string]string{},
+				map[string]interface{}{"value": "forty-two"},
+				now,
+			),
+			expected: testutil.MustMetric("foo",
+				map[string]string{},
+				map[string]interface{}{"value": "forty-two"},
+				now,
+			),
+		},
+		{
+			name: "tag condition",
+			plugin: &Expression{
+				Fields: []*Field{
+					{Key: "celsius", Expression: "(fahrenheit - 32) / 1.8", Tags: map[string][]string{"unit": {"f*"}}},
+				},
+			},
+			input: testutil.MustMetric("temp",
+				map[string]string{"unit": "kelvin"},
+				map[string]interface{}{"fahrenheit": 212.0},
+				now,
+			),
+			expected: testutil.MustMetric("temp",
+				map[string]string{"unit": "kelvin"},
+				map[string]interface{}{"fahrenheit": 212.0},
+				now,
+			),
+		},
+		{
+			name: "tag condition matches",
+			plugin: &Expression{
+				Fields: []*Field{
+					{Key: "celsius", Expression: "(fahrenheit - 32) / 1.8", Tags: map[string][]string{"unit": {"f*"}}},
+				},
+			},
+			input: testutil.MustMetric("temp",
+				map[string]string{"unit": "fahrenheit"},
+				map[string]interface{}{"fahrenheit": 212.0},
+				now,
+			),
+			expected: testutil.MustMetric("temp",
+				map[string]string{"unit": "fahrenheit"},
+				map[string]interface{}{"fahrenheit": 212.0, "celsius": 100.0},
+				now,
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.plugin.Apply(tt.input)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, actual)
+		})
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		field *Field
+	}{
+		{name: "syntax error", field: &Field{Key: "a", Expression: "used / "}},
+		{name: "unknown function", field: &Field{Key: "a", Expression: "foo(used)"}},
+		{name: "wrong number of arguments", field: &Field{Key: "a", Expression: "max(used)"}},
+		{name: "unsupported operator", field: &Field{Key: "a", Expression: "used << 2"}},
+		{name: "string literal", field: &Field{Key: "a", Expression: "used + \"2\""}},
+		{name: "field without string", field: &Field{Key: "a", Expression: "field(used)"}},
+		{name: "invalid type", field: &Field{Key: "a", Expression: "used", Type: "complex"}},
+		{name: "missing key", field: &Field{Expression: "used"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Expression{Fields: []*Field{tt.field}}
+			require.Error(t, plugin.compile())
+		})
+	}
+}
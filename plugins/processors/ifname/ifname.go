@@ -0,0 +1,286 @@
+package ifname
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs/snmp"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Name of tag holding the interface number
+  # tag = "ifIndex"
+
+  ## Name of output tag where the interface name will be added
+  # dest = "ifName"
+
+  ## Name of tag of the SNMP agent to request the interface name from
+  # agent = "agent_host"
+
+  ## Timeout for each SNMP query.
+  # timeout = "5s"
+
+  ## SNMP version, values can be 1, 2, or 3
+  # version = 2
+
+  ## SNMP community string.
+  # community = "public"
+
+  ## Number of retries to attempt within timeout.
+  # retries = 3
+
+  ## The GETBULK max-repetitions parameter
+  # max_repetitions = 10
+
+  ## SNMPv3 auth parameters
+  #sec_name = "myuser"
+  #auth_protocol = "md5"      # Values: "MD5", "SHA", ""
+  #auth_password = "pass"
+  #sec_level = "authNoPriv"   # Values: "noAuthNoPriv", "authNoPriv", "authPriv"
+  #context_name = ""
+  #priv_protocol = ""         # Values: "DES", "AES", ""
+  #priv_password = ""
+
+  ## How long the interface names of an agent are cached before they are
+  ## requested again.
+  # cache_ttl = "8h"
+
+  ## Maximum number of agents whose interface names are requested at the
+  ## same time.
+  # max_parallel_lookups = 100
+`
+
+const (
+	// ifName and ifDescr columns of IF-MIB::ifXTable and IF-MIB::ifTable
+	oidIfName  = ".1.3.6.1.2.1.31.1.1.1.1"
+	oidIfDescr = ".1.3.6.1.2.1.2.2.1.2"
+
+	// failedLookupTTL is the longest time a failed request is cached.
+	failedLookupTTL = time.Minute
+)
+
+// nameMap maps the interface index to its name.
+type nameMap map[string]string
+
+type cacheEntry struct {
+	names   nameMap
+	expires time.Time
+}
+
+type IfName struct {
+	SourceTag string `toml:"tag"`
+	DestTag   string `toml:"dest"`
+	AgentTag  string `toml:"agent"`
+
+	Timeout        internal.Duration `toml:"timeout"`
+	Version        uint8             `toml:"version"`
+	Community      string            `toml:"community"`
+	Retries        int               `toml:"retries"`
+	MaxRepetitions uint8             `toml:"max_repetitions"`
+
+	ContextName  string `toml:"context_name"`
+	SecLevel     string `toml:"sec_level"`
+	SecName      string `toml:"sec_name"`
+	AuthProtocol string `toml:"auth_protocol"`
+	AuthPassword string `toml:"auth_password"`
+	PrivProtocol string `toml:"priv_protocol"`
+	PrivPassword string `toml:"priv_password"`
+
+	CacheTTL           internal.Duration `toml:"cache_ttl"`
+	MaxParallelLookups int               `toml:"max_parallel_lookups"`
+
+	// getConnection returns a connection to the agent, it is replaced in
+	// tests.
+	getConnection func(agent string) (snmp.Connection, error)
+	sem           chan struct{}
+	fetches       sync.WaitGroup
+
+	sync.Mutex
+	cache   map[string]cacheEntry
+	pending map[string]bool
+	conns   map[string]snmp.Connection
+}
+
+func NewIfName() *IfName {
+	return &IfName{
+		SourceTag:          "ifIndex",
+		DestTag:            "ifName",
+		AgentTag:           "agent_host",
+		Timeout:            internal.Duration{Duration: 5 * time.Second},
+		Version:            2,
+		Community:          "public",
+		Retries:            3,
+		MaxRepetitions:     10,
+		CacheTTL:           internal.Duration{Duration: 8 * time.Hour},
+		MaxParallelLookups: 100,
+	}
+}
+
+func (d *IfName) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *IfName) Description() string {
+	return "Add a tag with the interface name, looked up over SNMP, to metrics with an interface index tag."
+}
+
+func (d *IfName) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if d.cache == nil {
+		d.init()
+	}
+
+	for _, metric := range in {
+		agent, ok := metric.GetTag(d.AgentTag)
+		if !ok {
+			continue
+		}
+		index, ok := metric.GetTag(d.SourceTag)
+		if !ok {
+			continue
+		}
+
+		names, ok := d.lookup(agent)
+		if !ok {
+			continue
+		}
+		if name, ok := names[index]; ok {
+			metric.AddTag(d.DestTag, name)
+		}
+	}
+	return in
+}
+
+func (d *IfName) init() {
+	if d.getConnection == nil {
+		client := &snmp.Snmp{
+			Timeout:        d.Timeout,
+			Retries:        d.Retries,
+			Version:        d.Version,
+			Community:      d.Community,
+			MaxRepetitions: d.MaxRepetitions,
+			ContextName:    d.ContextName,
+			SecLevel:       d.SecLevel,
+			SecName:        d.SecName,
+			AuthProtocol:   d.AuthProtocol,
+			AuthPassword:   d.AuthPassword,
+			PrivProtocol:   d.PrivProtocol,
+			PrivPassword:   d.PrivPassword,
+		}
+		d.getConnection = client.NewConnection
+	}
+	if d.MaxParallelLookups < 1 {
+		d.MaxParallelLookups = 1
+	}
+	d.sem = make(chan struct{}, d.MaxParallelLookups)
+	d.cache = make(map[string]cacheEntry)
+	d.pending = make(map[string]bool)
+	d.conns = make(map[string]snmp.Connection)
+}
+
+// lookup returns the cached interface names of the agent.  If the agent is
+// not cached, or its entry has expired, the names are requested in the
+// background; an expired entry is still returned until then.
+func (d *IfName) lookup(agent string) (nameMap, bool) {
+	d.Lock()
+	defer d.Unlock()
+
+	entry, ok := d.cache[agent]
+	if !ok || time.Now().After(entry.expires) {
+		d.fetch(agent)
+	}
+	return entry.names, ok
+}
+
+// fetch starts a background request of the interface names of the agent,
+// unless one is already running or the maximum number of requests is in
+// flight.  It must be called with the lock held.
+func (d *IfName) fetch(agent string) {
+	if d.pending[agent] {
+		return
+	}
+
+	select {
+	case d.sem <- struct{}{}:
+	default:
+		return
+	}
+
+	d.pending[agent] = true
+	d.fetches.Add(1)
+
+	// Only one request per agent is running at a time, so the connection
+	// is never used by more than one goroutine.
+	gs := d.conns[agent]
+
+	go func() {
+		defer d.fetches.Done()
+		defer func() { <-d.sem }()
+
+		entry := cacheEntry{expires: time.Now().Add(d.CacheTTL.Duration)}
+
+		var err error
+		if gs == nil {
+			gs, err = d.getConnection(agent)
+		}
+		if err == nil {
+			entry.names, err = buildMap(gs)
+		}
+		if err != nil {
+			log.Printf("E! [processors.ifname] getting interface names from %s: %v", agent, err)
+			if d.CacheTTL.Duration > failedLookupTTL {
+				entry.expires = time.Now().Add(failedLookupTTL)
+			}
+		}
+
+		d.Lock()
+		if err == nil {
+			d.conns[agent] = gs
+		} else {
+			delete(d.conns, agent)
+		}
+		d.cache[agent] = entry
+		delete(d.pending, agent)
+		d.Unlock()
+	}()
+}
+
+// buildMap walks the interface tables of the agent and returns the name of
+// every interface, falling back to its description if it has no name.
+func buildMap(gs snmp.Connection) (nameMap, error) {
+	table := snmp.Table{
+		IndexAsTag: true,
+		Fields: []snmp.Field{
+			{Name: "ifName", Oid: oidIfName, IsTag: true},
+			{Name: "ifDescr", Oid: oidIfDescr, IsTag: true},
+		},
+	}
+
+	rt, err := table.Build(gs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(nameMap, len(rt.Rows))
+	for _, row := range rt.Rows {
+		index, ok := row.Tags["index"]
+		if !ok {
+			continue
+		}
+		if name, ok := row.Tags["ifName"]; ok {
+			names[index] = name
+		} else if name, ok := row.Tags["ifDescr"]; ok {
+			names[index] = name
+		}
+	}
+	return names, nil
+}
+
+func init() {
+	processors.Add("ifname", func() telegraf.Processor {
+		return NewIfName()
+	})
+}
@@ -0,0 +1,180 @@
+package ifname
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/inputs/snmp"
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/require"
+)
+
+type testSNMPConnection struct {
+	host   string
+	values map[string]interface{}
+
+	sync.Mutex
+	walks int
+}
+
+func (tsc *testSNMPConnection) Host() string {
+	return tsc.host
+}
+
+func (tsc *testSNMPConnection) Get(oids []string) (*gosnmp.SnmpPacket, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (tsc *testSNMPConnection) Walk(oid string, wf gosnmp.WalkFunc) error {
+	tsc.Lock()
+	tsc.walks++
+	tsc.Unlock()
+
+	for void, v := range tsc.values {
+		if void == oid || (len(void) > len(oid) && void[:len(oid)+1] == oid+".") {
+			if err := wf(gosnmp.SnmpPDU{
+				Name:  void,
+				Value: v,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (tsc *testSNMPConnection) Walks() int {
+	tsc.Lock()
+	defer tsc.Unlock()
+	return tsc.walks
+}
+
+var tsc = &testSNMPConnection{
+	host: "tsc",
+	values: map[string]interface{}{
+		".1.3.6.1.2.1.31.1.1.1.1.1": []byte("lo"),
+		".1.3.6.1.2.1.31.1.1.1.1.2": []byte("eth0"),
+		".1.3.6.1.2.1.2.2.1.2.1":    []byte("Loopback"),
+		".1.3.6.1.2.1.2.2.1.2.2":    []byte("Intel Ethernet"),
+		".1.3.6.1.2.1.2.2.1.2.3":    []byte("Tunnel"),
+	},
+}
+
+func newIfName(conn snmp.Connection) *IfName {
+	d := NewIfName()
+	d.getConnection = func(agent string) (snmp.Connection, error) {
+		if agent != "127.0.0.1" {
+			return nil, errors.New("no route to host")
+		}
+		return conn, nil
+	}
+	return d
+}
+
+func newMetric(agent string, index string) telegraf.Metric {
+	m, _ := metric.New("interface",
+		map[string]string{"agent_host": agent, "ifIndex": index},
+		map[string]interface{}{"ifInOctets": 42},
+		time.Unix(0, 0),
+	)
+	return m
+}
+
+func TestBuildMap(t *testing.T) {
+	names, err := buildMap(tsc)
+	require.NoError(t, err)
+	require.Equal(t, nameMap{
+		"1": "lo",
+		"2": "eth0",
+		"3": "Tunnel",
+	}, names)
+}
+
+func TestApply(t *testing.T) {
+	d := newIfName(tsc)
+
+	// the first metric is passed on while the names are requested
+	results := d.Apply(newMetric("127.0.0.1", "2"))
+	require.Len(t, results, 1)
+	require.False(t, results[0].HasTag("ifName"))
+
+	d.fetches.Wait()
+
+	results = d.Apply(
+		newMetric("127.0.0.1", "1"),
+		newMetric("127.0.0.1", "2"),
+		newMetric("127.0.0.1", "3"),
+		newMetric("127.0.0.1", "4"),
+	)
+	require.Len(t, results, 4)
+	for i, expected := range []string{"lo", "eth0", "Tunnel"} {
+		name, ok := results[i].GetTag("ifName")
+		require.True(t, ok)
+		require.Equal(t, expected, name)
+	}
+	require.False(t, results[3].HasTag("ifName"))
+}
+
+func TestMissingTags(t *testing.T) {
+	conn := &testSNMPConnection{values: tsc.values}
+	d := newIfName(conn)
+
+	m, _ := metric.New("interface",
+		map[string]string{"ifIndex": "1"},
+		map[string]interface{}{"ifInOctets": 42},
+		time.Unix(0, 0),
+	)
+	results := d.Apply(m)
+	require.Len(t, results, 1)
+	d.fetches.Wait()
+	require.Equal(t, 0, conn.Walks())
+}
+
+func TestCachedLookup(t *testing.T) {
+	conn := &testSNMPConnection{values: tsc.values}
+	d := newIfName(conn)
+
+	for i := 0; i < 3; i++ {
+		d.Apply(newMetric("127.0.0.1", "1"))
+		d.fetches.Wait()
+	}
+	// one walk for each of the ifName and ifDescr columns
+	require.Equal(t, 2, conn.Walks())
+
+	d.CacheTTL = internal.Duration{Duration: 0}
+	d.Lock()
+	d.cache = map[string]cacheEntry{}
+	d.Unlock()
+
+	d.Apply(newMetric("127.0.0.1", "1"))
+	d.fetches.Wait()
+	results := d.Apply(newMetric("127.0.0.1", "1"))
+	d.fetches.Wait()
+
+	// the expired entry is used while it is refreshed
+	name, ok := results[0].GetTag("ifName")
+	require.True(t, ok)
+	require.Equal(t, "lo", name)
+	require.Equal(t, 6, conn.Walks())
+}
+
+func TestFailedLookup(t *testing.T) {
+	d := newIfName(tsc)
+
+	d.Apply(newMetric("10.0.0.1", "1"))
+	d.fetches.Wait()
+
+	results := d.Apply(newMetric("10.0.0.1", "1"))
+	require.False(t, results[0].HasTag("ifName"))
+
+	d.Lock()
+	entry, ok := d.cache["10.0.0.1"]
+	d.Unlock()
+	require.True(t, ok)
+	require.True(t, entry.expires.Before(time.Now().Add(failedLookupTTL+time.Second)))
+}
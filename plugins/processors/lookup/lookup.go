@@ -0,0 +1,238 @@
+package lookup
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## List of files containing the lookup table.  Entries of later files
+  ## override entries of earlier files with the same key.
+  files = ["/etc/telegraf/lookup.csv"]
+
+  ## Format of the lookup files, available are "csv" and "json".
+  ##  csv:  The first row is a header, the first column contains the key and
+  ##        each further column is added as a tag named after its header.
+  ##  json: An object mapping each key to an object of tag names and values,
+  ##        e.g. {"host01": {"team": "web", "rack": "r12"}}
+  format = "csv"
+
+  ## Tag keys whose values form the lookup key.  If multiple keys are given
+  ## the values are joined using the key_separator.  Metrics not having all
+  ## of these tags are passed unmodified.
+  key_tags = ["host"]
+  # key_separator = ":"
+
+  ## If true, tags already present in the metric are overwritten by the
+  ## values from the lookup table.
+  # overwrite = false
+
+  ## Interval in which the files are checked for changes and reloaded if
+  ## modified.  Set to "0s" to disable reloading.
+  # reload_interval = "1m"
+`
+
+type Lookup struct {
+	Files          []string          `toml:"files"`
+	Format         string            `toml:"format"`
+	KeyTags        []string          `toml:"key_tags"`
+	KeySeparator   string            `toml:"key_separator"`
+	Overwrite      bool              `toml:"overwrite"`
+	ReloadInterval internal.Duration `toml:"reload_interval"`
+
+	mappings  map[string]map[string]string
+	modTimes  map[string]time.Time
+	lastCheck time.Time
+}
+
+func (l *Lookup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *Lookup) Description() string {
+	return "Add tags to metrics using a lookup table loaded from files."
+}
+
+// Init checks that files and key tags are set and the format is known.
+func (l *Lookup) Init() error {
+	if len(l.Files) == 0 {
+		return fmt.Errorf("no files specified")
+	}
+	if len(l.KeyTags) == 0 {
+		return fmt.Errorf("no key_tags specified")
+	}
+	switch l.Format {
+	case "", "csv", "json":
+	default:
+		return fmt.Errorf("invalid format %q", l.Format)
+	}
+	return nil
+}
+
+func (l *Lookup) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if l.mappings == nil || l.needsReload() {
+		if err := l.load(); err != nil {
+			log.Printf("E! [processors.lookup] could not load lookup table: %v", err)
+		}
+	}
+
+	for _, metric := range in {
+		key, ok := l.key(metric)
+		if !ok {
+			continue
+		}
+
+		tags, ok := l.mappings[key]
+		if !ok {
+			continue
+		}
+
+		for tag, value := range tags {
+			if !l.Overwrite && metric.HasTag(tag) {
+				continue
+			}
+			metric.AddTag(tag, value)
+		}
+	}
+	return in
+}
+
+// key builds the lookup key from the metric's tags.
+func (l *Lookup) key(metric telegraf.Metric) (string, bool) {
+	values := make([]string, 0, len(l.KeyTags))
+	for _, tag := range l.KeyTags {
+		value, ok := metric.GetTag(tag)
+		if !ok {
+			return "", false
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, l.KeySeparator), len(values) > 0
+}
+
+// needsReload checks if any of the files changed since they have been loaded.
+// The files are not checked more often than the reload interval.
+func (l *Lookup) needsReload() bool {
+	if l.ReloadInterval.Duration <= 0 || time.Since(l.lastCheck) < l.ReloadInterval.Duration {
+		return false
+	}
+	l.lastCheck = time.Now()
+
+	for _, filename := range l.Files {
+		stat, err := os.Stat(filename)
+		if err != nil {
+			log.Printf("E! [processors.lookup] could not stat file %q: %v", filename, err)
+			continue
+		}
+		if modTime, ok := l.modTimes[filename]; !ok || !stat.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// load reads all files into a new lookup table.  The current table is only
+// replaced if all files could be read successfully.
+func (l *Lookup) load() error {
+	l.lastCheck = time.Now()
+	if l.mappings == nil {
+		l.mappings = make(map[string]map[string]string)
+	}
+
+	mappings := make(map[string]map[string]string)
+	modTimes := make(map[string]time.Time)
+	for _, filename := range l.Files {
+		stat, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+
+		buf, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		var entries map[string]map[string]string
+		switch l.Format {
+		case "", "csv":
+			entries, err = parseCSV(buf)
+		case "json":
+			entries, err = parseJSON(buf)
+		default:
+			return fmt.Errorf("invalid format %q", l.Format)
+		}
+		if err != nil {
+			return fmt.Errorf("parsing file %q failed: %v", filename, err)
+		}
+
+		for key, tags := range entries {
+			mappings[key] = tags
+		}
+		modTimes[filename] = stat.ModTime()
+	}
+
+	l.mappings = mappings
+	l.modTimes = modTimes
+	return nil
+}
+
+func parseCSV(buf []byte) (map[string]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(buf))
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	if len(header) < 2 {
+		return nil, fmt.Errorf("header must contain the key and at least one tag column")
+	}
+
+	entries := make(map[string]map[string]string, len(records)-1)
+	for _, record := range records[1:] {
+		tags := make(map[string]string, len(header)-1)
+		for i, value := range record[1:] {
+			if value == "" {
+				continue
+			}
+			tags[header[i+1]] = value
+		}
+		entries[record[0]] = tags
+	}
+	return entries, nil
+}
+
+func parseJSON(buf []byte) (map[string]map[string]string, error) {
+	var entries map[string]map[string]string
+	if err := json.Unmarshal(buf, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func init() {
+	processors.Add("lookup", func() telegraf.Processor {
+		return &Lookup{
+			Format:         "csv",
+			KeySeparator:   ":",
+			ReloadInterval: internal.Duration{Duration: time.Minute},
+		}
+	})
+}
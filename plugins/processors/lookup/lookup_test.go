@@ -0,0 +1,135 @@
+package lookup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(tags map[string]string) telegraf.Metric {
+	m, _ := metric.New("cpu", tags, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	return m
+}
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	filename := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0640))
+	return filename
+}
+
+func TestLookupCSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l := &Lookup{
+		Files:   []string{writeFile(t, dir, "hosts.csv", "host,team,rack\nserver01,web,r12\nserver02,db,\n")},
+		Format:  "csv",
+		KeyTags: []string{"host"},
+	}
+
+	results := l.Apply(
+		newMetric(map[string]string{"host": "server01"}),
+		newMetric(map[string]string{"host": "server02"}),
+		newMetric(map[string]string{"host": "server03"}),
+		newMetric(map[string]string{}),
+	)
+	require.Len(t, results, 4)
+	require.Equal(t, map[string]string{"host": "server01", "team": "web", "rack": "r12"}, results[0].Tags())
+	require.Equal(t, map[string]string{"host": "server02", "team": "db"}, results[1].Tags())
+	require.Equal(t, map[string]string{"host": "server03"}, results[2].Tags())
+	require.Equal(t, map[string]string{}, results[3].Tags())
+}
+
+func TestLookupJSONMultipleKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l := &Lookup{
+		Files:        []string{writeFile(t, dir, "hosts.json", `{"server01:10.0.0.1": {"env": "prod"}}`)},
+		Format:       "json",
+		KeyTags:      []string{"host", "ip"},
+		KeySeparator: ":",
+	}
+
+	results := l.Apply(
+		newMetric(map[string]string{"host": "server01", "ip": "10.0.0.1"}),
+		newMetric(map[string]string{"host": "server01"}),
+	)
+	require.Equal(t, map[string]string{"host": "server01", "ip": "10.0.0.1", "env": "prod"}, results[0].Tags())
+	require.Equal(t, map[string]string{"host": "server01"}, results[1].Tags())
+}
+
+func TestLookupOverwrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := writeFile(t, dir, "hosts.csv", "host,team\nserver01,web\n")
+
+	l := &Lookup{
+		Files:   []string{filename},
+		KeyTags: []string{"host"},
+	}
+	results := l.Apply(newMetric(map[string]string{"host": "server01", "team": "ops"}))
+	require.Equal(t, map[string]string{"host": "server01", "team": "ops"}, results[0].Tags())
+
+	l.Overwrite = true
+	results = l.Apply(newMetric(map[string]string{"host": "server01", "team": "ops"}))
+	require.Equal(t, map[string]string{"host": "server01", "team": "web"}, results[0].Tags())
+}
+
+func TestLookupReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := writeFile(t, dir, "hosts.csv", "host,team\nserver01,web\n")
+
+	l := &Lookup{
+		Files:          []string{filename},
+		KeyTags:        []string{"host"},
+		ReloadInterval: internal.Duration{Duration: time.Nanosecond},
+	}
+	results := l.Apply(newMetric(map[string]string{"host": "server01"}))
+	require.Equal(t, map[string]string{"host": "server01", "team": "web"}, results[0].Tags())
+
+	writeFile(t, dir, "hosts.csv", "host,team\nserver01,db\n")
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filename, future, future))
+
+	results = l.Apply(newMetric(map[string]string{"host": "server01"}))
+	require.Equal(t, map[string]string{"host": "server01", "team": "db"}, results[0].Tags())
+}
+
+func TestLookupKeepsTableOnError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := writeFile(t, dir, "hosts.json", `{"server01": {"team": "web"}}`)
+
+	l := &Lookup{
+		Files:          []string{filename},
+		Format:         "json",
+		KeyTags:        []string{"host"},
+		ReloadInterval: internal.Duration{Duration: time.Nanosecond},
+	}
+	results := l.Apply(newMetric(map[string]string{"host": "server01"}))
+	require.Equal(t, map[string]string{"host": "server01", "team": "web"}, results[0].Tags())
+
+	writeFile(t, dir, "hosts.json", `{"server01": `)
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filename, future, future))
+
+	results = l.Apply(newMetric(map[string]string{"host": "server01"}))
+	require.Equal(t, map[string]string{"host": "server01", "team": "web"}, results[0].Tags())
+}
@@ -1,16 +1,27 @@
 package regex
 
 import (
+	"fmt"
+	"log"
 	"regexp"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
 
+// resultKeep is the result_key of a rename conversion that keeps an
+// existing tag or field with the new name instead of overwriting it.
+const resultKeep = "keep"
+
 type Regex struct {
-	Tags       []converter
-	Fields     []converter
-	regexCache map[string]*regexp.Regexp
+	Tags         []converter
+	Fields       []converter
+	TagRename    []converter `toml:"tag_rename"`
+	FieldRename  []converter `toml:"field_rename"`
+	MetricRename []converter `toml:"metric_rename"`
+	regexCache   map[string]*regexp.Regexp
+	filterCache  map[string]filter.Filter
 }
 
 type converter struct {
@@ -23,7 +34,7 @@ type converter struct {
 const sampleConfig = `
   ## Tag and field conversions defined in a separate sub-tables
   # [[processors.regex.tags]]
-  #   ## Tag to change
+  #   ## Tag to change, a glob selects all matching tags
   #   key = "resp_code"
   #   ## Regular expression to match on a tag value
   #   pattern = "^(\\d)\\d\\d$"
@@ -47,11 +58,35 @@ const sampleConfig = `
   #   pattern = ".*category=(\\w+).*"
   #   replacement = "${1}"
   #   result_key = "search_category"
+
+  ## Rename tag keys matching a regular expression, key selects the tags to
+  ## consider and defaults to all tags.
+  # [[processors.regex.tag_rename]]
+  #   ## Glob matching the tag keys to rename
+  #   key = "*"
+  #   ## Regular expression to match on a tag key
+  #   pattern = "^resp_(\\w+)$"
+  #   ## Pattern for constructing a new key (${1} represents first subgroup)
+  #   replacement = "response_${1}"
+  #   ## If the new key already exists, "overwrite" (the default) replaces it,
+  #   ## "keep" leaves both tags untouched
+  #   # result_key = "keep"
+
+  ## Field keys are renamed the same way
+  # [[processors.regex.field_rename]]
+  #   pattern = "^HeapMemoryUsage\\.(\\w+)$"
+  #   replacement = "heap_${1}"
+
+  ## Rename the measurement
+  # [[processors.regex.metric_rename]]
+  #   pattern = "^java_lang_(\\w+)$"
+  #   replacement = "jvm_${1}"
 `
 
 func NewRegex() *Regex {
 	return &Regex{
-		regexCache: make(map[string]*regexp.Regexp),
+		regexCache:  make(map[string]*regexp.Regexp),
+		filterCache: make(map[string]filter.Filter),
 	}
 }
 
@@ -60,40 +95,104 @@ func (r *Regex) SampleConfig() string {
 }
 
 func (r *Regex) Description() string {
-	return "Transforms tag and field values with regex pattern"
+	return "Transforms tag and field values as well as measurement, tag and field names with regex pattern"
+}
+
+// Init compiles the patterns and key globs of all conversions.
+func (r *Regex) Init() error {
+	for _, converters := range [][]converter{r.Tags, r.Fields, r.TagRename, r.FieldRename, r.MetricRename} {
+		for _, c := range converters {
+			if _, compiled := r.regexCache[c.Pattern]; !compiled {
+				regex, err := regexp.Compile(c.Pattern)
+				if err != nil {
+					return fmt.Errorf("invalid pattern %q: %v", c.Pattern, err)
+				}
+				r.regexCache[c.Pattern] = regex
+			}
+
+			if _, compiled := r.filterCache[c.Key]; !compiled && c.Key != "" {
+				f, err := filter.Compile([]string{c.Key})
+				if err != nil {
+					return fmt.Errorf("invalid key %q: %v", c.Key, err)
+				}
+				r.filterCache[c.Key] = f
+			}
+		}
+	}
+	return nil
 }
 
 func (r *Regex) Apply(in ...telegraf.Metric) []telegraf.Metric {
 	for _, metric := range in {
 		for _, converter := range r.Tags {
-			if value, ok := metric.GetTag(converter.Key); ok {
-				if key, newValue := r.convert(converter, value); newValue != "" {
+			keys := r.filter(converter.Key)
+			for _, tag := range tagList(metric) {
+				if !keys.Match(tag.Key) {
+					continue
+				}
+				if key, newValue := r.convert(converter, tag.Key, tag.Value); newValue != "" {
 					metric.AddTag(key, newValue)
 				}
 			}
 		}
 
 		for _, converter := range r.Fields {
-			if value, ok := metric.GetField(converter.Key); ok {
-				switch value := value.(type) {
+			keys := r.filter(converter.Key)
+			for _, field := range fieldList(metric) {
+				if !keys.Match(field.Key) {
+					continue
+				}
+				switch value := field.Value.(type) {
 				case string:
-					if key, newValue := r.convert(converter, value); newValue != "" {
+					if key, newValue := r.convert(converter, field.Key, value); newValue != "" {
 						metric.AddField(key, newValue)
 					}
 				}
 			}
 		}
+
+		for _, converter := range r.TagRename {
+			keys := r.renameFilter(converter.Key)
+			for _, tag := range tagList(metric) {
+				if !keys.Match(tag.Key) {
+					continue
+				}
+				newKey, ok := r.rename(converter, tag.Key)
+				if !ok || (converter.ResultKey == resultKeep && metric.HasTag(newKey)) {
+					continue
+				}
+				metric.RemoveTag(tag.Key)
+				metric.AddTag(newKey, tag.Value)
+			}
+		}
+
+		for _, converter := range r.FieldRename {
+			keys := r.renameFilter(converter.Key)
+			for _, field := range fieldList(metric) {
+				if !keys.Match(field.Key) {
+					continue
+				}
+				newKey, ok := r.rename(converter, field.Key)
+				if !ok || (converter.ResultKey == resultKeep && metric.HasField(newKey)) {
+					continue
+				}
+				metric.RemoveField(field.Key)
+				metric.AddField(newKey, field.Value)
+			}
+		}
+
+		for _, converter := range r.MetricRename {
+			if newName, ok := r.rename(converter, metric.Name()); ok {
+				metric.SetName(newName)
+			}
+		}
 	}
 
 	return in
 }
 
-func (r *Regex) convert(c converter, src string) (string, string) {
-	regex, compiled := r.regexCache[c.Pattern]
-	if !compiled {
-		regex = regexp.MustCompile(c.Pattern)
-		r.regexCache[c.Pattern] = regex
-	}
+func (r *Regex) convert(c converter, key string, src string) (string, string) {
+	regex := r.regex(c.Pattern)
 
 	value := ""
 	if c.ResultKey == "" || regex.MatchString(src) {
@@ -104,7 +203,81 @@ func (r *Regex) convert(c converter, src string) (string, string) {
 		return c.ResultKey, value
 	}
 
-	return c.Key, value
+	return key, value
+}
+
+// rename returns the new name for a tag key, field key or measurement name,
+// it returns false if the pattern does not match or the name is unchanged.
+func (r *Regex) rename(c converter, src string) (string, bool) {
+	regex := r.regex(c.Pattern)
+	if !regex.MatchString(src) {
+		return "", false
+	}
+
+	name := regex.ReplaceAllString(src, c.Replacement)
+	if name == "" || name == src {
+		return "", false
+	}
+	return name, true
+}
+
+func (r *Regex) regex(pattern string) *regexp.Regexp {
+	regex, compiled := r.regexCache[pattern]
+	if !compiled {
+		regex = regexp.MustCompile(pattern)
+		r.regexCache[pattern] = regex
+	}
+	return regex
+}
+
+// filter returns the filter for the glob selecting the keys a conversion
+// applies to.
+func (r *Regex) filter(key string) filter.Filter {
+	f, compiled := r.filterCache[key]
+	if !compiled {
+		var err error
+		f, err = filter.Compile([]string{key})
+		if err != nil {
+			log.Printf("E! [processors.regex] invalid key %q: %v", key, err)
+			f = noMatch{}
+		}
+		r.filterCache[key] = f
+	}
+	return f
+}
+
+// renameFilter is like filter, but an empty glob selects all keys.
+func (r *Regex) renameFilter(key string) filter.Filter {
+	if key == "" {
+		key = "*"
+	}
+	return r.filter(key)
+}
+
+// tagList returns a copy of the tags of the metric, so they can be modified
+// while iterating over them.
+func tagList(metric telegraf.Metric) []telegraf.Tag {
+	tags := make([]telegraf.Tag, 0, len(metric.TagList()))
+	for _, tag := range metric.TagList() {
+		tags = append(tags, *tag)
+	}
+	return tags
+}
+
+// fieldList returns a copy of the fields of the metric, so they can be
+// modified while iterating over them.
+func fieldList(metric telegraf.Metric) []telegraf.Field {
+	fields := make([]telegraf.Field, 0, len(metric.FieldList()))
+	for _, field := range metric.FieldList() {
+		fields = append(fields, *field)
+	}
+	return fields
+}
+
+type noMatch struct{}
+
+func (noMatch) Match(string) bool {
+	return false
 }
 
 func init() {
@@ -270,3 +270,222 @@ func BenchmarkConversions(b *testing.B) {
 		_ = processed
 	}
 }
+
+func TestGlobKeyConversions(t *testing.T) {
+	regex := NewRegex()
+	regex.Tags = []converter{
+		{
+			Key:         "*",
+			Pattern:     "^(\\d)\\d\\d$",
+			Replacement: "${1}xx",
+		},
+	}
+	regex.Fields = []converter{
+		{
+			Key:         "req*",
+			Pattern:     "^/users/\\d+/$",
+			Replacement: "/users/{id}/",
+		},
+	}
+
+	processed := regex.Apply(newM1())
+
+	expectedTags := map[string]string{
+		"verb":      "GET",
+		"resp_code": "2xx",
+	}
+	expectedFields := map[string]interface{}{
+		"request": "/users/{id}/",
+	}
+
+	assert.Equal(t, expectedTags, processed[0].Tags())
+	assert.Equal(t, expectedFields, processed[0].Fields())
+}
+
+func TestTagRenameConversions(t *testing.T) {
+	tests := []struct {
+		message      string
+		converter    converter
+		expectedTags map[string]string
+	}{
+		{
+			message: "Should change tag key",
+			converter: converter{
+				Pattern:     "^resp_(\\w+)$",
+				Replacement: "response_${1}",
+			},
+			expectedTags: map[string]string{
+				"verb":          "GET",
+				"response_code": "200",
+				"exists":        "yes",
+			},
+		},
+		{
+			message: "Should only change tags selected by key",
+			converter: converter{
+				Key:         "verb",
+				Pattern:     "^(\\w+)$",
+				Replacement: "method",
+			},
+			expectedTags: map[string]string{
+				"method":    "GET",
+				"resp_code": "200",
+				"exists":    "yes",
+			},
+		},
+		{
+			message: "Should overwrite existing tag by default",
+			converter: converter{
+				Pattern:     "^resp_code$",
+				Replacement: "exists",
+			},
+			expectedTags: map[string]string{
+				"verb":   "GET",
+				"exists": "200",
+			},
+		},
+		{
+			message: "Should keep existing tag with result_key keep",
+			converter: converter{
+				Pattern:     "^resp_code$",
+				Replacement: "exists",
+				ResultKey:   "keep",
+			},
+			expectedTags: map[string]string{
+				"verb":      "GET",
+				"resp_code": "200",
+				"exists":    "yes",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		regex := NewRegex()
+		regex.TagRename = []converter{
+			test.converter,
+		}
+
+		m := newM1()
+		m.AddTag("exists", "yes")
+		processed := regex.Apply(m)
+
+		expectedFields := map[string]interface{}{
+			"request": "/users/42/",
+		}
+
+		assert.Equal(t, expectedFields, processed[0].Fields(), test.message, "Should not change fields")
+		assert.Equal(t, test.expectedTags, processed[0].Tags(), test.message)
+		assert.Equal(t, "access_log", processed[0].Name(), "Should not change name")
+	}
+}
+
+func TestFieldRenameConversions(t *testing.T) {
+	tests := []struct {
+		message        string
+		converter      converter
+		expectedFields map[string]interface{}
+	}{
+		{
+			message: "Should change field keys",
+			converter: converter{
+				Pattern:     "^ignore_(\\w+)$",
+				Replacement: "${1}",
+			},
+			expectedFields: map[string]interface{}{
+				"request": "/api/search/?category=plugins&q=regex&sort=asc",
+				"number":  int64(200),
+				"bool":    true,
+			},
+		},
+		{
+			message: "Should not change field key to an empty string",
+			converter: converter{
+				Pattern:     "^request$",
+				Replacement: "",
+			},
+			expectedFields: map[string]interface{}{
+				"request":       "/api/search/?category=plugins&q=regex&sort=asc",
+				"ignore_number": int64(200),
+				"ignore_bool":   true,
+			},
+		},
+		{
+			message: "Should keep existing field with result_key keep",
+			converter: converter{
+				Key:         "ignore_number",
+				Pattern:     "^.*$",
+				Replacement: "ignore_bool",
+				ResultKey:   "keep",
+			},
+			expectedFields: map[string]interface{}{
+				"request":       "/api/search/?category=plugins&q=regex&sort=asc",
+				"ignore_number": int64(200),
+				"ignore_bool":   true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		regex := NewRegex()
+		regex.FieldRename = []converter{
+			test.converter,
+		}
+
+		processed := regex.Apply(newM2())
+
+		expectedTags := map[string]string{
+			"verb":      "GET",
+			"resp_code": "200",
+		}
+
+		assert.Equal(t, test.expectedFields, processed[0].Fields(), test.message)
+		assert.Equal(t, expectedTags, processed[0].Tags(), "Should not change tags")
+		assert.Equal(t, "access_log", processed[0].Name(), "Should not change name")
+	}
+}
+
+func TestMetricRenameConversions(t *testing.T) {
+	regex := NewRegex()
+	regex.MetricRename = []converter{
+		{
+			Pattern:     "^access_(\\w+)$",
+			Replacement: "${1}",
+		},
+		{
+			Pattern:     "^not_match$",
+			Replacement: "x",
+		},
+	}
+
+	processed := regex.Apply(newM1())
+
+	assert.Equal(t, "log", processed[0].Name())
+}
+
+func TestInit(t *testing.T) {
+	regex := NewRegex()
+	regex.Tags = []converter{
+		{
+			Key:     "resp_code",
+			Pattern: "^(\\d)\\d\\d$",
+		},
+	}
+	assert.NoError(t, regex.Init())
+
+	regex = NewRegex()
+	regex.FieldRename = []converter{
+		{
+			Pattern: "^(\\d",
+		},
+	}
+	assert.Error(t, regex.Init())
+
+	regex = NewRegex()
+	regex.Fields = []converter{
+		{
+			Key:     "request[",
+			Pattern: ".*",
+		},
+	}
+	assert.Error(t, regex.Init())
+}
@@ -0,0 +1,97 @@
+package reverse_dns
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// cacheEntry is a single resolved address. An empty name marks an address
+// that could not be resolved.
+type cacheEntry struct {
+	addr    string
+	name    string
+	expires time.Time
+}
+
+// lookupCache is a size bounded least-recently-used cache of reverse lookup
+// results, where each entry is valid until its ttl expired.
+type lookupCache struct {
+	sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newLookupCache(size int) *lookupCache {
+	return &lookupCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached name of the address, if an entry existed, and if
+// the entry has expired.  An expired entry is kept until it is refreshed or
+// evicted, so that its name can still be used while the address is looked up
+// again.
+func (c *lookupCache) get(addr string) (string, bool, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	elem, ok := c.entries[addr]
+	if !ok {
+		return "", false, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	c.order.MoveToFront(elem)
+	return entry.name, true, time.Now().After(entry.expires)
+}
+
+// put stores the name of the address for the ttl, evicting the least recently
+// used entry if the cache is full.
+func (c *lookupCache) put(addr string, name string, ttl time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	expires := time.Now().Add(ttl)
+	if elem, ok := c.entries[addr]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.name = name
+		entry.expires = expires
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[addr] = c.order.PushFront(&cacheEntry{addr: addr, name: name, expires: expires})
+	for c.size > 0 && c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).addr)
+	}
+}
+
+// putFailed stores a failed lookup of the address for the ttl.  A name known
+// from an earlier lookup is kept, so that a failed refresh does not remove it.
+func (c *lookupCache) putFailed(addr string, ttl time.Duration) {
+	c.Lock()
+	if elem, ok := c.entries[addr]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if entry.name != "" {
+			entry.expires = time.Now().Add(ttl)
+			c.order.MoveToFront(elem)
+			c.Unlock()
+			return
+		}
+	}
+	c.Unlock()
+
+	c.put(addr, "", ttl)
+}
+
+func (c *lookupCache) len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.order.Len()
+}
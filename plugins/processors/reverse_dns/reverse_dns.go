@@ -0,0 +1,210 @@
+package reverse_dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## For optimal performance, you may want to limit which metrics are passed to this
+  ## processor. eg:
+  ## namepass = ["my_metric_*"]
+
+  ## How long a resolved name is cached.
+  # cache_ttl = "24h"
+
+  ## How long a failed lookup is cached, before the address is looked up
+  ## again.
+  # negative_cache_ttl = "1m"
+
+  ## Maximum number of addresses kept in the cache, the least recently used
+  ## entries are evicted first.
+  # cache_size = 10000
+
+  ## Maximum time of a lookup, after which it is cancelled and counted as
+  ## failed.
+  # lookup_timeout = "3s"
+
+  ## Maximum number of lookups in flight.  If all are in use, no lookup is
+  ## started for further uncached addresses until one finishes.
+  # max_parallel_lookups = 10
+
+  [[processors.reverse_dns.lookup]]
+    ## get the ip from the field "source_ip", and put the result in the field "source_name"
+    field = "source_ip"
+    dest = "source_name"
+
+  [[processors.reverse_dns.lookup]]
+    ## get the ip from the tag "destination_ip", and put the result in the tag
+    ## "destination_name".
+    tag = "destination_ip"
+    dest = "destination_name"
+`
+
+// Resolver looks up the names of an address, it is satisfied by *net.Resolver.
+type Resolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+type Lookup struct {
+	Tag   string `toml:"tag"`
+	Field string `toml:"field"`
+	Dest  string `toml:"dest"`
+}
+
+type ReverseDNS struct {
+	Lookups            []Lookup          `toml:"lookup"`
+	CacheTTL           internal.Duration `toml:"cache_ttl"`
+	NegativeCacheTTL   internal.Duration `toml:"negative_cache_ttl"`
+	CacheSize          int               `toml:"cache_size"`
+	LookupTimeout      internal.Duration `toml:"lookup_timeout"`
+	MaxParallelLookups int               `toml:"max_parallel_lookups"`
+
+	resolver Resolver
+	cache    *lookupCache
+	sem      chan struct{}
+
+	sync.Mutex
+	pending map[string]bool
+}
+
+func NewReverseDNS() *ReverseDNS {
+	return &ReverseDNS{
+		CacheTTL:           internal.Duration{Duration: 24 * time.Hour},
+		NegativeCacheTTL:   internal.Duration{Duration: time.Minute},
+		CacheSize:          10000,
+		LookupTimeout:      internal.Duration{Duration: 3 * time.Second},
+		MaxParallelLookups: 10,
+		resolver:           net.DefaultResolver,
+	}
+}
+
+func (r *ReverseDNS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *ReverseDNS) Description() string {
+	return "Resolve IP addresses in tags or fields to hostnames using reverse DNS lookups."
+}
+
+// Apply adds the cached names of the addresses to the metrics.  Lookups of
+// the addresses not yet cached are started in the background, without
+// waiting for them, so that the names are added to the next metrics.
+func (r *ReverseDNS) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if r.cache == nil {
+		r.init()
+	}
+
+	for _, metric := range in {
+		for _, lookup := range r.Lookups {
+			addr, ok := lookup.source(metric)
+			if !ok {
+				continue
+			}
+			// An expired name is still used while it is refreshed.
+			name, ok, expired := r.cache.get(addr)
+			if !ok || expired {
+				r.resolve(addr)
+			}
+			if name != "" {
+				lookup.setDest(metric, name)
+			}
+		}
+	}
+	return in
+}
+
+func (r *ReverseDNS) init() {
+	if r.resolver == nil {
+		r.resolver = net.DefaultResolver
+	}
+	if r.MaxParallelLookups < 1 {
+		r.MaxParallelLookups = 1
+	}
+	r.cache = newLookupCache(r.CacheSize)
+	r.sem = make(chan struct{}, r.MaxParallelLookups)
+	r.pending = make(map[string]bool)
+}
+
+// resolve starts a background lookup of the address, caching its result.
+// No lookup is started if one is already running for the address, or if the
+// maximum number of lookups is in flight.
+func (r *ReverseDNS) resolve(addr string) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.pending[addr] {
+		return
+	}
+
+	select {
+	case r.sem <- struct{}{}:
+	default:
+		return
+	}
+
+	r.pending[addr] = true
+
+	go func() {
+		defer func() { <-r.sem }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), r.LookupTimeout.Duration)
+		defer cancel()
+
+		// Failed lookups, timeouts included, are only cached briefly so that
+		// a transient failure does not leave the address unresolved for long.
+		// A failed refresh keeps the name resolved before.
+		names, err := r.resolver.LookupAddr(ctx, addr)
+		if err == nil && len(names) > 0 {
+			r.cache.put(addr, strings.TrimSuffix(names[0], "."), r.CacheTTL.Duration)
+		} else {
+			r.cache.putFailed(addr, r.NegativeCacheTTL.Duration)
+		}
+
+		r.Lock()
+		delete(r.pending, addr)
+		r.Unlock()
+	}()
+}
+
+// source returns the address to resolve from the metric.
+func (l *Lookup) source(metric telegraf.Metric) (string, bool) {
+	if l.Dest == "" {
+		return "", false
+	}
+
+	if l.Tag != "" {
+		return metric.GetTag(l.Tag)
+	}
+
+	if l.Field != "" {
+		if value, ok := metric.GetField(l.Field); ok {
+			if addr, ok := value.(string); ok {
+				return addr, true
+			}
+		}
+	}
+	return "", false
+}
+
+// setDest stores the name using the same kind, tag or field, as the source.
+func (l *Lookup) setDest(metric telegraf.Metric, name string) {
+	if l.Tag != "" {
+		metric.AddTag(l.Dest, name)
+		return
+	}
+	metric.AddField(l.Dest, name)
+}
+
+func init() {
+	processors.Add("reverse_dns", func() telegraf.Processor {
+		return NewReverseDNS()
+	})
+}
@@ -0,0 +1,263 @@
+package reverse_dns
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/require"
+)
+
+type stubResolver struct {
+	sync.Mutex
+	names map[string]string
+	delay time.Duration
+	calls int
+}
+
+func (s *stubResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	s.Lock()
+	s.calls++
+	delay := s.delay
+	s.Unlock()
+
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if name, ok := s.names[addr]; ok {
+		return []string{name}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (s *stubResolver) Calls() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.calls
+}
+
+func (s *stubResolver) SetDelay(delay time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	s.delay = delay
+}
+
+func newReverseDNS(resolver Resolver) *ReverseDNS {
+	r := NewReverseDNS()
+	r.resolver = resolver
+	r.Lookups = []Lookup{
+		{Tag: "source_ip", Dest: "source_name"},
+		{Field: "destination_ip", Dest: "destination_name"},
+	}
+	return r
+}
+
+func newMetric(source string, destination string) telegraf.Metric {
+	m, _ := metric.New("conntrack",
+		map[string]string{"source_ip": source},
+		map[string]interface{}{"destination_ip": destination},
+		time.Unix(0, 0),
+	)
+	return m
+}
+
+// waitForLookups waits for the lookups in flight to be cached.
+func waitForLookups(t *testing.T, r *ReverseDNS) {
+	for i := 0; i < 100; i++ {
+		r.Lock()
+		n := len(r.pending)
+		r.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("lookups did not finish")
+}
+
+func TestResolveTagAndField(t *testing.T) {
+	resolver := &stubResolver{names: map[string]string{
+		"10.0.0.1": "localhost.",
+		"10.0.0.2": "dns.google.",
+	}}
+	r := newReverseDNS(resolver)
+
+	// The addresses are not cached yet, the metric is passed on without
+	// waiting for their lookups.
+	results := r.Apply(newMetric("10.0.0.1", "10.0.0.2"))
+	require.Len(t, results, 1)
+	require.False(t, results[0].HasTag("source_name"))
+	require.False(t, results[0].HasField("destination_name"))
+
+	waitForLookups(t, r)
+	results = r.Apply(newMetric("10.0.0.1", "10.0.0.2"))
+	require.Len(t, results, 1)
+
+	name, ok := results[0].GetTag("source_name")
+	require.True(t, ok)
+	require.Equal(t, "localhost", name)
+
+	value, ok := results[0].GetField("destination_name")
+	require.True(t, ok)
+	require.Equal(t, "dns.google", value)
+}
+
+func TestUnresolvableAddress(t *testing.T) {
+	resolver := &stubResolver{names: map[string]string{}}
+	r := newReverseDNS(resolver)
+	r.NegativeCacheTTL = internal.Duration{Duration: 50 * time.Millisecond}
+
+	r.Apply(newMetric("10.0.0.1", "10.0.0.2"))
+	waitForLookups(t, r)
+
+	// failed lookups are cached for the negative ttl
+	results := r.Apply(newMetric("10.0.0.1", "10.0.0.2"))
+	require.False(t, results[0].HasTag("source_name"))
+	require.False(t, results[0].HasField("destination_name"))
+	require.Equal(t, 2, resolver.Calls())
+
+	time.Sleep(100 * time.Millisecond)
+	r.Apply(newMetric("10.0.0.1", "10.0.0.2"))
+	waitForLookups(t, r)
+	require.Equal(t, 4, resolver.Calls())
+}
+
+func TestCachedLookup(t *testing.T) {
+	resolver := &stubResolver{names: map[string]string{"10.0.0.1": "localhost."}}
+	r := newReverseDNS(resolver)
+
+	r.Apply(newMetric("10.0.0.1", "10.0.0.1"))
+	waitForLookups(t, r)
+	for i := 0; i < 3; i++ {
+		results := r.Apply(newMetric("10.0.0.1", "10.0.0.1"))
+		require.True(t, results[0].HasTag("source_name"))
+		require.True(t, results[0].HasField("destination_name"))
+	}
+	require.Equal(t, 1, resolver.Calls())
+}
+
+func TestSlowResolverDoesNotBlock(t *testing.T) {
+	resolver := &stubResolver{
+		names: map[string]string{"10.0.0.1": "localhost."},
+		delay: time.Hour,
+	}
+	r := newReverseDNS(resolver)
+
+	start := time.Now()
+	results := r.Apply(newMetric("10.0.0.1", "10.0.0.1"))
+	require.True(t, time.Since(start) < time.Second)
+	require.False(t, results[0].HasTag("source_name"))
+	require.False(t, results[0].HasField("destination_name"))
+}
+
+func TestLookupTimeout(t *testing.T) {
+	resolver := &stubResolver{
+		names: map[string]string{"10.0.0.1": "localhost."},
+		delay: time.Hour,
+	}
+	r := newReverseDNS(resolver)
+	r.LookupTimeout = internal.Duration{Duration: 10 * time.Millisecond}
+	r.NegativeCacheTTL = internal.Duration{Duration: 50 * time.Millisecond}
+
+	r.Apply(newMetric("10.0.0.1", "10.0.0.1"))
+	waitForLookups(t, r)
+	require.Equal(t, 1, resolver.Calls())
+
+	// A timed out lookup is retried once its negative ttl expired.
+	resolver.SetDelay(0)
+	time.Sleep(100 * time.Millisecond)
+	r.Apply(newMetric("10.0.0.1", "10.0.0.1"))
+	waitForLookups(t, r)
+
+	results := r.Apply(newMetric("10.0.0.1", "10.0.0.1"))
+	require.True(t, results[0].HasTag("source_name"))
+	require.Equal(t, 2, resolver.Calls())
+}
+
+func TestMaxParallelLookups(t *testing.T) {
+	resolver := &stubResolver{
+		names: map[string]string{"10.0.0.1": "localhost.", "10.0.0.2": "dns.google."},
+		delay: 50 * time.Millisecond,
+	}
+	r := newReverseDNS(resolver)
+	r.MaxParallelLookups = 1
+
+	r.Apply(newMetric("10.0.0.1", "10.0.0.2"))
+	waitForLookups(t, r)
+	require.Equal(t, 1, resolver.Calls())
+
+	results := r.Apply(newMetric("10.0.0.1", "10.0.0.2"))
+	require.True(t, results[0].HasTag("source_name"))
+	require.False(t, results[0].HasField("destination_name"))
+	waitForLookups(t, r)
+	require.Equal(t, 2, resolver.Calls())
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := newLookupCache(2)
+	c.put("10.0.0.1", "a", time.Hour)
+	c.put("10.0.0.2", "b", time.Hour)
+
+	// touch the first entry, so the second is the least recently used one
+	_, ok, _ := c.get("10.0.0.1")
+	require.True(t, ok)
+
+	c.put("10.0.0.3", "c", time.Hour)
+	require.Equal(t, 2, c.len())
+
+	_, ok, _ = c.get("10.0.0.2")
+	require.False(t, ok)
+	name, ok, _ := c.get("10.0.0.1")
+	require.True(t, ok)
+	require.Equal(t, "a", name)
+}
+
+func TestCacheExpiry(t *testing.T) {
+	c := newLookupCache(2)
+	c.put("10.0.0.1", "a", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// the expired entry is returned until it is refreshed
+	name, ok, expired := c.get("10.0.0.1")
+	require.True(t, ok)
+	require.True(t, expired)
+	require.Equal(t, "a", name)
+
+	// a failed refresh keeps the name
+	c.putFailed("10.0.0.1", time.Hour)
+	name, ok, expired = c.get("10.0.0.1")
+	require.True(t, ok)
+	require.False(t, expired)
+	require.Equal(t, "a", name)
+}
+
+func TestStaleNameWhileRefreshing(t *testing.T) {
+	resolver := &stubResolver{names: map[string]string{"10.0.0.1": "localhost."}}
+	r := newReverseDNS(resolver)
+	r.CacheTTL = internal.Duration{Duration: 10 * time.Millisecond}
+
+	r.Apply(newMetric("10.0.0.1", "10.0.0.1"))
+	waitForLookups(t, r)
+	time.Sleep(20 * time.Millisecond)
+
+	// The refresh fails, but the expired name is used meanwhile and kept.
+	resolver.Lock()
+	delete(resolver.names, "10.0.0.1")
+	resolver.Unlock()
+	results := r.Apply(newMetric("10.0.0.1", "10.0.0.1"))
+	require.True(t, results[0].HasTag("source_name"))
+	waitForLookups(t, r)
+	require.Equal(t, 2, resolver.Calls())
+
+	results = r.Apply(newMetric("10.0.0.1", "10.0.0.1"))
+	name, _ := results[0].GetTag("source_name")
+	require.Equal(t, "localhost", name)
+}
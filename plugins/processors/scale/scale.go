@@ -0,0 +1,201 @@
+package scale
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## It is possible to define multiple different scaling that can be applied
+  ## to different sets of fields. Each scaling expects the following
+  ## arguments:
+  ##   - input_minimum: Minimum expected input value
+  ##   - input_maximum: Maximum expected input value
+  ##   - output_minimum: Minimum desired output value
+  ##   - output_maximum: Maximum desired output value
+  ##   - clamp: If true, results are limited to the output range
+  ## alternatively you can specify a scaling with factor and offset
+  ##   - factor: factor to scale the input value with
+  ##   - offset: additive offset for value after scaling
+  ##   - fields: a list of field names (or filters) to apply this scaling to
+
+  ## Example: Scaling with minimum and maximum values
+  # [[processors.scale.scaling]]
+  #    input_minimum = 0.0
+  #    input_maximum = 1.0
+  #    output_minimum = 0.0
+  #    output_maximum = 100.0
+  #    clamp = true
+  #    fields = ["temperature1", "temperature2"]
+
+  ## Example: Scaling with factor and offset
+  # [[processors.scale.scaling]]
+  #    factor = 10.0
+  #    offset = -5.0
+  #    fields = ["voltage*"]
+`
+
+type Scaling struct {
+	InMin  *float64 `toml:"input_minimum"`
+	InMax  *float64 `toml:"input_maximum"`
+	OutMin *float64 `toml:"output_minimum"`
+	OutMax *float64 `toml:"output_maximum"`
+	Clamp  bool     `toml:"clamp"`
+	Factor *float64 `toml:"factor"`
+	Offset *float64 `toml:"offset"`
+	Fields []string `toml:"fields"`
+
+	factor      float64
+	offset      float64
+	fieldFilter filter.Filter
+}
+
+type Scale struct {
+	Scalings []*Scaling `toml:"scaling"`
+
+	initialized bool
+}
+
+func (s *Scale) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Scale) Description() string {
+	return "Scale values with a predefined range to a different output range."
+}
+
+// Init compiles the field filters of the scalings and checks that each uses
+// either factor and offset or the minimum and maximum values.
+func (s *Scale) Init() error {
+	if s.initialized {
+		return nil
+	}
+	return s.compile()
+}
+
+func (s *Scale) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if !s.initialized {
+		err := s.compile()
+		if err != nil {
+			log.Printf("E! [processors.scale] initialization error: %v", err)
+			return in
+		}
+	}
+
+	for _, metric := range in {
+		for _, scaling := range s.Scalings {
+			scaling.process(metric)
+		}
+	}
+	return in
+}
+
+func (s *Scale) compile() error {
+	for i, scaling := range s.Scalings {
+		if err := scaling.init(); err != nil {
+			return fmt.Errorf("scaling %d: %v", i+1, err)
+		}
+	}
+
+	s.initialized = true
+	return nil
+}
+
+func (s *Scaling) init() error {
+	if len(s.Fields) == 0 {
+		return fmt.Errorf("no fields specified")
+	}
+
+	var err error
+	s.fieldFilter, err = filter.Compile(s.Fields)
+	if err != nil {
+		return fmt.Errorf("could not compile fields filter: %v", err)
+	}
+
+	allMinMaxSet := s.OutMax != nil && s.OutMin != nil && s.InMax != nil && s.InMin != nil
+	anyMinMaxSet := s.OutMax != nil || s.OutMin != nil || s.InMax != nil || s.InMin != nil
+	factorSet := s.Factor != nil || s.Offset != nil
+
+	switch {
+	case anyMinMaxSet && factorSet:
+		return fmt.Errorf("cannot use factor/offset and minimum/maximum at the same time")
+	case anyMinMaxSet && !allMinMaxSet:
+		return fmt.Errorf("all minimum and maximum values need to be set")
+	case allMinMaxSet:
+		if *s.InMax == *s.InMin {
+			return fmt.Errorf("input minimum and maximum are equal")
+		}
+		s.factor = (*s.OutMax - *s.OutMin) / (*s.InMax - *s.InMin)
+		s.offset = *s.OutMin - (s.factor * *s.InMin)
+	case factorSet:
+		s.factor = 1.0
+		if s.Factor != nil {
+			s.factor = *s.Factor
+		}
+		if s.Offset != nil {
+			s.offset = *s.Offset
+		}
+		if s.Clamp {
+			return fmt.Errorf("clamp requires minimum and maximum values")
+		}
+	default:
+		return fmt.Errorf("no scaling defined")
+	}
+
+	return nil
+}
+
+// process scales all matching numeric fields of the metric.
+func (s *Scaling) process(metric telegraf.Metric) {
+	for _, field := range metric.FieldList() {
+		if !s.fieldFilter.Match(field.Key) {
+			continue
+		}
+
+		var value float64
+		switch v := field.Value.(type) {
+		case float64:
+			value = v
+		case int64:
+			value = float64(v)
+		case uint64:
+			value = float64(v)
+		default:
+			log.Printf("D! [processors.scale] field %q of type %T is not numeric", field.Key, field.Value)
+			continue
+		}
+
+		metric.AddField(field.Key, s.scale(value))
+	}
+}
+
+func (s *Scaling) scale(value float64) float64 {
+	result := value*s.factor + s.offset
+	if !s.Clamp {
+		return result
+	}
+
+	// the output range might be inverted
+	lower, upper := *s.OutMin, *s.OutMax
+	if lower > upper {
+		lower, upper = upper, lower
+	}
+
+	if result < lower {
+		return lower
+	}
+	if result > upper {
+		return upper
+	}
+	return result
+}
+
+func init() {
+	processors.Add("scale", func() telegraf.Processor {
+		return &Scale{}
+	})
+}
@@ -0,0 +1,187 @@
+package scale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestScaler(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		scale    []*Scaling
+		inputs   []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "range",
+			scale: []*Scaling{
+				{
+					InMin:  floatPtr(-1),
+					InMax:  floatPtr(1),
+					OutMin: floatPtr(0),
+					OutMax: floatPtr(100),
+					Fields: []string{"test1", "test2"},
+				},
+				{
+					InMin:  floatPtr(0),
+					InMax:  floatPtr(4096),
+					OutMin: floatPtr(0),
+					OutMax: floatPtr(10),
+					Fields: []string{"adc*"},
+				},
+			},
+			inputs: []telegraf.Metric{
+				testutil.MustMetric("Name1", map[string]string{},
+					map[string]interface{}{
+						"test1": int64(0),
+						"test2": uint64(1),
+						"adc0":  int64(2048),
+						"adc1":  int64(4096),
+						"other": "unchanged",
+					}, now),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("Name1", map[string]string{},
+					map[string]interface{}{
+						"test1": float64(50),
+						"test2": float64(100),
+						"adc0":  float64(5),
+						"adc1":  float64(10),
+						"other": "unchanged",
+					}, now),
+			},
+		},
+		{
+			name: "clamp",
+			scale: []*Scaling{
+				{
+					InMin:  floatPtr(0),
+					InMax:  floatPtr(50),
+					OutMin: floatPtr(100),
+					OutMax: floatPtr(0),
+					Clamp:  true,
+					Fields: []string{"test*"},
+				},
+			},
+			inputs: []telegraf.Metric{
+				testutil.MustMetric("Name1", map[string]string{},
+					map[string]interface{}{
+						"test1": int64(-10),
+						"test2": int64(25),
+						"test3": int64(100),
+					}, now),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("Name1", map[string]string{},
+					map[string]interface{}{
+						"test1": float64(100),
+						"test2": float64(50),
+						"test3": float64(0),
+					}, now),
+			},
+		},
+		{
+			name: "factor and offset",
+			scale: []*Scaling{
+				{
+					Factor: floatPtr(0.1),
+					Fields: []string{"decivolts"},
+				},
+				{
+					Factor: floatPtr(1.8),
+					Offset: floatPtr(32),
+					Fields: []string{"temperature"},
+				},
+				{
+					Offset: floatPtr(-273.15),
+					Fields: []string{"kelvin"},
+				},
+			},
+			inputs: []telegraf.Metric{
+				testutil.MustMetric("sensors", map[string]string{},
+					map[string]interface{}{
+						"decivolts":   int64(120),
+						"temperature": float64(100),
+						"kelvin":      float64(373.15),
+					}, now),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("sensors", map[string]string{},
+					map[string]interface{}{
+						"decivolts":   float64(12),
+						"temperature": float64(212),
+						"kelvin":      float64(100),
+					}, now),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Scale{Scalings: tt.scale}
+			actual := plugin.Apply(tt.inputs...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestInvalidScaling(t *testing.T) {
+	tests := []struct {
+		name    string
+		scaling *Scaling
+	}{
+		{
+			name:    "no fields",
+			scaling: &Scaling{Factor: floatPtr(2)},
+		},
+		{
+			name:    "no scaling",
+			scaling: &Scaling{Fields: []string{"a"}},
+		},
+		{
+			name:    "partial range",
+			scaling: &Scaling{InMin: floatPtr(0), InMax: floatPtr(1), Fields: []string{"a"}},
+		},
+		{
+			name: "range and factor",
+			scaling: &Scaling{
+				InMin:  floatPtr(0),
+				InMax:  floatPtr(1),
+				OutMin: floatPtr(0),
+				OutMax: floatPtr(1),
+				Factor: floatPtr(2),
+				Fields: []string{"a"},
+			},
+		},
+		{
+			name: "empty input range",
+			scaling: &Scaling{
+				InMin:  floatPtr(1),
+				InMax:  floatPtr(1),
+				OutMin: floatPtr(0),
+				OutMax: floatPtr(1),
+				Fields: []string{"a"},
+			},
+		},
+		{
+			name:    "clamp without range",
+			scaling: &Scaling{Factor: floatPtr(2), Clamp: true, Fields: []string{"a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Scale{Scalings: []*Scaling{tt.scaling}}
+			require.Error(t, plugin.compile())
+		})
+	}
+}
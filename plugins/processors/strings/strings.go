@@ -1,22 +1,32 @@
 package strings
 
 import (
+	"bytes"
+	"encoding/base64"
+	"log"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
 
 type Strings struct {
-	Lowercase  []converter `toml:"lowercase"`
-	Uppercase  []converter `toml:"uppercase"`
-	Trim       []converter `toml:"trim"`
-	TrimLeft   []converter `toml:"trim_left"`
-	TrimRight  []converter `toml:"trim_right"`
-	TrimPrefix []converter `toml:"trim_prefix"`
-	TrimSuffix []converter `toml:"trim_suffix"`
-	Replace    []converter `toml:"replace"`
+	Lowercase    []converter `toml:"lowercase"`
+	Uppercase    []converter `toml:"uppercase"`
+	Trim         []converter `toml:"trim"`
+	TrimLeft     []converter `toml:"trim_left"`
+	TrimRight    []converter `toml:"trim_right"`
+	TrimPrefix   []converter `toml:"trim_prefix"`
+	TrimSuffix   []converter `toml:"trim_suffix"`
+	Replace      []converter `toml:"replace"`
+	Base64Decode []converter `toml:"base64decode"`
+	ValidUTF8    []converter `toml:"valid_utf8"`
+	Left         []converter `toml:"left"`
+	PadLeft      []converter `toml:"pad_left"`
+	PadRight     []converter `toml:"pad_right"`
 
 	converters []converter
 	init       bool
@@ -34,8 +44,14 @@ type converter struct {
 	Prefix      string
 	Old         string
 	New         string
-
-	fn ConvertFunc
+	Replacement string
+	Width       int
+	Padding     string
+
+	fn                ConvertFunc
+	fieldFilter       filter.Filter
+	tagFilter         filter.Filter
+	measurementFilter filter.Filter
 }
 
 const sampleConfig = `
@@ -77,6 +93,28 @@ const sampleConfig = `
   #   measurement = "*"
   #   old = ":"
   #   new = "_"
+
+  ## Decode a base64 encoded utf-8 string
+  # [[processors.strings.base64decode]]
+  #   field = "message"
+
+  ## Replace invalid utf-8 sequences in all tags with the replacement, which
+  ## defaults to the unicode replacement character
+  # [[processors.strings.valid_utf8]]
+  #   tag = "*"
+  #   replacement = "?"
+
+  ## Trims strings based on width
+  # [[processors.strings.left]]
+  #   field = "message"
+  #   width = 10
+
+  ## Pad strings to width on the left, or on the right with pad_right, using
+  ## the padding characters which default to a space
+  # [[processors.strings.pad_left]]
+  #   tag = "port"
+  #   width = 5
+  #   padding = "0"
 `
 
 func (s *Strings) SampleConfig() string {
@@ -88,21 +126,16 @@ func (s *Strings) Description() string {
 }
 
 func (c *converter) convertTag(metric telegraf.Metric) {
-	var tags map[string]string
-	if c.Tag == "*" {
-		tags = metric.Tags()
-	} else {
-		tags = make(map[string]string)
-		tv, ok := metric.GetTag(c.Tag)
-		if !ok {
-			return
+	tags := make(map[string]string)
+	for _, tag := range metric.TagList() {
+		if c.tagFilter.Match(tag.Key) {
+			tags[tag.Key] = tag.Value
 		}
-		tags[c.Tag] = tv
 	}
 
 	for key, value := range tags {
 		dest := key
-		if c.Tag != "*" && c.Dest != "" {
+		if !isGlob(c.Tag) && c.Dest != "" {
 			dest = c.Dest
 		}
 		metric.AddTag(dest, c.fn(value))
@@ -110,21 +143,16 @@ func (c *converter) convertTag(metric telegraf.Metric) {
 }
 
 func (c *converter) convertField(metric telegraf.Metric) {
-	var fields map[string]interface{}
-	if c.Field == "*" {
-		fields = metric.Fields()
-	} else {
-		fields = make(map[string]interface{})
-		fv, ok := metric.GetField(c.Field)
-		if !ok {
-			return
+	fields := make(map[string]interface{})
+	for _, field := range metric.FieldList() {
+		if c.fieldFilter.Match(field.Key) {
+			fields[field.Key] = field.Value
 		}
-		fields[c.Field] = fv
 	}
 
 	for key, value := range fields {
 		dest := key
-		if c.Field != "*" && c.Dest != "" {
+		if !isGlob(c.Field) && c.Dest != "" {
 			dest = c.Dest
 		}
 		if fv, ok := value.(string); ok {
@@ -134,7 +162,7 @@ func (c *converter) convertField(metric telegraf.Metric) {
 }
 
 func (c *converter) convertMeasurement(metric telegraf.Metric) {
-	if metric.Name() != c.Measurement && c.Measurement != "*" {
+	if !c.measurementFilter.Match(metric.Name()) {
 		return
 	}
 
@@ -155,6 +183,27 @@ func (c *converter) convert(metric telegraf.Metric) {
 	}
 }
 
+// compile compiles the tag, field and measurement globs of the converter.
+func (c *converter) compile() error {
+	var err error
+	if c.fieldFilter, err = filter.Compile([]string{c.Field}); err != nil {
+		return err
+	}
+	if c.tagFilter, err = filter.Compile([]string{c.Tag}); err != nil {
+		return err
+	}
+	if c.measurementFilter, err = filter.Compile([]string{c.Measurement}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// isGlob reports whether the key is a glob pattern, possibly matching
+// multiple keys.
+func isGlob(key string) bool {
+	return strings.ContainsAny(key, "*?[")
+}
+
 func (s *Strings) initOnce() {
 	if s.init {
 		return
@@ -218,10 +267,102 @@ func (s *Strings) initOnce() {
 		}
 		s.converters = append(s.converters, c)
 	}
+	for _, c := range s.Base64Decode {
+		c.fn = func(s string) string {
+			data, err := base64.StdEncoding.DecodeString(s)
+			if err != nil || !utf8.Valid(data) {
+				return s
+			}
+			return string(data)
+		}
+		s.converters = append(s.converters, c)
+	}
+	for _, c := range s.ValidUTF8 {
+		c := c
+		if c.Replacement == "" {
+			c.Replacement = string(utf8.RuneError)
+		}
+		c.fn = func(s string) string { return toValidUTF8(s, c.Replacement) }
+		s.converters = append(s.converters, c)
+	}
+	for _, c := range s.Left {
+		c := c
+		c.fn = func(s string) string {
+			if c.Width < 1 || utf8.RuneCountInString(s) <= c.Width {
+				return s
+			}
+			return string([]rune(s)[:c.Width])
+		}
+		s.converters = append(s.converters, c)
+	}
+	for _, c := range s.PadLeft {
+		c := c
+		c.fn = func(s string) string { return padding(s, c.Width, c.Padding) + s }
+		s.converters = append(s.converters, c)
+	}
+	for _, c := range s.PadRight {
+		c := c
+		c.fn = func(s string) string { return s + padding(s, c.Width, c.Padding) }
+		s.converters = append(s.converters, c)
+	}
+
+	// Converters with invalid globs would never match, drop them so they
+	// are not evaluated for every metric.
+	converters := s.converters[:0]
+	for _, c := range s.converters {
+		if err := c.compile(); err != nil {
+			log.Printf("E! [processors.strings] invalid glob in conversion: %v", err)
+			continue
+		}
+		converters = append(converters, c)
+	}
+	s.converters = converters
 
 	s.init = true
 }
 
+// toValidUTF8 returns s with each run of invalid utf-8 byte sequences
+// replaced by the replacement string.
+func toValidUTF8(s string, replacement string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+
+	var b bytes.Buffer
+	invalid := false
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			if !invalid {
+				b.WriteString(replacement)
+				invalid = true
+			}
+			i++
+			continue
+		}
+		b.WriteString(s[i : i+size])
+		invalid = false
+		i += size
+	}
+	return b.String()
+}
+
+// padding returns the padding needed to extend s to width characters,
+// repeating the pad characters, which default to a space, as needed.
+func padding(s string, width int, pad string) string {
+	if pad == "" {
+		pad = " "
+	}
+
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return ""
+	}
+
+	p := []rune(strings.Repeat(pad, n))
+	return string(p[:n])
+}
+
 func (s *Strings) Apply(in ...telegraf.Metric) []telegraf.Metric {
 	s.initOnce()
 
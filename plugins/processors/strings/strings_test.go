@@ -543,3 +543,203 @@ func TestMeasurementCharDeletion(t *testing.T) {
 	assert.Equal(t, "foofoofoo", results[1].Name(), "Should have refused to delete the whole string")
 	assert.Equal(t, "barbarbar", results[2].Name(), "Should not have changed the input")
 }
+
+func TestGlobConversions(t *testing.T) {
+	plugin := &Strings{
+		Uppercase: []converter{
+			{
+				Tag:  "s-*",
+				Dest: "ignored",
+			},
+			{
+				Field: "req*",
+			},
+			{
+				Measurement: "IIS_*",
+			},
+		},
+	}
+
+	processed := plugin.Apply(newM1())
+
+	expectedFields := map[string]interface{}{
+		"request":    "/MIXED/CASE/PATH/?FROM=-1D&TO=NOW",
+		"whitespace": "  whitespace\t",
+	}
+	expectedTags := map[string]string{
+		"verb":           "GET",
+		"s-computername": "MIXEDCASE_HOSTNAME",
+	}
+
+	assert.Equal(t, expectedFields, processed[0].Fields())
+	assert.Equal(t, expectedTags, processed[0].Tags())
+	assert.Equal(t, "IIS_LOG", processed[0].Name())
+}
+
+func TestBase64Decode(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			name:     "should decode a valid base64 string",
+			value:    "aG93ZHk=",
+			expected: "howdy",
+		},
+		{
+			name:     "should keep an invalid base64 string",
+			value:    "not base64",
+			expected: "not base64",
+		},
+		{
+			name:     "should keep a string decoding to invalid utf-8",
+			value:    "/w==",
+			expected: "/w==",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Strings{
+				Base64Decode: []converter{
+					{
+						Field: "message",
+					},
+				},
+			}
+			m, _ := metric.New("test",
+				map[string]string{},
+				map[string]interface{}{"message": tt.value},
+				time.Unix(0, 0),
+			)
+
+			processed := plugin.Apply(m)
+
+			fv, ok := processed[0].GetField("message")
+			require.True(t, ok)
+			require.Equal(t, tt.expected, fv)
+		})
+	}
+}
+
+func TestValidUTF8(t *testing.T) {
+	tests := []struct {
+		name        string
+		replacement string
+		value       string
+		expected    string
+	}{
+		{
+			name:     "should keep a valid string",
+			value:    "grüß gott",
+			expected: "grüß gott",
+		},
+		{
+			name:     "should replace invalid sequences with the replacement character",
+			value:    "a\xff\xfeb\xc3",
+			expected: "a�b�",
+		},
+		{
+			name:        "should replace invalid sequences with the replacement",
+			replacement: "?",
+			value:       "\xffa\xc3\x28b",
+			expected:    "?a?(b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Strings{
+				ValidUTF8: []converter{
+					{
+						Tag:         "*",
+						Replacement: tt.replacement,
+					},
+				},
+			}
+			m, _ := metric.New("test",
+				map[string]string{"message": tt.value},
+				map[string]interface{}{"value": 42},
+				time.Unix(0, 0),
+			)
+
+			processed := plugin.Apply(m)
+
+			tv, ok := processed[0].GetTag("message")
+			require.True(t, ok)
+			require.Equal(t, tt.expected, tv)
+		})
+	}
+}
+
+func TestLeftAndPadding(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Strings
+		value    string
+		expected string
+	}{
+		{
+			name: "should truncate to width characters",
+			plugin: &Strings{
+				Left: []converter{{Field: "message", Width: 4}},
+			},
+			value:    "grüß gott",
+			expected: "grüß",
+		},
+		{
+			name: "should keep a string shorter than width",
+			plugin: &Strings{
+				Left: []converter{{Field: "message", Width: 20}},
+			},
+			value:    "grüß gott",
+			expected: "grüß gott",
+		},
+		{
+			name: "should pad on the left",
+			plugin: &Strings{
+				PadLeft: []converter{{Field: "message", Width: 5, Padding: "0"}},
+			},
+			value:    "42",
+			expected: "00042",
+		},
+		{
+			name: "should pad on the right with spaces by default",
+			plugin: &Strings{
+				PadRight: []converter{{Field: "message", Width: 5}},
+			},
+			value:    "42",
+			expected: "42   ",
+		},
+		{
+			name: "should pad with multiple characters",
+			plugin: &Strings{
+				PadRight: []converter{{Field: "message", Width: 6, Padding: "-="}},
+			},
+			value:    "ab",
+			expected: "ab-=-=",
+		},
+		{
+			name: "should not pad a string longer than width",
+			plugin: &Strings{
+				PadLeft: []converter{{Field: "message", Width: 2, Padding: "0"}},
+			},
+			value:    "12345",
+			expected: "12345",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := metric.New("test",
+				map[string]string{},
+				map[string]interface{}{"message": tt.value},
+				time.Unix(0, 0),
+			)
+
+			processed := tt.plugin.Apply(m)
+
+			fv, ok := processed[0].GetField("message")
+			require.True(t, ok)
+			require.Equal(t, tt.expected, fv)
+		})
+	}
+}
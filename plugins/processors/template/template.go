@@ -0,0 +1,98 @@
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"text/template"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Tag to set with the output of the template.
+  tag = "topic"
+
+  ## Field to set with the output of the template, instead of a tag.
+  # field = "topic"
+
+  ## If true, the measurement name is set to the output of the template,
+  ## instead of a tag or field.
+  # measurement = false
+
+  ## Go template used to create the value.  In order to ease TOML escaping
+  ## requirements, you may wish to use single quotes around the template
+  ## string.  The template has access to the metric via .Name, .Tag "key",
+  ## .Field "key", .Tags, .Fields and .Time
+  template = '{{ .Tag "hostname" }}.{{ .Tag "level" }}'
+`
+
+type TemplateProcessor struct {
+	Tag         string `toml:"tag"`
+	Field       string `toml:"field"`
+	Measurement bool   `toml:"measurement"`
+	Template    string `toml:"template"`
+
+	tmpl *template.Template
+}
+
+func (r *TemplateProcessor) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *TemplateProcessor) Description() string {
+	return "Uses a Go template to create a new tag, field or measurement name"
+}
+
+// Init checks that exactly one of tag, field or measurement is set and parses
+// the template.
+func (r *TemplateProcessor) Init() error {
+	var targets int
+	for _, set := range []bool{r.Tag != "", r.Field != "", r.Measurement} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return fmt.Errorf("exactly one of tag, field or measurement must be set")
+	}
+
+	tmpl, err := template.New("template").Parse(r.Template)
+	if err != nil {
+		return fmt.Errorf("could not parse template: %v", err)
+	}
+	r.tmpl = tmpl
+	return nil
+}
+
+func (r *TemplateProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	// for each metric in "in" array
+	for _, metric := range in {
+		var b bytes.Buffer
+		newM := TemplateMetric{metric}
+
+		// supply TemplateMetric and Template from configuration to Template.Execute
+		err := r.tmpl.Execute(&b, &newM)
+		if err != nil {
+			log.Printf("E! [processors.template] could not execute template: %v", err)
+			continue
+		}
+
+		switch {
+		case r.Measurement:
+			metric.SetName(b.String())
+		case r.Field != "":
+			metric.AddField(r.Field, b.String())
+		case r.Tag != "":
+			metric.AddTag(r.Tag, b.String())
+		}
+	}
+	return in
+}
+
+func init() {
+	processors.Add("template", func() telegraf.Processor {
+		return &TemplateProcessor{}
+	})
+}
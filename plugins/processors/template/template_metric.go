@@ -0,0 +1,45 @@
+package template
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// TemplateMetric is the data passed to the template, it gives read-only
+// access to the metric.
+type TemplateMetric struct {
+	metric telegraf.Metric
+}
+
+// Name returns the measurement name.
+func (m *TemplateMetric) Name() string {
+	return m.metric.Name()
+}
+
+// Tag returns the value of the tag or an empty string if it does not exist.
+func (m *TemplateMetric) Tag(key string) string {
+	tagString, _ := m.metric.GetTag(key)
+	return tagString
+}
+
+// Field returns the value of the field or nil if it does not exist.
+func (m *TemplateMetric) Field(key string) interface{} {
+	field, _ := m.metric.GetField(key)
+	return field
+}
+
+// Tags returns all tags of the metric.
+func (m *TemplateMetric) Tags() map[string]string {
+	return m.metric.Tags()
+}
+
+// Fields returns all fields of the metric.
+func (m *TemplateMetric) Fields() map[string]interface{} {
+	return m.metric.Fields()
+}
+
+// Time returns the timestamp of the metric.
+func (m *TemplateMetric) Time() time.Time {
+	return m.metric.Time()
+}
@@ -0,0 +1,102 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(tags map[string]string, fields map[string]interface{}) telegraf.Metric {
+	m, _ := metric.New("cpu", tags, fields, time.Unix(1560540094, 0))
+	return m
+}
+
+func TestTagTemplateConcatenate(t *testing.T) {
+	plugin := TemplateProcessor{
+		Tag:      "service",
+		Template: `{{ .Tag "app" }}-{{ .Tag "env" }}`,
+	}
+
+	m := newMetric(map[string]string{"app": "web", "env": "prod"}, map[string]interface{}{"value": 42})
+	require.NoError(t, plugin.Init())
+	result := plugin.Apply(m)
+	assert.Equal(t, map[string]string{"app": "web", "env": "prod", "service": "web-prod"}, result[0].Tags())
+}
+
+func TestMetricMissingTagsIsNotLost(t *testing.T) {
+	plugin := TemplateProcessor{
+		Tag:      "topic",
+		Template: `{{ .Tag "hostname" }}.{{ .Tag "level" }}`,
+	}
+
+	m1 := newMetric(map[string]string{"hostname": "localhost", "level": "debug"}, map[string]interface{}{"value": 42})
+	m2 := newMetric(map[string]string{"hostname": "localhost"}, map[string]interface{}{"value": 42})
+	require.NoError(t, plugin.Init())
+	result := plugin.Apply(m1, m2)
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, "localhost.debug", result[0].Tags()["topic"])
+	assert.Equal(t, "localhost.", result[1].Tags()["topic"])
+}
+
+func TestFieldAndTimeTemplate(t *testing.T) {
+	plugin := TemplateProcessor{
+		Field:    "summary",
+		Template: `{{ .Name }} {{ .Field "value" }} {{ .Time.UTC.Format "2006-01-02" }}`,
+	}
+
+	m := newMetric(map[string]string{}, map[string]interface{}{"value": 42})
+	require.NoError(t, plugin.Init())
+	result := plugin.Apply(m)
+	assert.Equal(t, map[string]interface{}{"value": int64(42), "summary": "cpu 42 2019-06-14"}, result[0].Fields())
+}
+
+func TestMeasurementTemplate(t *testing.T) {
+	plugin := TemplateProcessor{
+		Measurement: true,
+		Template:    `{{ .Name }}_{{ .Tag "cpu" }}`,
+	}
+
+	m := newMetric(map[string]string{"cpu": "cpu0"}, map[string]interface{}{"value": 42})
+	require.NoError(t, plugin.Init())
+	result := plugin.Apply(m)
+	assert.Equal(t, "cpu_cpu0", result[0].Name())
+}
+
+func TestRangeOverTags(t *testing.T) {
+	plugin := TemplateProcessor{
+		Tag:      "all",
+		Template: `{{ range $k, $v := .Tags }}{{ $k }}={{ $v }};{{ end }}`,
+	}
+
+	m := newMetric(map[string]string{"a": "1", "b": "2"}, map[string]interface{}{"value": 42})
+	require.NoError(t, plugin.Init())
+	result := plugin.Apply(m)
+	assert.Equal(t, "a=1;b=2;", result[0].Tags()["all"])
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	plugin := TemplateProcessor{
+		Tag:      "topic",
+		Template: `{{ .Tag "hostname" }`,
+	}
+	require.Error(t, plugin.Init())
+}
+
+func TestInvalidTarget(t *testing.T) {
+	plugin := TemplateProcessor{
+		Template: `{{ .Name }}`,
+	}
+	require.Error(t, plugin.Init())
+
+	plugin = TemplateProcessor{
+		Tag:         "topic",
+		Measurement: true,
+		Template:    `{{ .Name }}`,
+	}
+	require.Error(t, plugin.Init())
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -191,6 +192,73 @@ func (a *Accumulator) Delivered() <-chan telegraf.DeliveryInfo {
 	return a.delivered
 }
 
+// AcceptingAccumulator is an Accumulator that accepts tracking metrics as
+// soon as they are added, as if they were written by an output.
+type AcceptingAccumulator struct {
+	Accumulator
+	accepted chan telegraf.DeliveryInfo
+}
+
+func (a *AcceptingAccumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	a.accepted = make(chan telegraf.DeliveryInfo, maxTracked)
+	return a
+}
+
+func (a *AcceptingAccumulator) AddTrackingMetric(m telegraf.Metric) telegraf.TrackingID {
+	return a.AddTrackingMetricGroup([]telegraf.Metric{m})
+}
+
+func (a *AcceptingAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	group, id := metric.WithGroupTracking(group, func(info telegraf.DeliveryInfo) {
+		a.accepted <- info
+	})
+	for _, m := range group {
+		a.AddMetric(m)
+		m.Accept()
+	}
+	return id
+}
+
+func (a *AcceptingAccumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	return a.accepted
+}
+
+// DeliveryAccumulator is an Accumulator that passes tracking metrics to the
+// Tracked channel instead of adding them, so that tests can accept or reject
+// them as an output would.
+type DeliveryAccumulator struct {
+	Accumulator
+	Tracked   chan telegraf.Metric
+	delivered chan telegraf.DeliveryInfo
+}
+
+func NewDeliveryAccumulator() *DeliveryAccumulator {
+	return &DeliveryAccumulator{Tracked: make(chan telegraf.Metric, 100)}
+}
+
+func (a *DeliveryAccumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	a.delivered = make(chan telegraf.DeliveryInfo, maxTracked)
+	return a
+}
+
+func (a *DeliveryAccumulator) AddTrackingMetric(m telegraf.Metric) telegraf.TrackingID {
+	return a.AddTrackingMetricGroup([]telegraf.Metric{m})
+}
+
+func (a *DeliveryAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	group, id := metric.WithGroupTracking(group, func(info telegraf.DeliveryInfo) {
+		a.delivered <- info
+	})
+	for _, m := range group {
+		a.Tracked <- m
+	}
+	return id
+}
+
+func (a *DeliveryAccumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	return a.delivered
+}
+
 // AddError appends the given error to Accumulator.Errors.
 func (a *Accumulator) AddError(err error) {
 	if err == nil {